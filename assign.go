@@ -0,0 +1,237 @@
+package jq
+
+import (
+	"bytes"
+	"sort"
+)
+
+// assignOps combine the current value at a path with the value of the right-hand side of an assignment
+var assignOps = map[string]binaryOp{
+	"=":  func(_, v []byte) ([]byte, error) { return v, nil },
+	"+=": add,
+	"-=": subtract,
+	"*=": multiply,
+	"/=": divide,
+	"%=": modulo,
+	"//=": func(current, v []byte) ([]byte, error) {
+		if truthy(current) {
+			return current, nil
+		}
+		return v, nil
+	},
+}
+
+// assignNode implements = and the arithmetic update-assignment operators. The value is evaluated against the input
+// and, for each value it produces, fn combines it with the current value at each path selected by target.
+type assignNode struct {
+	op     string
+	fn     binaryOp
+	target node
+	value  node
+}
+
+func (n *assignNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	return n.value.eval(e, in, func(v []byte) error {
+		targets, err := collectTargets(e, n.target, in)
+		if err != nil {
+			return err
+		}
+		out, err := update(in, targets, func(current []byte) ([]byte, bool, error) {
+			result, err := n.fn(current, v)
+			return result, true, err
+		})
+		if err != nil {
+			return err
+		}
+		return fn(out)
+	})
+}
+
+// updateNode implements |=; the first value update produces for the current value at each path selected by target
+// replaces it, and paths for which update produces no value are deleted
+type updateNode struct {
+	target node
+	update node
+}
+
+func (n *updateNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	targets, err := collectTargets(e, n.target, in)
+	if err != nil {
+		return err
+	}
+	out, err := update(in, targets, func(current []byte) ([]byte, bool, error) {
+		return first(e, n.update, current)
+	})
+	if err != nil {
+		return err
+	}
+	return fn(out)
+}
+
+// target is a location selected by a path expression along with its value; start and end locate the value within
+// the input when it is found there and are -1 otherwise
+type target struct {
+	p          path
+	value      []byte
+	start, end int
+}
+
+func collectTargets(e *env, n node, in []byte) ([]target, error) {
+	var targets []target
+	err := evalPath(e, n, in, nil, func(v []byte, p path) error {
+		targets = append(targets, target{p: p, value: v, start: -1, end: -1})
+		return nil
+	})
+	return targets, err
+}
+
+// update replaces the value at each target with the value computed by fn, deleting the targets for which fn reports
+// no value. Targets that can be updated independently of one another are spliced into the input in a single pass;
+// otherwise each target is read and set in turn so that later targets observe the updates of earlier ones.
+func update(in []byte, targets []target, fn func(current []byte) ([]byte, bool, error)) ([]byte, error) {
+	var deleted [][]byte
+	out := in
+
+	if independent(in, targets) {
+		edits := make([]target, 0, len(targets))
+		for _, t := range targets {
+			v, ok, err := fn(t.value)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				deleted = append(deleted, t.p.bytes())
+				continue
+			}
+			t.value = v
+			edits = append(edits, t)
+		}
+
+		var err error
+		if out, err = setTargets(in, edits); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, t := range targets {
+			current, err := getPath(out, t.p)
+			if err != nil {
+				return nil, err
+			}
+			v, ok, err := fn(current)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				deleted = append(deleted, t.p.bytes())
+				continue
+			}
+			if out, err = setPath(out, t.p, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(deleted) == 0 {
+		return out, nil
+	}
+	return delPaths(out, appendArray(nil, deleted))
+}
+
+// independent reports whether the targets can be updated in any order: none of their paths holds a slice, the values
+// found within in don't overlap, and no path leading to a value missing from in is a prefix of another. It records
+// the position of each value found within in.
+func independent(in []byte, targets []target) bool {
+	var located []int
+	missing := false
+	for i := range targets {
+		t := &targets[i]
+		for _, elem := range t.p {
+			if elem[0] == '{' {
+				return false
+			}
+		}
+
+		if t.start = offsetOf(in, t.value); t.start < 0 {
+			t.end = -1
+			missing = true
+			continue
+		}
+		t.end = t.start + len(t.value)
+		located = append(located, i)
+	}
+
+	sort.Slice(located, func(i, j int) bool { return targets[located[i]].start < targets[located[j]].start })
+	for i := 1; i < len(located); i++ {
+		if targets[located[i]].start < targets[located[i-1]].end {
+			return false
+		}
+	}
+
+	if !missing {
+		return true
+	}
+	for i := range targets {
+		if targets[i].start >= 0 {
+			continue
+		}
+		for j := range targets {
+			if i != j && (isPrefix(targets[i].p, targets[j].p) || isPrefix(targets[j].p, targets[i].p)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isPrefix reports whether the path a is a prefix of, or equal to, the path b
+func isPrefix(a, b path) bool {
+	if len(a) > len(b) {
+		return false
+	}
+	for i := range a {
+		if bytes.Equal(a[i], b[i]) {
+			continue
+		}
+		if c, err := compareValues(a[i], b[i]); err != nil || c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// setTargets sets the value of each target, splicing the values located within in and then setting the values
+// missing from in by path
+func setTargets(in []byte, targets []target) ([]byte, error) {
+	var located, missing []target
+	size := len(in)
+	for _, t := range targets {
+		if t.start < 0 {
+			missing = append(missing, t)
+			continue
+		}
+		located = append(located, t)
+		size += len(t.value) - (t.end - t.start)
+	}
+
+	out := in
+	if len(located) > 0 {
+		sort.Slice(located, func(i, j int) bool { return located[i].start < located[j].start })
+
+		out = make([]byte, 0, size)
+		pos := 0
+		for _, t := range located {
+			out = append(out, in[pos:t.start]...)
+			out = append(out, t.value...)
+			pos = t.end
+		}
+		out = append(out, in[pos:]...)
+	}
+
+	for _, t := range missing {
+		var err error
+		if out, err = setPath(out, t.p, t.value); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,106 @@
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkAssign(t *testing.B) {
+	op := jq.Must(jq.Parse(`.items[].secret = "***"`))
+	data := []byte(`{"items": [{"id": 1, "secret": "a"}, {"id": 2, "secret": "b"}, {"id": 3, "secret": "c"}]}`)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func BenchmarkDel(t *testing.B) {
+	op := jq.Must(jq.Parse(`del(.items[].secret)`))
+	data := []byte(`{"items": [{"id": 1, "secret": "a"}, {"id": 2, "secret": "b"}, {"id": 3, "secret": "c"}]}`)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestAssign(t *testing.T) {
+	doc := `{
+  "user": {"name": "alice", "token": "abc"},
+  "items": [ {"id": 1, "qty": 2}, {"id": 2, "qty": 5} ]
+}`
+
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+		HasError bool
+	}{
+		"assign": {
+			In:       doc,
+			Op:       `.user.token = "***"`,
+			Expected: "{\n  \"user\": {\"name\": \"alice\", \"token\": \"***\"},\n  \"items\": [ {\"id\": 1, \"qty\": 2}, {\"id\": 2, \"qty\": 5} ]\n}",
+		},
+		"assign iterate": {
+			In:       doc,
+			Op:       `.items[].qty = 0`,
+			Expected: "{\n  \"user\": {\"name\": \"alice\", \"token\": \"abc\"},\n  \"items\": [ {\"id\": 1, \"qty\": 0}, {\"id\": 2, \"qty\": 0} ]\n}",
+		},
+		"assign from input":  {In: `{"a": 1, "b": 2}`, Op: `.a = .b`, Expected: `{"a": 2, "b": 2}`},
+		"assign new key":     {In: `{"a": 1}`, Op: `.b.c = 2`, Expected: `{"a": 1,"b":{"c":2}}`},
+		"assign several":     {In: `{"a": 1}`, Op: `.b = (1, 2)`, Expected: `[{"a": 1,"b":1},{"a": 1,"b":2}]`},
+		"assign both":        {In: `{"a": {"b": 1}}`, Op: `(.a, .a.c) = 5`, HasError: true},
+		"assign invalid":     {In: `{"a": 1}`, Op: `.a + 1 = 2`, HasError: true},
+		"update":             {In: `{"a": 1, "b": [1, 2]}`, Op: `.b[] |= . * 10`, Expected: `{"a": 1, "b": [10, 20]}`},
+		"update twice":       {In: `{"a": 1}`, Op: `(.a, .a) |= . + 1`, Expected: `{"a": 3}`},
+		"update nested":      {In: `{"a": {"b": 1}}`, Op: `(.a, .a.b) |= (if type == "object" then . + {"c": 1} else . + 1 end)`, Expected: `{"a": {"b":2,"c":1}}`},
+		"update first value": {In: `{"a": 1}`, Op: `.a |= (5, 6)`, Expected: `{"a": 5}`},
+		"update empty":       {In: `[1, 5, 3, 0, 7]`, Op: `(.[] | select(. >= 2)) |= empty`, Expected: `[1, 0]`},
+		"update missing":     {In: `{}`, Op: `.a |= 1`, Expected: `{"a":1}`},
+		"update slice":       {In: `[1, 2, 3]`, Op: `.[1:] |= map(. * 2)`, Expected: `[1,4,6]`},
+		"add":                {In: `{"a": 1}`, Op: `.a += 2`, Expected: `{"a": 3}`},
+		"subtract":           {In: `{"a": [1, 2]}`, Op: `.a -= [1]`, Expected: `{"a": [2]}`},
+		"multiply":           {In: `{"a": 3}`, Op: `.a *= 2`, Expected: `{"a": 6}`},
+		"divide":             {In: `{"a": 3}`, Op: `.a /= 2`, Expected: `{"a": 1.5}`},
+		"modulo":             {In: `{"a": 7}`, Op: `.a %= 4`, Expected: `{"a": 3}`},
+		"add uses input":     {In: `{"a": 1, "b": 10}`, Op: `.a += .b`, Expected: `{"a": 11, "b": 10}`},
+		"alternative":        {In: `{"a": null, "b": 0}`, Op: `(.a, .b) //= 5`, Expected: `{"a": 5, "b": 0}`},
+		"precedence":         {In: `{"a": false}`, Op: `.a = 1 // 2`, Expected: `{"a": 1}`},
+		"chained":            {In: `{}`, Op: `.a = .b = 1`, HasError: true},
+		"del":                {In: `{"a": 1, "b": 2, "c": 3}`, Op: `del(.b)`, Expected: `{"a": 1, "c": 3}`},
+		"del last":           {In: `{"a": 1, "b": 2, "c": 3}`, Op: `del(.c)`, Expected: `{"a": 1, "b": 2}`},
+		"del several":        {In: `{"a": 1, "b": 2, "c": 3}`, Op: `del(.a, .c)`, Expected: `{"b": 2}`},
+		"del all":            {In: `{"a": 1, "b": 2}`, Op: `del(.a, .b)`, Expected: `{}`},
+		"del iterate":        {In: doc, Op: `del(.items[].qty)`, Expected: "{\n  \"user\": {\"name\": \"alice\", \"token\": \"abc\"},\n  \"items\": [ {\"id\": 1}, {\"id\": 2} ]\n}"},
+		"del elements":       {In: `[0, 1, 2, 3, 4]`, Op: `del(.[1, 3, 4])`, Expected: `[0, 2]`},
+		"del negative":       {In: `[0, 1, 2]`, Op: `del(.[-1])`, Expected: `[0, 1]`},
+		"del select":         {In: `[1, 5, 2]`, Op: `del(.[] | select(. > 1))`, Expected: `[1]`},
+		"del nested":         {In: `{"a": {"b": 1}}`, Op: `del(.a, .a.b)`, Expected: `{}`},
+		"del missing":        {In: `{"a": 1}`, Op: `del(.b.c)`, Expected: `{"a": 1}`},
+		"del slice":          {In: `[0, 1, 2, 3]`, Op: `del(.[1:2])`, Expected: `[0,3]`},
+		"del root":           {In: `{"a": 1}`, Op: `del(.)`, Expected: `null`},
+		"del invalid":        {In: `{"a": 1}`, Op: `del(.[0])`, HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op)
+			if tc.HasError && err != nil {
+				return
+			}
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package jq
+
+import "fmt"
+
+// binding is a variable in scope; bindings form a list from the innermost scope outwards
+type binding struct {
+	name  string
+	value []byte
+	next  *binding
+}
+
+// bind returns a copy of the environment with the variable name bound to value
+func (e *env) bind(name string, value []byte) *env {
+	inner := *e
+	inner.vars = &binding{name: name, value: value, next: e.vars}
+	return &inner
+}
+
+func (e *env) lookup(name string) ([]byte, bool) {
+	for b := e.vars; b != nil; b = b.next {
+		if b.name == name {
+			return b.value, true
+		}
+	}
+	return nil, false
+}
+
+// variableNode produces the value of a variable
+type variableNode struct {
+	name string
+}
+
+func (n *variableNode) eval(e *env, _ []byte, fn func([]byte) error) error {
+	v, ok := e.lookup(n.name)
+	if !ok {
+		return fmt.Errorf("$%v is not defined", n.name)
+	}
+	return fn(v)
+}
+
+// asNode implements source as $x | body: body is evaluated against the input once for each value produced by
+// source, with the value bound by the pattern
+type asNode struct {
+	source  node
+	pattern pattern
+	body    node
+}
+
+func (n *asNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	return n.source.eval(e, in, func(v []byte) error {
+		return n.pattern.bind(e, in, v, func(inner *env) error {
+			return n.body.eval(inner, in, fn)
+		})
+	})
+}
+
+func (n *asNode) evalPath(e *env, in []byte, p path, fn func([]byte, path) error) error {
+	return n.source.eval(e, in, func(v []byte) error {
+		return n.pattern.bind(e, in, v, func(inner *env) error {
+			return evalPath(inner, n.body, in, p, fn)
+		})
+	})
+}
+
+// pattern destructures a value into variables; bind calls fn with the environment holding the variables for each
+// way the pattern matches, as keys computed by expressions may produce several values
+type pattern interface {
+	bind(e *env, in, v []byte, fn func(*env) error) error
+	variables() []string
+}
+
+// variablePattern binds the whole value to a variable
+type variablePattern struct {
+	name string
+}
+
+func (p *variablePattern) bind(e *env, _, v []byte, fn func(*env) error) error {
+	return fn(e.bind(p.name, v))
+}
+
+func (p *variablePattern) variables() []string {
+	return []string{p.name}
+}
+
+// arrayPattern binds the elements of an array to patterns by position; missing elements are null
+type arrayPattern struct {
+	elements []pattern
+}
+
+func (p *arrayPattern) bind(e *env, in, v []byte, fn func(*env) error) error {
+	if k, err := kindOf(v); err != nil || (k != kindArray && k != kindNull) {
+		return fmt.Errorf("cannot index %v with number", k)
+	}
+
+	var bindElement func(e *env, i int) error
+	bindElement = func(e *env, i int) error {
+		if i == len(p.elements) {
+			return fn(e)
+		}
+		element, err := index(v, number(float64(i)))
+		if err != nil {
+			return err
+		}
+		return p.elements[i].bind(e, in, element, func(inner *env) error {
+			return bindElement(inner, i+1)
+		})
+	}
+	return bindElement(e, 0)
+}
+
+func (p *arrayPattern) variables() []string {
+	var names []string
+	for _, element := range p.elements {
+		names = append(names, element.variables()...)
+	}
+	return names
+}
+
+// objectPattern binds the values of an object to patterns by key
+type objectPattern struct {
+	entries []objectPatternEntry
+}
+
+// objectPatternEntry destructures the value at key; when variable is set, as in {$name} or {$name: pattern}, the
+// value is also bound to the variable of the same name as the key
+type objectPatternEntry struct {
+	key      node
+	variable string
+	value    pattern
+}
+
+func (p *objectPattern) bind(e *env, in, v []byte, fn func(*env) error) error {
+	var bindEntry func(e *env, i int) error
+	bindEntry = func(e *env, i int) error {
+		if i == len(p.entries) {
+			return fn(e)
+		}
+		entry := p.entries[i]
+		return entry.key.eval(e, in, func(key []byte) error {
+			if k, err := kindOf(key); err != nil || k != kindString {
+				return fmt.Errorf("cannot index object with %v", k)
+			}
+			value, err := index(v, key)
+			if err != nil {
+				return err
+			}
+
+			inner := e
+			if entry.variable != "" {
+				inner = inner.bind(entry.variable, value)
+			}
+			if entry.value == nil {
+				return bindEntry(inner, i+1)
+			}
+			return entry.value.bind(inner, in, value, func(inner *env) error {
+				return bindEntry(inner, i+1)
+			})
+		})
+	}
+	return bindEntry(e, 0)
+}
+
+func (p *objectPattern) variables() []string {
+	var names []string
+	for _, entry := range p.entries {
+		if entry.variable != "" {
+			names = append(names, entry.variable)
+		}
+		if entry.value != nil {
+			names = append(names, entry.value.variables()...)
+		}
+	}
+	return names
+}
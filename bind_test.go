@@ -0,0 +1,57 @@
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVariables(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+		HasError bool
+	}{
+		"bind":             {In: `{"a": 1, "b": 2}`, Op: `.a as $x | .b + $x`, Expected: `3`},
+		"bind each":        {In: `[1, 2]`, Op: `.[] as $x | $x * 10`, Expected: `[10,20]`},
+		"bind nested":      {In: `{"a": 1, "b": 2}`, Op: `.a as $x | .b as $y | [$x, $y]`, Expected: `[1,2]`},
+		"bind shadow":      {In: `null`, Op: `1 as $x | (2 as $x | $x), $x`, Expected: `[2,1]`},
+		"bind in term":     {In: `{"a": 3}`, Op: `1 + .a as $x | $x`, Expected: `4`},
+		"bind in string":   {In: `{"a": "x"}`, Op: `.a as $v | "v=\($v)"`, Expected: `"v=x"`},
+		"bind in object":   {In: `{"a": 1}`, Op: `.a as $a | {$a, b: $a}`, Expected: `{"a":1,"b":1}`},
+		"bind in map":      {In: `{"k": 10, "l": [1, 2]}`, Op: `.k as $k | .l | map(. + $k)`, Expected: `[11,12]`},
+		"bind path":        {In: `{"a": "b", "b": 1}`, Op: `path(.a as $k | .[$k])`, Expected: `["b"]`},
+		"bind assignment":  {In: `{"a": "b", "b": 1}`, Op: `.a as $k | .[$k] = 5`, Expected: `{"a": "b", "b": 5}`},
+		"array pattern":    {In: `[1, [2, 3]]`, Op: `. as [$a, [$b, $c]] | $a + $b + $c`, Expected: `6`},
+		"array missing":    {In: `[1]`, Op: `. as [$a, $b] | $b`, Expected: `null`},
+		"object pattern":   {In: `{"a": 1, "b": {"c": 2}}`, Op: `. as {a: $x, b: {c: $y}} | $x + $y`, Expected: `3`},
+		"object shorthand": {In: `{"a": 1, "b": [2]}`, Op: `. as {$a, $b: [$c]} | [$a, $b, $c]`, Expected: `[1,[2],2]`},
+		"object string":    {In: `{"a b": 1}`, Op: `. as {"a b": $x} | $x`, Expected: `1`},
+		"object computed":  {In: `{"k": "a", "a": 5}`, Op: `. as {(.k): $x} | $x`, Expected: `5`},
+		"pattern mismatch": {In: `{"a": 1}`, Op: `. as [$a] | $a`, HasError: true},
+		"undefined":        {In: `null`, Op: `$x`, HasError: true},
+		"out of scope":     {In: `null`, Op: `(1 as $x | $x), $x`, HasError: true},
+		"missing pipe":     {In: `null`, Op: `1 as $x`, HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op)
+			if tc.HasError && err != nil {
+				return
+			}
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}
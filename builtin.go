@@ -0,0 +1,329 @@
+package jq
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// builtin is a function callable from a selector; eval receives the function's arguments unevaluated so that they can
+// be used as filters, as map(f) and select(f) require
+type builtin struct {
+	eval func(e *env, in []byte, args []node, fn func([]byte) error) error
+
+	// path, when set, allows the function to be used within path expressions such as path(f) and assignments
+	path func(e *env, in []byte, p path, args []node, fn func([]byte, path) error) error
+
+	// stream reports that the function may produce several values regardless of its arguments
+	stream bool
+
+	// aggregate reports that the function consumes every value of its arguments to produce at most one value
+	aggregate bool
+}
+
+// builtins holds the functions available to selectors keyed by name and arity, such as "select/1"
+var builtins = map[string]*builtin{}
+
+func define(name string, arity int, b *builtin) {
+	builtins[name+"/"+strconv.Itoa(arity)] = b
+}
+
+// cartesian calls fn with every combination of the values produced by args; the first argument varies fastest
+func cartesian(e *env, in []byte, args []node, fn func(values [][]byte) error) error {
+	values := make([][]byte, len(args))
+
+	var walk func(i int) error
+	walk = func(i int) error {
+		if i < 0 {
+			return fn(values)
+		}
+		return args[i].eval(e, in, func(v []byte) error {
+			values[i] = v
+			return walk(i - 1)
+		})
+	}
+	return walk(len(args) - 1)
+}
+
+// function adapts a Go function of the input and the values of its arguments into a builtin
+func function(f func(in []byte, args [][]byte) ([]byte, error)) *builtin {
+	return &builtin{
+		eval: func(e *env, in []byte, args []node, fn func([]byte) error) error {
+			return cartesian(e, in, args, func(values [][]byte) error {
+				v, err := f(in, values)
+				if err != nil {
+					return err
+				}
+				return fn(v)
+			})
+		},
+	}
+}
+
+// init defines the Go builtins and then parses the prelude, which is linked against them. The builtins of each file are
+// defined here in turn rather than by init functions of their own, which would run in the order of the file names.
+func init() {
+	for _, defineAll := range []func(){
+		coreBuiltins, controlBuiltins, dateBuiltins, inputBuiltins, mathBuiltins, moduleBuiltins,
+		pathBuiltins, searchBuiltins, sortBuiltins, sqlBuiltins, streamBuiltins, stringBuiltins, walkBuiltins,
+	} {
+		defineAll()
+	}
+	loadPrelude()
+}
+
+func coreBuiltins() {
+	define("empty", 0, &builtin{
+		eval: func(*env, []byte, []node, func([]byte) error) error { return nil },
+		path: func(*env, []byte, path, []node, func([]byte, path) error) error { return nil },
+	})
+
+	define("not", 0, function(func(in []byte, _ [][]byte) ([]byte, error) {
+		return boolValue(!truthy(in)), nil
+	}))
+
+	define("select", 1, &builtin{
+		eval: func(e *env, in []byte, args []node, fn func([]byte) error) error {
+			return args[0].eval(e, in, func(c []byte) error {
+				if !truthy(c) {
+					return nil
+				}
+				return fn(in)
+			})
+		},
+		path: func(e *env, in []byte, p path, args []node, fn func([]byte, path) error) error {
+			return args[0].eval(e, in, func(c []byte) error {
+				if !truthy(c) {
+					return nil
+				}
+				return fn(in, p)
+			})
+		},
+	})
+
+	define("recurse", 0, &builtin{
+		eval: func(e *env, in []byte, _ []node, fn func([]byte) error) error {
+			return recurse(in, nil, func(v []byte, _ path) error { return fn(v) })
+		},
+		path: func(e *env, in []byte, p path, _ []node, fn func([]byte, path) error) error {
+			return recurse(in, p, fn)
+		},
+		stream: true,
+	})
+
+	define("recurse", 1, &builtin{
+		eval: func(e *env, in []byte, args []node, fn func([]byte) error) error {
+			return recurseWith(e, in, args[0], nil, fn)
+		},
+		path: func(e *env, in []byte, p path, args []node, fn func([]byte, path) error) error {
+			return recurseWithPath(e, in, p, args[0], nil, fn)
+		},
+		stream: true,
+	})
+
+	define("recurse", 2, &builtin{
+		eval: func(e *env, in []byte, args []node, fn func([]byte) error) error {
+			return recurseWith(e, in, args[0], args[1], fn)
+		},
+		path: func(e *env, in []byte, p path, args []node, fn func([]byte, path) error) error {
+			return recurseWithPath(e, in, p, args[0], args[1], fn)
+		},
+		stream: true,
+	})
+
+	define("length", 0, function(func(in []byte, _ [][]byte) ([]byte, error) {
+		return length(in)
+	}))
+
+	define("keys", 0, function(func(in []byte, _ [][]byte) ([]byte, error) {
+		return keys(in, true)
+	}))
+
+	define("keys_unsorted", 0, function(func(in []byte, _ [][]byte) ([]byte, error) {
+		return keys(in, false)
+	}))
+
+	define("map", 1, &builtin{
+		eval: func(e *env, in []byte, args []node, fn func([]byte) error) error {
+			return (&arrayNode{body: &pipeNode{lhs: &iterateNode{target: identityNode{}}, rhs: args[0]}}).eval(e, in, fn)
+		},
+		aggregate: true,
+	})
+
+	define("add", 0, function(func(in []byte, _ [][]byte) ([]byte, error) {
+		return addAll(in)
+	}))
+
+	define("first", 1, &builtin{
+		eval: func(e *env, in []byte, args []node, fn func([]byte) error) error {
+			v, ok, err := first(e, args[0], in)
+			if err != nil || !ok {
+				return err
+			}
+			return fn(v)
+		},
+		path: func(e *env, in []byte, p path, args []node, fn func([]byte, path) error) error {
+			var v []byte
+			var vp path
+			done := &stop{}
+			err := evalPath(e, args[0], in, p, func(pv []byte, pp path) error {
+				v, vp = pv, pp
+				return done
+			})
+			if err != done {
+				return err
+			}
+			return fn(v, vp)
+		},
+		aggregate: true,
+	})
+
+	define("range", 2, &builtin{
+		eval: func(e *env, in []byte, args []node, fn func([]byte) error) error {
+			return args[0].eval(e, in, func(from []byte) error {
+				return args[1].eval(e, in, func(upto []byte) error {
+					return countUp(from, upto, fn)
+				})
+			})
+		},
+		stream: true,
+	})
+}
+
+// countUp calls fn with the numbers from from up to but excluding upto
+func countUp(from, upto []byte, fn func([]byte) error) error {
+	kf, err := kindOf(from)
+	if err != nil {
+		return err
+	}
+	ku, err := kindOf(upto)
+	if err != nil {
+		return err
+	}
+	if kf != kindNumber || ku != kindNumber {
+		return fmt.Errorf("range bounds must be numeric")
+	}
+
+	f, err := toFloat(from)
+	if err != nil {
+		return err
+	}
+	u, err := toFloat(upto)
+	if err != nil {
+		return err
+	}
+	for ; f < u; f++ {
+		if err := fn(number(f)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recurse calls fn with in and every value nested within it, depth first
+func recurse(in []byte, p path, fn func([]byte, path) error) error {
+	if err := fn(in, p); err != nil {
+		return err
+	}
+
+	switch in[0] {
+	case '[', '{':
+		return eachChild(in, p, func(v []byte, vp path) error {
+			return recurse(v, vp, fn)
+		})
+	default:
+		return nil
+	}
+}
+
+// recurseWith calls fn with in and the values produced by repeatedly applying f, stopping at values that fail cond
+func recurseWith(e *env, in []byte, f, cond node, fn func([]byte) error) error {
+	if err := fn(in); err != nil {
+		return err
+	}
+
+	return f.eval(e, in, func(v []byte) error {
+		if cond == nil {
+			return recurseWith(e, v, f, cond, fn)
+		}
+		return cond.eval(e, v, func(c []byte) error {
+			if !truthy(c) {
+				return nil
+			}
+			return recurseWith(e, v, f, cond, fn)
+		})
+	})
+}
+
+func recurseWithPath(e *env, in []byte, p path, f, cond node, fn func([]byte, path) error) error {
+	if err := fn(in, p); err != nil {
+		return err
+	}
+
+	return evalPath(e, f, in, p, func(v []byte, vp path) error {
+		if cond == nil {
+			return recurseWithPath(e, v, vp, f, cond, fn)
+		}
+		return cond.eval(e, v, func(c []byte) error {
+			if !truthy(c) {
+				return nil
+			}
+			return recurseWithPath(e, v, vp, f, cond, fn)
+		})
+	})
+}
+
+// keys returns the keys of an object, optionally sorted, or the indices of an array
+func keys(in []byte, sorted bool) ([]byte, error) {
+	k, err := kindOf(in)
+	if err != nil {
+		return nil, err
+	}
+
+	switch k {
+	case kindObject:
+		var ms []member
+		if sorted {
+			ms, err = sortedMembers(in)
+		} else {
+			ms, err = members(in)
+		}
+		if err != nil {
+			return nil, err
+		}
+		values := make([][]byte, len(ms))
+		for i, m := range ms {
+			values[i] = m.raw
+		}
+		return appendArray(nil, values), nil
+	case kindArray:
+		n, err := count(in)
+		if err != nil {
+			return nil, err
+		}
+		dst := []byte{'['}
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = strconv.AppendInt(dst, int64(i), 10)
+		}
+		return append(dst, ']'), nil
+	default:
+		return nil, fmt.Errorf("%v (%s) has no keys", k, truncate(in))
+	}
+}
+
+// addAll adds together the elements of an array, returning null for an empty array
+func addAll(in []byte) ([]byte, error) {
+	if k, err := kindOf(in); err != nil || k != kindArray {
+		return nil, fmt.Errorf("cannot add the elements of %s", truncate(in))
+	}
+
+	result := nullValue
+	err := eachElement(in, func(_ int, v []byte) error {
+		sum, err := add(result, v)
+		result = sum
+		return err
+	})
+	return result, err
+}
@@ -0,0 +1,143 @@
+package jq
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// DefaultMaxDecompressedSize is the largest payload a decompression Op will produce unless configured otherwise
+const DefaultMaxDecompressedSize = 64 << 20
+
+// ErrDecompressedSizeExceeded is returned when a decompressed payload is larger than the configured maximum
+var ErrDecompressedSizeExceeded = errors.New("decompressed size exceeds limit")
+
+// Gunzip decompresses gzip-compressed bytes, such as those produced by B64Decode; output larger than limit bytes is
+// rejected with ErrDecompressedSizeExceeded
+func Gunzip(limit int64) OpFunc {
+	return func(in []byte) ([]byte, error) {
+		r, err := gzip.NewReader(bytes.NewReader(in))
+		if err != nil {
+			return nil, fmt.Errorf("gunzip failed: %v", err)
+		}
+		defer r.Close()
+
+		return readLimited("gunzip", r, limit)
+	}
+}
+
+// ZlibInflate decompresses zlib-compressed bytes; output larger than limit bytes is rejected with
+// ErrDecompressedSizeExceeded
+func ZlibInflate(limit int64) OpFunc {
+	return func(in []byte) ([]byte, error) {
+		r, err := zlib.NewReader(bytes.NewReader(in))
+		if err != nil {
+			return nil, fmt.Errorf("zlib_inflate failed: %v", err)
+		}
+		defer r.Close()
+
+		return readLimited("zlib_inflate", r, limit)
+	}
+}
+
+// Inflate decompresses raw deflate bytes; output larger than limit bytes is rejected with ErrDecompressedSizeExceeded
+func Inflate(limit int64) OpFunc {
+	return func(in []byte) ([]byte, error) {
+		r := flate.NewReader(bytes.NewReader(in))
+		defer r.Close()
+
+		return readLimited("inflate", r, limit)
+	}
+}
+
+// Unzstd decompresses zstd-compressed bytes; output larger than limit bytes is rejected with
+// ErrDecompressedSizeExceeded. The decoder is bounded by the limit too, so a frame header declaring a larger frame or
+// window is rejected before any memory is allocated for it.
+func Unzstd(limit int64) OpFunc {
+	return func(in []byte) ([]byte, error) {
+		// every frame reserves a window of at least zstd.MinWindowSize, so smaller limits are left to readLimited
+		bound := uint64(max(limit, zstd.MinWindowSize))
+		r, err := zstd.NewReader(bytes.NewReader(in),
+			zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(bound), zstd.WithDecoderMaxWindow(bound))
+		if err != nil {
+			return nil, fmt.Errorf("unzstd failed: %v", err)
+		}
+		defer r.Close()
+
+		data, err := readLimited("unzstd", r, limit)
+		if errors.Is(err, zstd.ErrDecoderSizeExceeded) || errors.Is(err, zstd.ErrWindowSizeExceeded) {
+			return nil, fmt.Errorf("unzstd failed: %w", ErrDecompressedSizeExceeded)
+		}
+		return data, err
+	}
+}
+
+// Gzip compresses its input with gzip; combine with B64Encode to embed the result in a JSON document
+func Gzip() OpFunc {
+	return func(in []byte) ([]byte, error) {
+		var buf bytes.Buffer
+		return compress("gzip", &buf, gzip.NewWriter(&buf), in)
+	}
+}
+
+// ZlibDeflate compresses its input with zlib
+func ZlibDeflate() OpFunc {
+	return func(in []byte) ([]byte, error) {
+		var buf bytes.Buffer
+		return compress("zlib_deflate", &buf, zlib.NewWriter(&buf), in)
+	}
+}
+
+// Deflate compresses its input with raw deflate
+func Deflate() OpFunc {
+	return func(in []byte) ([]byte, error) {
+		var buf bytes.Buffer
+		w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		if err != nil {
+			return nil, fmt.Errorf("deflate failed: %v", err)
+		}
+		return compress("deflate", &buf, w, in)
+	}
+}
+
+// Zstd compresses its input with zstd
+func Zstd() OpFunc {
+	return func(in []byte) ([]byte, error) {
+		var buf bytes.Buffer
+		w, err := zstd.NewWriter(&buf, zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("zstd failed: %v", err)
+		}
+		return compress("zstd", &buf, w, in)
+	}
+}
+
+// readLimited reads r to completion, failing once more than limit bytes have been produced
+func readLimited(name string, r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("%v failed: %w", name, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%v failed: %w", name, ErrDecompressedSizeExceeded)
+	}
+
+	return data, nil
+}
+
+func compress(name string, buf *bytes.Buffer, w io.WriteCloser, in []byte) ([]byte, error) {
+	if _, err := w.Write(in); err != nil {
+		return nil, fmt.Errorf("%v failed: %v", name, err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("%v failed: %v", name, err)
+	}
+
+	return buf.Bytes(), nil
+}
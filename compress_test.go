@@ -0,0 +1,107 @@
+package jq_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompress(t *testing.T) {
+	payload := `{"order":{"id":42}}`
+
+	testCases := map[string]struct {
+		Compress   jq.Op
+		Decompress string
+	}{
+		"gzip": {
+			Compress:   jq.Gzip(),
+			Decompress: "gunzip",
+		},
+		"zlib": {
+			Compress:   jq.ZlibDeflate(),
+			Decompress: "zlib_inflate",
+		},
+		"deflate": {
+			Compress:   jq.Deflate(),
+			Decompress: "inflate",
+		},
+		"zstd": {
+			Compress:   jq.Zstd(),
+			Decompress: "unzstd",
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			body, err := jq.Chain(tc.Compress, jq.B64Encode()).Apply([]byte(payload))
+			require.NoError(t, err)
+
+			in := []byte(`{"body":` + string(body) + `}`)
+			op, err := jq.Parse(".body|b64_decode|" + tc.Decompress + "|.order.id")
+			require.NoError(t, err)
+
+			data, err := op.Apply(in)
+			require.NoError(t, err)
+			assert.Equal(t, `42`, string(data))
+		})
+	}
+}
+
+func TestDecompressLimit(t *testing.T) {
+	compressed, err := jq.Gzip().Apply([]byte(`{"order":{"id":42}}`))
+	require.NoError(t, err)
+
+	op, err := jq.Parse("gunzip", jq.WithMaxDecompressedSize(8))
+	require.NoError(t, err)
+
+	_, err = op.Apply(compressed)
+	assert.True(t, errors.Is(err, jq.ErrDecompressedSizeExceeded))
+
+	_, err = jq.Gunzip(jq.DefaultMaxDecompressedSize).Apply([]byte(`not gzip`))
+	assert.Error(t, err)
+}
+
+func TestUnzstdLimit(t *testing.T) {
+	payload := []byte(`{"order":{"id":42}}`)
+	compressed, err := jq.Zstd().Apply(payload)
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		Input    []byte
+		Limit    int64
+		Expected string
+		Exceeded bool
+	}{
+		"within limit": {
+			Input:    compressed,
+			Limit:    int64(len(payload)),
+			Expected: string(payload),
+		},
+		"output beyond limit": {
+			Input:    compressed,
+			Limit:    8,
+			Exceeded: true,
+		},
+		"declared window beyond limit": {
+			// a frame header declaring a 256 MiB window followed by a single raw block holding x
+			Input:    []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00, 0x90, 0x09, 0x00, 0x00, 'x'},
+			Limit:    1 << 20,
+			Exceeded: true,
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			data, err := jq.Unzstd(tc.Limit).Apply(tc.Input)
+			if tc.Exceeded {
+				assert.True(t, errors.Is(err, jq.ErrDecompressedSizeExceeded), "error %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}
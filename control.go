@@ -0,0 +1,127 @@
+package jq
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ValueError is the error raised by error(v); Value holds the JSON value passed to error, so structured payloads can
+// be recovered with errors.As
+type ValueError struct {
+	Value []byte
+}
+
+func (err *ValueError) Error() string {
+	if k, kerr := kindOf(err.Value); kerr == nil && k == kindString {
+		if s, serr := decodeString(err.Value); serr == nil {
+			return s
+		}
+	}
+	return fmt.Sprintf("%s (not a string)", err.Value)
+}
+
+// HaltError is returned by Apply when a query calls halt_error; Value holds the input of halt_error and Code the
+// exit status requested
+type HaltError struct {
+	Value []byte
+	Code  int
+}
+
+func (err *HaltError) Error() string {
+	if k, kerr := kindOf(err.Value); kerr == nil && k == kindString {
+		if s, serr := decodeString(err.Value); serr == nil {
+			return s
+		}
+	}
+	return string(err.Value)
+}
+
+// errHalt ends a query started with Apply as if its input were exhausted
+var errHalt = &HaltError{}
+
+func controlBuiltins() {
+	define("error", 0, function(func(in []byte, _ [][]byte) ([]byte, error) {
+		return nil, &ValueError{Value: in}
+	}))
+
+	define("error", 1, function(func(_ []byte, args [][]byte) ([]byte, error) {
+		return nil, &ValueError{Value: args[0]}
+	}))
+
+	define("halt", 0, function(func([]byte, [][]byte) ([]byte, error) {
+		return nil, errHalt
+	}))
+
+	define("halt_error", 1, function(func(in []byte, args [][]byte) ([]byte, error) {
+		if k, err := kindOf(args[0]); err != nil || k != kindNumber {
+			return nil, fmt.Errorf("halt_error/1: number required")
+		}
+		code, err := toInt(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return nil, &HaltError{Value: in, Code: code}
+	}))
+}
+
+// label is a label in scope; labels form a list from the innermost scope outwards
+type label struct {
+	name string
+	stop *stop
+	next *label
+}
+
+// labelNode implements label $name | body: breaking to the label ends body without error
+type labelNode struct {
+	name string
+	body node
+}
+
+func (n *labelNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	inner, done := e.label(n.name)
+	if err := n.body.eval(inner, in, fn); err != done {
+		return err
+	}
+	return nil
+}
+
+func (n *labelNode) evalPath(e *env, in []byte, p path, fn func([]byte, path) error) error {
+	inner, done := e.label(n.name)
+	if err := evalPath(inner, n.body, in, p, fn); err != done {
+		return err
+	}
+	return nil
+}
+
+// label returns a copy of the environment with the label name in scope along with the error breaking to it; each
+// evaluation of a label allocates its own so that recursive uses of a label don't clash
+func (e *env) label(name string) (*env, *stop) {
+	done := &stop{}
+	inner := *e
+	inner.labels = &label{name: name, stop: done, next: e.labels}
+	return &inner, done
+}
+
+// breakNode implements break $name, ending the evaluation of the innermost label of that name
+type breakNode struct {
+	name string
+}
+
+func (n *breakNode) eval(e *env, _ []byte, _ func([]byte) error) error {
+	for l := e.labels; l != nil; l = l.next {
+		if l.name == n.name {
+			return l.stop
+		}
+	}
+	return fmt.Errorf("$*label-%v is not defined", n.name)
+}
+
+func (n *breakNode) evalPath(e *env, in []byte, _ path, _ func([]byte, path) error) error {
+	return n.eval(e, in, nil)
+}
+
+// isHalt reports whether err was raised by halt or halt_error, neither of which can be caught
+func isHalt(err error) bool {
+	var h *HaltError
+	return errors.As(err, &h)
+}
@@ -0,0 +1,101 @@
+package jq_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkLabel(t *testing.B) {
+	op := jq.Must(jq.Parse(`label $out | .[] | if . > 2 then ., break $out else . end`))
+	data := []byte(`[1,2,3,4,5]`)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestControl(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+		HasError bool
+	}{
+		"label break":        {In: `[1, 2, 3, 4]`, Op: `label $out | .[] | if . > 2 then break $out else . end`, Expected: `[1,2]`},
+		"label first":        {In: `[3, 4]`, Op: `[label $f | .[] | ., break $f]`, Expected: `[3]`},
+		"label nested":       {In: `null`, Op: `[label $a | 1, (label $b | 2, break $a, 3), 4]`, Expected: `[1,2]`},
+		"label inner":        {In: `null`, Op: `[label $a | 1, (label $b | 2, break $b, 3), 4]`, Expected: `[1,2,4]`},
+		"label shadow":       {In: `null`, Op: `[label $a | 1, (label $a | 2, break $a), 3]`, Expected: `[1,2,3]`},
+		"label recursive":    {In: `[[1, 2], [3, 4]]`, Op: `[.[] | label $row | .[] | ., break $row]`, Expected: `[1,3]`},
+		"label path":         {In: `[1, 2]`, Op: `[path(label $p | .[] | ., break $p)]`, Expected: `[[0]]`},
+		"label try":          {In: `[1, 2]`, Op: `[label $p | .[] | try (., break $p)]`, Expected: `[1]`},
+		"label undefined":    {In: `null`, Op: `break $out`, HasError: true},
+		"label out of scope": {In: `null`, Op: `(label $out | 1), break $out`, HasError: true},
+		"label variable":     {In: `null`, Op: `label $out | $out`, HasError: true},
+		"error":              {In: `null`, Op: `error("invalid")`, HasError: true},
+		"error input":        {In: `{"a": 1}`, Op: `error`, HasError: true},
+		"error caught":       {In: `null`, Op: `try error("invalid") catch .`, Expected: `"invalid"`},
+		"error object":       {In: `null`, Op: `try error({code: 422}) catch .code`, Expected: `422`},
+		"error null":         {In: `null`, Op: `try error(null) catch .`, Expected: `null`},
+		"error suppressed":   {In: `null`, Op: `[error("x")?, 1]`, Expected: `[1]`},
+		"error alternative":  {In: `null`, Op: `error("x") // 1`, Expected: `1`},
+		"error other":        {In: `{"a": 1}`, Op: `try (.a | keys) catch .`, Expected: `"number (1) has no keys"`},
+		"empty":              {In: `[1, 2]`, Op: `[.[] | if . == 1 then empty else . end]`, Expected: `[2]`},
+		"halt":               {In: `null`, Op: `halt`},
+		"halt stream":        {In: `[1, 2, 3]`, Op: `.[] | if . == 3 then halt else . end`, Expected: `[1,2]`},
+		"halt try":           {In: `null`, Op: `try halt catch 1`},
+		"halt_error":         {In: `"stop"`, Op: `halt_error`, HasError: true},
+		"halt_error try":     {In: `"stop"`, Op: `try halt_error(1) catch .`, HasError: true},
+		"halt_error code":    {In: `"stop"`, Op: `halt_error("x")`, HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op)
+			if tc.HasError && err != nil {
+				return
+			}
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}
+
+func TestValueError(t *testing.T) {
+	op := jq.Must(jq.Parse(`if .age < 0 then error({field: "age", reason: "negative"}) else . end`))
+
+	_, err := op.Apply([]byte(`{"age": -1}`))
+	var ve *jq.ValueError
+	require.True(t, errors.As(err, &ve))
+	assert.Equal(t, `{"field":"age","reason":"negative"}`, string(ve.Value))
+	assert.Equal(t, `{"field":"age","reason":"negative"} (not a string)`, err.Error())
+
+	_, err = jq.Must(jq.Parse(`error("invalid")`)).Apply([]byte(`null`))
+	assert.EqualError(t, err, "invalid")
+}
+
+func TestHaltError(t *testing.T) {
+	_, err := jq.Must(jq.Parse(`"bye" | halt_error(2)`)).Apply([]byte(`null`))
+	var he *jq.HaltError
+	require.True(t, errors.As(err, &he))
+	assert.Equal(t, 2, he.Code)
+	assert.Equal(t, `"bye"`, string(he.Value))
+	assert.EqualError(t, err, "bye")
+
+	_, err = jq.Must(jq.Parse(`{a: 1} | halt_error`)).Apply([]byte(`null`))
+	require.True(t, errors.As(err, &he))
+	assert.Equal(t, 5, he.Code)
+	assert.EqualError(t, err, `{"a":1}`)
+}
@@ -0,0 +1,537 @@
+package jq
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func dateBuiltins() {
+	define("now", 0, &builtin{
+		eval: func(e *env, _ []byte, _ []node, fn func([]byte) error) error {
+			t := e.opts.now()
+			return fn(number(float64(t.Unix()) + float64(t.Nanosecond())/1e9))
+		},
+	})
+
+	define("mktime", 0, function(func(in []byte, _ [][]byte) ([]byte, error) {
+		t, err := fromBrokenDown(in, time.UTC, "mktime")
+		if err != nil {
+			return nil, err
+		}
+		return number(float64(t.Unix())), nil
+	}))
+
+	define("gmtime", 0, function(func(in []byte, _ [][]byte) ([]byte, error) {
+		return brokenDownAt(in, time.UTC, "gmtime")
+	}))
+
+	define("localtime", 0, &builtin{
+		eval: func(e *env, in []byte, _ []node, fn func([]byte) error) error {
+			v, err := brokenDownAt(in, e.opts.location, "localtime")
+			if err != nil {
+				return err
+			}
+			return fn(v)
+		},
+	})
+
+	define("strftime", 1, function(func(in []byte, args [][]byte) ([]byte, error) {
+		return strftime(in, args[0], time.UTC, "strftime")
+	}))
+
+	define("strflocaltime", 1, &builtin{
+		eval: func(e *env, in []byte, args []node, fn func([]byte) error) error {
+			return cartesian(e, in, args, func(values [][]byte) error {
+				v, err := strftime(in, values[0], e.opts.location, "strflocaltime")
+				if err != nil {
+					return err
+				}
+				return fn(v)
+			})
+		},
+	})
+
+	define("strptime", 1, function(func(in []byte, args [][]byte) ([]byte, error) {
+		t, err := strptime(in, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return brokenDown(t, 0), nil
+	}))
+}
+
+// brokenDown returns the jq broken down form of t: [year, month (0-11), day of month, hours, minutes, seconds,
+// day of week, day of year (0-365)], with frac added to the seconds
+func brokenDown(t time.Time, frac float64) []byte {
+	fields := []float64{
+		float64(t.Year()), float64(t.Month() - 1), float64(t.Day()),
+		float64(t.Hour()), float64(t.Minute()), float64(t.Second()) + frac,
+		float64(t.Weekday()), float64(t.YearDay() - 1),
+	}
+
+	dst := make([]byte, 0, 40)
+	dst = append(dst, '[')
+	for i, f := range fields {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendNumber(dst, f)
+	}
+	return append(dst, ']')
+}
+
+// brokenDownAt converts seconds since the epoch into the broken down time in loc
+func brokenDownAt(in []byte, loc *time.Location, name string) ([]byte, error) {
+	if k, err := kindOf(in); err != nil || k != kindNumber {
+		return nil, fmt.Errorf("%v() requires a number", name)
+	}
+	secs, err := toFloat(in)
+	if err != nil {
+		return nil, err
+	}
+	whole := math.Floor(secs)
+	return brokenDown(time.Unix(int64(whole), 0).In(loc), secs-whole), nil
+}
+
+// fromBrokenDown converts a broken down time, interpreted in loc, into a time; fractions of a second are dropped
+func fromBrokenDown(in []byte, loc *time.Location, name string) (time.Time, error) {
+	invalid := fmt.Errorf("%v requires array of 6 numbers", name)
+	if k, err := kindOf(in); err != nil || k != kindArray {
+		return time.Time{}, invalid
+	}
+	values, err := elements(in)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(values) < 6 {
+		return time.Time{}, invalid
+	}
+
+	fields := make([]int, 6)
+	for i := range fields {
+		if k, err := kindOf(values[i]); err != nil || k != kindNumber {
+			return time.Time{}, invalid
+		}
+		f, err := toFloat(values[i])
+		if err != nil {
+			return time.Time{}, err
+		}
+		fields[i] = int(math.Floor(f))
+	}
+	return time.Date(fields[0], time.Month(fields[1]+1), fields[2], fields[3], fields[4], fields[5], 0, loc), nil
+}
+
+// strftime formats seconds since the epoch, or a broken down time, in loc
+func strftime(in, format []byte, loc *time.Location, name string) ([]byte, error) {
+	if k, err := kindOf(format); err != nil || k != kindString {
+		return nil, fmt.Errorf("%v/1 requires a string format", name)
+	}
+	layout, err := decodeString(format)
+	if err != nil {
+		return nil, err
+	}
+
+	k, err := kindOf(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var t time.Time
+	switch k {
+	case kindNumber:
+		secs, err := toFloat(in)
+		if err != nil {
+			return nil, err
+		}
+		t = time.Unix(int64(math.Floor(secs)), 0).In(loc)
+	case kindArray:
+		if t, err = fromBrokenDown(in, loc, name); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%v/1 requires parsed datetime inputs", name)
+	}
+	return quote(formatTime(t, layout)), nil
+}
+
+// composites are the conversion specifications defined in terms of others
+var composites = map[byte]string{
+	'c': "%a %b %e %H:%M:%S %Y",
+	'D': "%m/%d/%y",
+	'F': "%Y-%m-%d",
+	'h': "%b",
+	'r': "%I:%M:%S %p",
+	'R': "%H:%M",
+	'T': "%H:%M:%S",
+	'x': "%m/%d/%y",
+	'X': "%H:%M:%S",
+}
+
+// expandFormat replaces the composite conversion specifications in a strftime or strptime format
+func expandFormat(format string) string {
+	if !strings.Contains(format, "%") {
+		return format
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 == len(format) {
+			b.WriteByte(format[i])
+			continue
+		}
+		i++
+		if expansion, ok := composites[format[i]]; ok {
+			b.WriteString(expansion)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(format[i])
+	}
+	return b.String()
+}
+
+// formatTime formats t using the C strftime conversion specifications in the C locale
+func formatTime(t time.Time, format string) string {
+	format = expandFormat(format)
+
+	var b strings.Builder
+	pad := func(v, width int, fill byte) {
+		s := strconv.Itoa(v)
+		for i := len(s); i < width; i++ {
+			b.WriteByte(fill)
+		}
+		b.WriteString(s)
+	}
+	hour12 := func() int {
+		if h := t.Hour() % 12; h != 0 {
+			return h
+		}
+		return 12
+	}
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 == len(format) {
+			b.WriteByte(format[i])
+			continue
+		}
+		i++
+
+		switch format[i] {
+		case 'a':
+			b.WriteString(t.Weekday().String()[:3])
+		case 'A':
+			b.WriteString(t.Weekday().String())
+		case 'b':
+			b.WriteString(t.Month().String()[:3])
+		case 'B':
+			b.WriteString(t.Month().String())
+		case 'C':
+			pad(t.Year()/100, 2, '0')
+		case 'd':
+			pad(t.Day(), 2, '0')
+		case 'e':
+			pad(t.Day(), 2, ' ')
+		case 'g':
+			year, _ := t.ISOWeek()
+			pad(year%100, 2, '0')
+		case 'G':
+			year, _ := t.ISOWeek()
+			pad(year, 4, '0')
+		case 'H':
+			pad(t.Hour(), 2, '0')
+		case 'I':
+			pad(hour12(), 2, '0')
+		case 'j':
+			pad(t.YearDay(), 3, '0')
+		case 'k':
+			pad(t.Hour(), 2, ' ')
+		case 'l':
+			pad(hour12(), 2, ' ')
+		case 'm':
+			pad(int(t.Month()), 2, '0')
+		case 'M':
+			pad(t.Minute(), 2, '0')
+		case 'n':
+			b.WriteByte('\n')
+		case 'p':
+			if t.Hour() < 12 {
+				b.WriteString("AM")
+			} else {
+				b.WriteString("PM")
+			}
+		case 's':
+			b.WriteString(strconv.FormatInt(t.Unix(), 10))
+		case 'S':
+			pad(t.Second(), 2, '0')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			pad((int(t.Weekday())+6)%7+1, 1, '0')
+		case 'U':
+			pad((t.YearDay()+6-int(t.Weekday()))/7, 2, '0')
+		case 'V':
+			_, week := t.ISOWeek()
+			pad(week, 2, '0')
+		case 'w':
+			pad(int(t.Weekday()), 1, '0')
+		case 'W':
+			pad((t.YearDay()+6-(int(t.Weekday())+6)%7)/7, 2, '0')
+		case 'y':
+			pad(t.Year()%100, 2, '0')
+		case 'Y':
+			pad(t.Year(), 4, '0')
+		case 'z':
+			b.WriteString(t.Format("-0700"))
+		case 'Z':
+			name, _ := t.Zone()
+			b.WriteString(name)
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(format[i])
+		}
+	}
+	return b.String()
+}
+
+// strptime parses the string in according to a C strptime format. As with jq, the fields are taken as written: any
+// time zone offset is parsed but not applied.
+func strptime(in, format []byte) (time.Time, error) {
+	if k, err := kindOf(in); err != nil || k != kindString {
+		return time.Time{}, fmt.Errorf("strptime/1 requires string inputs and arguments")
+	}
+	if k, err := kindOf(format); err != nil || k != kindString {
+		return time.Time{}, fmt.Errorf("strptime/1 requires string inputs and arguments")
+	}
+	s, err := decodeString(in)
+	if err != nil {
+		return time.Time{}, err
+	}
+	layout, err := decodeString(format)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	p := timeParser{s: s, year: 1900, month: 1, day: 1, yday: -1}
+	if !p.parse(expandFormat(layout)) {
+		return time.Time{}, fmt.Errorf("date \"%v\" does not match format \"%v\"", s, layout)
+	}
+	return p.time(), nil
+}
+
+// timeParser holds the state of strptime
+type timeParser struct {
+	s   string
+	pos int
+
+	year, month, day, hour, minute, second, yday int
+
+	epoch    *time.Time
+	hour12   bool
+	pm, ampm bool
+	monthSet bool
+}
+
+func (p *timeParser) parse(format string) bool {
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if isSpace(c) {
+			p.skipSpace()
+			continue
+		}
+		if c != '%' || i+1 == len(format) {
+			if p.pos == len(p.s) || p.s[p.pos] != c {
+				return false
+			}
+			p.pos++
+			continue
+		}
+		i++
+
+		var ok bool
+		switch format[i] {
+		case 'Y':
+			p.year, ok = p.number(4, 0, 9999)
+		case 'C':
+			var century int
+			if century, ok = p.number(2, 0, 99); ok {
+				p.year = century*100 + p.year%100
+			}
+		case 'y':
+			var y int
+			if y, ok = p.number(2, 0, 99); ok {
+				if y < 69 {
+					p.year = 2000 + y
+				} else {
+					p.year = 1900 + y
+				}
+			}
+		case 'm':
+			p.month, ok = p.number(2, 1, 12)
+			p.monthSet = true
+		case 'd', 'e':
+			p.day, ok = p.number(2, 1, 31)
+			p.monthSet = true
+		case 'H', 'k':
+			p.hour, ok = p.number(2, 0, 23)
+		case 'I', 'l':
+			p.hour, ok = p.number(2, 1, 12)
+			p.hour12 = true
+		case 'M':
+			p.minute, ok = p.number(2, 0, 59)
+		case 'S':
+			p.second, ok = p.number(2, 0, 60)
+		case 'j':
+			p.yday, ok = p.number(3, 1, 366)
+		case 'b', 'B':
+			var m int
+			if m, ok = p.name(12, func(i int) string { return time.Month(i + 1).String() }); ok {
+				p.month = m + 1
+				p.monthSet = true
+			}
+		case 'a', 'A':
+			_, ok = p.name(7, func(i int) string { return time.Weekday(i).String() })
+		case 'p':
+			ok = p.meridiem()
+		case 'z':
+			ok = p.offset()
+		case 'Z':
+			start := p.pos
+			for p.pos < len(p.s) && !isSpace(p.s[p.pos]) {
+				p.pos++
+			}
+			ok = p.pos > start
+		case 's':
+			ok = p.seconds()
+		case 'n', 't':
+			p.skipSpace()
+			ok = true
+		case '%':
+			ok = p.pos < len(p.s) && p.s[p.pos] == '%'
+			p.pos++
+		}
+		if !ok {
+			return false
+		}
+	}
+	return p.pos == len(p.s)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+func (p *timeParser) skipSpace() {
+	for p.pos < len(p.s) && isSpace(p.s[p.pos]) {
+		p.pos++
+	}
+}
+
+// number reads an unsigned number of at most width digits, skipping leading spaces as C's strptime does
+func (p *timeParser) number(width, min, max int) (int, bool) {
+	for p.pos < len(p.s) && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+
+	start, v := p.pos, 0
+	for p.pos < len(p.s) && p.pos-start < width && isDigit(p.s[p.pos]) {
+		v = v*10 + int(p.s[p.pos]-'0')
+		p.pos++
+	}
+	return v, p.pos > start && v >= min && v <= max
+}
+
+// name reads a full or abbreviated English name, returning its index
+func (p *timeParser) name(n int, names func(int) string) (int, bool) {
+	rest := p.s[p.pos:]
+	for i := 0; i < n; i++ {
+		full := names(i)
+		for _, candidate := range []string{full, full[:3]} {
+			if len(rest) >= len(candidate) && strings.EqualFold(rest[:len(candidate)], candidate) {
+				p.pos += len(candidate)
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func (p *timeParser) meridiem() bool {
+	rest := p.s[p.pos:]
+	if len(rest) < 2 {
+		return false
+	}
+	switch strings.ToUpper(rest[:2]) {
+	case "AM":
+		p.pm = false
+	case "PM":
+		p.pm = true
+	default:
+		return false
+	}
+	p.ampm = true
+	p.pos += 2
+	return true
+}
+
+// offset reads a time zone offset such as Z, +0100 or -05:30
+func (p *timeParser) offset() bool {
+	if p.pos < len(p.s) && p.s[p.pos] == 'Z' {
+		p.pos++
+		return true
+	}
+	if p.pos == len(p.s) || (p.s[p.pos] != '+' && p.s[p.pos] != '-') {
+		return false
+	}
+	p.pos++
+
+	if _, ok := p.number(2, 0, 23); !ok {
+		return false
+	}
+	if p.pos < len(p.s) && p.s[p.pos] == ':' {
+		p.pos++
+	}
+	_, ok := p.number(2, 0, 59)
+	return ok
+}
+
+// seconds reads seconds since the epoch
+func (p *timeParser) seconds() bool {
+	start := p.pos
+	if p.pos < len(p.s) && p.s[p.pos] == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.s) && isDigit(p.s[p.pos]) {
+		p.pos++
+	}
+	secs, err := strconv.ParseInt(p.s[start:p.pos], 10, 64)
+	if err != nil {
+		return false
+	}
+	t := time.Unix(secs, 0).UTC()
+	p.epoch = &t
+	return true
+}
+
+func (p *timeParser) time() time.Time {
+	if p.epoch != nil {
+		return *p.epoch
+	}
+
+	hour := p.hour
+	if p.hour12 && p.ampm {
+		hour %= 12
+		if p.pm {
+			hour += 12
+		}
+	}
+
+	if p.yday > 0 && !p.monthSet {
+		return time.Date(p.year, time.January, p.yday, hour, p.minute, p.second, 0, time.UTC)
+	}
+	return time.Date(p.year, time.Month(p.month), p.day, hour, p.minute, p.second, 0, time.UTC)
+}
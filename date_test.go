@@ -0,0 +1,83 @@
+package jq_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkStrptime(t *testing.B) {
+	op := jq.Must(jq.Parse(`.ts | strptime("%Y-%m-%dT%H:%M:%SZ") | mktime`))
+	data := []byte(`{"ts":"2015-03-05T23:51:47Z"}`)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestDates(t *testing.T) {
+	clock := func() time.Time { return time.Date(2024, time.February, 29, 12, 30, 15, 500000000, time.UTC) }
+	tokyo := time.FixedZone("JST", 9*60*60)
+
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+		HasError bool
+	}{
+		"now":                 {In: `null`, Op: `now`, Expected: `1709209815.5`},
+		"now todate":          {In: `null`, Op: `now | todate`, Expected: `"2024-02-29T12:30:15Z"`},
+		"todate":              {In: `1425599621`, Op: `todate`, Expected: `"2015-03-05T23:53:41Z"`},
+		"todate fraction":     {In: `1425599621.9`, Op: `todate`, Expected: `"2015-03-05T23:53:41Z"`},
+		"todateiso8601":       {In: `1425599621`, Op: `todateiso8601`, Expected: `"2015-03-05T23:53:41Z"`},
+		"date":                {In: `0`, Op: `date`, Expected: `"1970-01-01T00:00:00Z"`},
+		"fromdate":            {In: `"2015-03-05T23:51:47Z"`, Op: `fromdate`, Expected: `1425599507`},
+		"fromdate invalid":    {In: `"2015-03-05 23:51:47"`, Op: `fromdate`, HasError: true},
+		"strptime":            {In: `"2015-03-05T23:51:47Z"`, Op: `strptime("%Y-%m-%dT%H:%M:%SZ")`, Expected: `[2015,2,5,23,51,47,4,63]`},
+		"strptime mktime":     {In: `{"ts":"2015-03-05T23:51:47Z"}`, Op: `.ts | strptime("%Y-%m-%dT%H:%M:%SZ") | mktime`, Expected: `1425599507`},
+		"strptime names":      {In: `"Thu, 05 Mar 2015 11:51:47 pm"`, Op: `strptime("%a, %d %b %Y %I:%M:%S %p") | mktime`, Expected: `1425599507`},
+		"strptime composite":  {In: `"03/05/15 23:51"`, Op: `strptime("%D %R") | todate`, Expected: `"2015-03-05T23:51:00Z"`},
+		"strptime offset":     {In: `"2015-03-05T23:51:47+01:00"`, Op: `strptime("%Y-%m-%dT%H:%M:%S%z") | mktime`, Expected: `1425599507`},
+		"strptime epoch":      {In: `"1425599507"`, Op: `strptime("%s") | todate`, Expected: `"2015-03-05T23:51:47Z"`},
+		"strptime day":        {In: `"2015 064"`, Op: `strptime("%Y %j") | todate`, Expected: `"2015-03-05T00:00:00Z"`},
+		"strptime mismatch":   {In: `"2015-03"`, Op: `strptime("%Y-%m-%d")`, HasError: true},
+		"strptime trailing":   {In: `"2015-03-05x"`, Op: `strptime("%Y-%m-%d")`, HasError: true},
+		"strptime range":      {In: `"2015-13-05"`, Op: `strptime("%Y-%m-%d")`, HasError: true},
+		"strptime number":     {In: `1`, Op: `strptime("%Y")`, HasError: true},
+		"mktime":              {In: `[2015,2,5,23,51,47,4,63]`, Op: `mktime`, Expected: `1425599507`},
+		"mktime normalizes":   {In: `[2015,0,32,0,0,0]`, Op: `mktime | todate`, Expected: `"2015-02-01T00:00:00Z"`},
+		"mktime invalid":      {In: `[2015,2]`, Op: `mktime`, HasError: true},
+		"gmtime":              {In: `1425599621.25`, Op: `gmtime`, Expected: `[2015,2,5,23,53,41.25,4,63]`},
+		"gmtime invalid":      {In: `"x"`, Op: `gmtime`, HasError: true},
+		"localtime":           {In: `1425599621`, Op: `localtime`, Expected: `[2015,2,6,8,53,41,5,64]`},
+		"strftime":            {In: `1425599621`, Op: `strftime("%A, %B %d, %Y %j %e %k %l%p %u %w %y %C")`, Expected: `"Thursday, March 05, 2015 064  5 23 11PM 4 4 15 20"`},
+		"strftime weeks":      {In: `1425599621`, Op: `strftime("%U %W %V %G %g")`, Expected: `"09 09 10 2015 15"`},
+		"strftime zone":       {In: `1425599621`, Op: `strftime("%H:%M %z %Z %%")`, Expected: `"23:53 +0000 UTC %"`},
+		"strftime broken":     {In: `[2015,2,5,23,51,47,4,63]`, Op: `strftime("%T %D %s")`, Expected: `"23:51:47 03/05/15 1425599507"`},
+		"strftime invalid":    {In: `"x"`, Op: `strftime("%Y")`, HasError: true},
+		"strflocaltime":       {In: `1425599621`, Op: `strflocaltime("%Y-%m-%dT%H:%M:%S %z %Z")`, Expected: `"2015-03-06T08:53:41 +0900 JST"`},
+		"strflocaltime array": {In: `[2015,2,6,8,53,41]`, Op: `strflocaltime("%H:%M %Z")`, Expected: `"08:53 JST"`},
+		"dateadd":             {In: `0`, Op: `dateadd("seconds"; 3600) | todate`, Expected: `"1970-01-01T01:00:00Z"`},
+		"datesub":             {In: `3600`, Op: `datesub("seconds"; 3600)`, Expected: `0`},
+		"bucket by hour":      {In: `"2015-03-05T23:51:47Z"`, Op: `fromdate | . - . % 3600 | todate`, Expected: `"2015-03-05T23:00:00Z"`},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op, jq.WithClock(clock), jq.WithLocation(tokyo))
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package jq
+
+import "fmt"
+
+// funcDef is a function defined with def, or one of its parameters. Parameters are functions of no arguments bound to
+// the argument of each call, evaluated against the input at the point they are used, as jq's closures are.
+type funcDef struct {
+	name   string
+	params []*funcDef
+	body   node
+
+	// param reports that the definition is a parameter of an enclosing function
+	param bool
+
+	// variable reports that a parameter was declared as $name, so it is also bound as a variable to each value of its
+	// argument
+	variable bool
+
+	// closed reports that no variables, labels or parameters were in scope where the function was defined, so it
+	// captures nothing and can be called without looking up the environment it was defined in
+	closed bool
+
+	// multi caches the static analysis of the body, with and without arguments producing several values; 0 is
+	// unknown, -1 single and 1 multiple
+	multi [2]int8
+
+	// multiArg holds, while a body is analysed, whether the argument of a parameter may produce several values
+	multiArg bool
+
+	// optimized reports that the body has been rewritten by optimize, which happens once whatever calls it
+	optimized bool
+}
+
+func (d *funcDef) String() string {
+	return fmt.Sprintf("%v/%v", d.name, len(d.params))
+}
+
+// isMulti reports whether a call to the function may produce more than one value. Calls made while the body is being
+// analysed, by recursion, are assumed to produce a single value since recursion alone adds no values.
+func (d *funcDef) isMulti(argsMulti bool) bool {
+	i := 0
+	if argsMulti {
+		i = 1
+	}
+	if d.multi[i] != 0 {
+		return d.multi[i] > 0
+	}
+
+	d.multi[i] = -1
+	saved := make([]bool, len(d.params))
+	for j, param := range d.params {
+		saved[j] = param.multiArg
+		param.multiArg = argsMulti && !param.variable
+	}
+	multi := isMulti(d.body)
+	for j, param := range d.params {
+		param.multiArg = saved[j]
+	}
+
+	switch {
+	case multi:
+		d.multi[i] = 1
+	case !d.closed:
+		// the body may use parameters of enclosing functions, whose arguments vary between analyses
+		d.multi[i] = 0
+	}
+	return multi
+}
+
+// closure is a function or parameter in scope at run time; closures form a list from the innermost scope outwards
+type closure struct {
+	def  *funcDef
+	body node // the argument bound to a parameter
+	env  *env // the environment the function was defined in, or the parameter's argument is evaluated in
+	next *closure
+}
+
+// define returns a copy of the environment in which the function d is in scope; the copy is the environment d is
+// evaluated in, so that it can call itself
+func (e *env) define(d *funcDef) *env {
+	inner := *e
+	inner.funcs = &closure{def: d, env: &inner, next: e.funcs}
+	return &inner
+}
+
+func (e *env) closure(d *funcDef) (*closure, error) {
+	for c := e.funcs; c != nil; c = c.next {
+		if c.def == d {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("%v is not defined", d)
+}
+
+// defNode brings a function that captures its environment into scope for the remainder of the expression, rest;
+// closed functions are resolved when parsing and need no defNode
+type defNode struct {
+	def  *funcDef
+	rest node
+}
+
+func (n *defNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	return n.rest.eval(e.define(n.def), in, fn)
+}
+
+func (n *defNode) evalPath(e *env, in []byte, p path, fn func([]byte, path) error) error {
+	return evalPath(e.define(n.def), n.rest, in, p, fn)
+}
+
+// funcCallNode calls a function defined with def or one of the parameters of an enclosing function
+type funcCallNode struct {
+	def  *funcDef
+	args []node
+}
+
+func (n *funcCallNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	if n.def.param {
+		c, err := e.closure(n.def)
+		if err != nil {
+			return err
+		}
+		return c.body.eval(c.env, in, fn)
+	}
+	return n.call(e, in, func(inner *env) error {
+		return n.def.body.eval(inner, in, fn)
+	})
+}
+
+func (n *funcCallNode) evalPath(e *env, in []byte, p path, fn func([]byte, path) error) error {
+	if n.def.param {
+		c, err := e.closure(n.def)
+		if err != nil {
+			return err
+		}
+		return evalPath(c.env, c.body, in, p, fn)
+	}
+	return n.call(e, in, func(inner *env) error {
+		return evalPath(inner, n.def.body, in, p, fn)
+	})
+}
+
+// call calls fn with the environment the body of the function is evaluated in: the one it was defined in, with
+// each parameter bound to its argument and each $parameter to a value of its argument, once for every combination of
+// those values
+func (n *funcCallNode) call(e *env, in []byte, fn func(*env) error) error {
+	defined := e.top
+	if !n.def.closed {
+		c, err := e.closure(n.def)
+		if err != nil {
+			return err
+		}
+		defined = c.env
+	}
+
+	inner := *defined
+	for i, param := range n.def.params {
+		inner.funcs = &closure{def: param, body: n.args[i], env: e, next: inner.funcs}
+	}
+
+	// $params are bound as a as $a | b as $b would be, so the first varies slowest
+	var bind func(scope *env, i int) error
+	bind = func(scope *env, i int) error {
+		for i < len(n.def.params) && !n.def.params[i].variable {
+			i++
+		}
+		if i == len(n.def.params) {
+			return fn(scope)
+		}
+		return n.args[i].eval(e, in, func(v []byte) error {
+			return bind(scope.bind(n.def.params[i].name, v), i+1)
+		})
+	}
+	return bind(&inner, 0)
+}
+
+// isMulti reports whether the call may produce more than one value
+func (n *funcCallNode) isMulti() bool {
+	if n.def.param {
+		return n.def.multiArg
+	}
+	for i, param := range n.def.params {
+		if param.variable && isMulti(n.args[i]) {
+			return true
+		}
+	}
+	return n.def.isMulti(anyMulti(n.args))
+}
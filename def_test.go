@@ -0,0 +1,110 @@
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkDef(t *testing.B) {
+	op := jq.Must(jq.Parse(`def fac: if . <= 1 then 1 else . * (. - 1 | fac) end; map(fac)`))
+	data := []byte(`[1,2,3,4,5,6,7,8,9,10]`)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestDef(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+		HasError bool
+	}{
+		"def":                {In: `{"a": 1}`, Op: `def inc: . + 1; .a | inc`, Expected: `2`},
+		"def only":           {In: `{"a": 1}`, Op: `def inc: . + 1;`, Expected: `{"a": 1}`},
+		"def filter param":   {In: `[1, 2]`, Op: `def twice(f): f | f; map(twice(. * 3))`, Expected: `[9,18]`},
+		"def closure":        {In: `{"a": [1, 2], "n": 10}`, Op: `def apply(f): map(f); .n as $n | .a | apply(. + $n)`, Expected: `[11,12]`},
+		"def param input":    {In: `{"a": 1}`, Op: `def f(g): {x: 2} | g; f(.x)`, Expected: `2`},
+		"def variable param": {In: `{"a": 1}`, Op: `def add($x; $y): $x + $y; add(.a; 2)`, Expected: `3`},
+		"def variable both":  {In: `3`, Op: `def f($a): [$a, a]; f(. * 2)`, Expected: `[6,6]`},
+		"def variable multi": {In: `null`, Op: `def f($a): $a * 10; f(1, 2)`, Expected: `[10,20]`},
+		"def variable order": {In: `null`, Op: `def f($a; $b): [$a, $b]; [f(1, 2; 3, 4)]`, Expected: `[[1,3],[1,4],[2,3],[2,4]]`},
+		"def recursive":      {In: `5`, Op: `def fac: if . <= 1 then 1 else . * (. - 1 | fac) end; fac`, Expected: `120`},
+		"def multi":          {In: `3`, Op: `def count: if . > 0 then ., (. - 1 | count) else empty end; count`, Expected: `[3,2,1]`},
+		"def multi arg":      {In: `[1, 2]`, Op: `def pass(f): f; pass(.[])`, Expected: `[1,2]`},
+		"def single arg":     {In: `[1, 2]`, Op: `def collect(f): [f]; collect(.[])`, Expected: `[1,2]`},
+		"def nested":         {In: `2`, Op: `def f: def g: . * 3; g + 1; f`, Expected: `7`},
+		"def nested param":   {In: `2`, Op: `def f(x): def g: x * 3; g; f(. + 1)`, Expected: `9`},
+		"def captured":       {In: `2`, Op: `. as $x | def f: $x * 10; 5 | f`, Expected: `20`},
+		"def shadow":         {In: `2`, Op: `def f: 1; def f: 2; f`, Expected: `2`},
+		"def arity":          {In: `2`, Op: `def f: 1; def f(x): x + 1; [f, f(10)]`, Expected: `[1,11]`},
+		"def builtin":        {In: `[1]`, Op: `def length: "overridden"; length`, Expected: `"overridden"`},
+		"def scope":          {In: `2`, Op: `(def f: 1; f), f`, HasError: true},
+		"def path":           {In: `{"a": {"b": 1}}`, Op: `def ab: .a.b; path(ab)`, Expected: `["a","b"]`},
+		"def assign":         {In: `{"a": {"b": 1}}`, Op: `def ab: .a.b; ab |= . + 1`, Expected: `{"a": {"b": 2}}`},
+		"def param path":     {In: `{"a": {"b": 1}}`, Op: `def at(f): f; path(at(.a.b))`, Expected: `["a","b"]`},
+		"def undefined":      {In: `2`, Op: `def f(x): y; f(1)`, HasError: true},
+		"def missing semi":   {In: `2`, Op: `def f: 1 f`, HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op)
+			if tc.HasError && err != nil {
+				return
+			}
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}
+
+func TestWithFunction(t *testing.T) {
+	wrap := jq.OpFunc(func(in []byte) ([]byte, error) {
+		return append(append([]byte{'['}, in...), ']'), nil
+	})
+	none := jq.OpFunc(func([]byte) ([]byte, error) {
+		return nil, nil
+	})
+
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+		HasError bool
+	}{
+		"function":           {In: `{"a": 1}`, Op: `.a | wrap`, Expected: `[1]`},
+		"function composed":  {In: `[1, 2]`, Op: `map(wrap)`, Expected: `[[1],[2]]`},
+		"function nothing":   {In: `[1, 2]`, Op: `[.[] | none]`, Expected: `[]`},
+		"function def":       {In: `1`, Op: `def wrap: "defined"; wrap`, Expected: `"defined"`},
+		"function builtin":   {In: `"abc"`, Op: `length`, Expected: `["abc"]`},
+		"function arguments": {In: `1`, Op: `wrap(.)`, HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op, jq.WithFunction("wrap", wrap), jq.WithFunction("none", none), jq.WithFunction("length", wrap))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}
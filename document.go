@@ -0,0 +1,114 @@
+package jq
+
+import (
+	"bytes"
+
+	"github.com/bubunyo/go-jq/scanner"
+)
+
+// Document is a JSON document indexed once, in a single pass, so that the queries applied to it find keys, elements
+// and members by jumping through the index rather than scanning the document again. It suits evaluating many queries
+// against the same document, such as a set of rules against a request body.
+type Document struct {
+	in   []byte
+	tape *scanner.Tape
+}
+
+// NewDocument validates and indexes the JSON document provided; the document must not be modified while in use
+func NewDocument(in []byte) (*Document, error) {
+	in = bytes.TrimSpace(in)
+	tape, err := scanner.Index(in)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{in: in, tape: tape}, nil
+}
+
+// Bytes returns the document
+func (d *Document) Bytes() []byte {
+	return d.in
+}
+
+// Apply applies op to the document. Queries returned by Parse use the index for the values they take from the document;
+// any other Op is applied to its bytes.
+func (d *Document) Apply(op Op) ([]byte, error) {
+	if q, ok := op.(*Query); ok {
+		return q.apply(d.in, d)
+	}
+	return op.Apply(d.in)
+}
+
+// node returns the node of the tape v is, when v is a value taken from the document rather than one computed by a
+// query; d may be nil, when no document is indexed
+func (d *Document) node(v []byte) (int, bool) {
+	if d == nil || len(v) == 0 {
+		return 0, false
+	}
+
+	// a value taken from the document shares its memory, so its offset follows from their capacities
+	pos := cap(d.in) - cap(v)
+	if pos < 0 || pos >= len(d.in) || &d.in[pos] != &v[0] {
+		return 0, false
+	}
+
+	n, ok := d.tape.Lookup(pos)
+	if !ok || len(d.tape.Value(n)) != len(v) {
+		return 0, false
+	}
+	return n, true
+}
+
+// findKey returns the value of the key k of the object v, with the index when v is part of the document
+func (d *Document) findKey(v, k []byte) ([]byte, error) {
+	n, ok := d.node(v)
+	if !ok {
+		return scanner.FindKey(v, 0, k)
+	}
+
+	n, err := d.tape.FindKey(n, k)
+	if err != nil {
+		return nil, err
+	}
+	return d.tape.Value(n), nil
+}
+
+// findIndex returns the element at index i of the array v, with the index when v is part of the document
+func (d *Document) findIndex(v []byte, i int) ([]byte, error) {
+	n, ok := d.node(v)
+	if !ok {
+		return scanner.FindIndex(v, 0, i)
+	}
+
+	n, err := d.tape.FindIndex(n, i)
+	if err != nil {
+		return nil, err
+	}
+	return d.tape.Value(n), nil
+}
+
+// eachElement calls fn with each element of the array v, as eachElement does
+func (d *Document) eachElement(v []byte, fn func(i int, v []byte) error) error {
+	n, ok := d.node(v)
+	if !ok {
+		return eachElement(v, fn)
+	}
+
+	i := 0
+	return d.tape.Elements(n, func(e int) error {
+		err := fn(i, d.tape.Value(e))
+		i++
+		return err
+	})
+}
+
+// eachMember calls fn with the key and value of each member of the object v, as eachMember does
+func (d *Document) eachMember(v []byte, fn func(key, v []byte) error) error {
+	n, ok := d.node(v)
+	if !ok {
+		return eachMember(v, fn)
+	}
+
+	return d.tape.Members(n, func(key []byte, value int) error {
+		return fn(key, d.tape.Value(value))
+	})
+}
@@ -0,0 +1,88 @@
+package jq_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// request is a request body that a set of rules is evaluated against
+var request = []byte(`{"headers":{"content-type":"application/json","x-tenant":"acme"},"user":{"id":7,"roles":["admin","dev"]},` +
+	`"items":[` + strings.Repeat(`{"sku":"a-1","qty":1,"tags":["x","y"]},`, 50) + `{"sku":"z-9","qty":3,"tags":[]}],"total":99.5}`)
+
+func BenchmarkDocument(t *testing.B) {
+	rules := []jq.Op{
+		jq.Must(jq.Parse(`.headers["x-tenant"] == "acme"`)),
+		jq.Must(jq.Parse(`.user.roles | index("admin") != null`)),
+		jq.Must(jq.Parse(`.items[50].qty > 2`)),
+		jq.Must(jq.Parse(`.total < 100`)),
+		jq.Must(jq.Parse(`[.items[] | .qty] | add`)),
+	}
+
+	for i := 0; i < t.N; i++ {
+		doc, err := jq.NewDocument(request)
+		require.NoError(t, err)
+		for _, rule := range rules {
+			_, err := doc.Apply(rule)
+			require.NoError(t, err)
+		}
+	}
+}
+
+func TestDocument(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+		HasError bool
+	}{
+		"field":             {In: string(request), Op: `.user.id`, Expected: `7`},
+		"fused path":        {In: string(request), Op: `.items[50].sku`, Expected: `"z-9"`},
+		"index":             {In: string(request), Op: `.headers["x-tenant"]`, Expected: `"acme"`},
+		"negative index":    {In: string(request), Op: `.user.roles[-1]`, Expected: `"dev"`},
+		"iterate array":     {In: string(request), Op: `[.items[] | .qty] | add`, Expected: `53`},
+		"iterate object":    {In: string(request), Op: `[.headers[]]`, Expected: `["application/json","acme"]`},
+		"keys":              {In: string(request), Op: `.headers | keys`, Expected: `["content-type","x-tenant"]`},
+		"computed values":   {In: `{"a":{"b":1}}`, Op: `{x: .a} | .x.b`, Expected: `1`},
+		"spaced":            {In: ` { "a" : [ 1 , { "b" : 2 } ] } `, Op: `.a[1].b`, Expected: `2`},
+		"escaped key":       {In: `{"a\/b":{"c":1}}`, Op: `.["a/b"].c`, Expected: `1`},
+		"null":              {In: `{"a":null}`, Op: `.a.b`, Expected: `null`},
+		"update":            {In: `{"a":{"b":1}}`, Op: `.a.b += 1`, Expected: `{"a":{"b":2}}`},
+		"multiple values":   {In: `{"a":[1,2]}`, Op: `.a[]`, Expected: `[1,2]`},
+		"missing key":       {In: `{"a":{}}`, Op: `.a.b`, Expected: `null`},
+		"out of bounds":     {In: `{"a":[]}`, Op: `.a[0]`, Expected: `null`},
+		"mismatched kind":   {In: `{"a":1}`, Op: `try .a.b catch .`, Expected: `"cannot index number with \"b\""`},
+		"invalid document":  {In: `{"a":1,}`, Op: `.a`, HasError: true},
+		"trailing document": {In: `{} {}`, Op: `.`, HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			doc, err := jq.NewDocument([]byte(tc.In))
+			if err == nil {
+				var data []byte
+				data, err = doc.Apply(jq.Must(jq.Parse(tc.Op)))
+				if !tc.HasError {
+					require.NoError(t, err)
+					assert.Equal(t, tc.Expected, string(data))
+				}
+			}
+			if tc.HasError {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestDocumentOps(t *testing.T) {
+	doc, err := jq.NewDocument([]byte(` {"a":{"b":[1,2]}} `))
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":{"b":[1,2]}}`, string(doc.Bytes()))
+
+	data, err := doc.Apply(jq.Chain(jq.Dot("a"), jq.Dot("b"), jq.Index(1)))
+	require.NoError(t, err)
+	assert.Equal(t, `2`, string(data))
+}
@@ -0,0 +1,903 @@
+package jq
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/bubunyo/go-jq/scanner"
+)
+
+// env holds the state shared by the nodes of a running query
+type env struct {
+	opts   *options
+	vars   *binding
+	labels *label
+	inputs *inputState
+	funcs  *closure
+	top    *env      // the environment the query started in, which closed functions are evaluated in
+	doc    *Document // the indexed document being queried, if any
+}
+
+// node is a compiled expression; eval calls fn with each value the expression produces for the input
+type node interface {
+	eval(e *env, in []byte, fn func([]byte) error) error
+}
+
+// pathNode is implemented by nodes that can be used as path expressions; evalPath calls fn with each value the
+// expression produces along with the path locating that value within the input
+type pathNode interface {
+	evalPath(e *env, in []byte, p path, fn func([]byte, path) error) error
+}
+
+// path is a sequence of object keys, array indices and slices, each held as a JSON value
+type path [][]byte
+
+// append returns a new path extended by elem without modifying the receiver
+func (p path) append(elem ...[]byte) path {
+	return append(p[:len(p):len(p)], elem...)
+}
+
+func (p path) bytes() []byte {
+	return appendArray(nil, p)
+}
+
+// evalPath evaluates n as a path expression
+func evalPath(e *env, n node, in []byte, p path, fn func([]byte, path) error) error {
+	if pn, ok := n.(pathNode); ok {
+		return pn.evalPath(e, in, p, fn)
+	}
+
+	// report the first value produced by the invalid path expression as jq does
+	err := n.eval(e, in, func(v []byte) error {
+		return fmt.Errorf("invalid path expression with result %s", truncate(v))
+	})
+	if err == nil {
+		return nil
+	}
+	return err
+}
+
+// stop is returned by callbacks to end an evaluation early; each use allocates its own so nested stops don't clash,
+// which requires stop to have a size as pointers to distinct zero-size values may be equal
+type stop struct{ _ byte }
+
+func (*stop) Error() string { return "stop" }
+
+// first returns the first value n produces for in, reporting false when it produces none
+func first(e *env, n node, in []byte) ([]byte, bool, error) {
+	var result []byte
+	done := &stop{}
+	err := n.eval(e, in, func(v []byte) error {
+		result = v
+		return done
+	})
+	switch {
+	case err == done:
+		return result, true, nil
+	case err != nil:
+		return nil, false, err
+	default:
+		return nil, false, nil
+	}
+}
+
+// passthrough tracks errors returned by downstream callbacks so that constructs which suppress errors, such as try and
+// //, only suppress errors raised by their own operands
+type passthrough struct {
+	err error
+}
+
+func (p *passthrough) wrap(fn func([]byte) error) func([]byte) error {
+	return func(v []byte) error {
+		if err := fn(v); err != nil {
+			p.err = err
+			return err
+		}
+		return nil
+	}
+}
+
+func (p *passthrough) wrapPath(fn func([]byte, path) error) func([]byte, path) error {
+	return func(v []byte, pa path) error {
+		if err := fn(v, pa); err != nil {
+			p.err = err
+			return err
+		}
+		return nil
+	}
+}
+
+type identityNode struct{}
+
+func (identityNode) eval(_ *env, in []byte, fn func([]byte) error) error {
+	return fn(in)
+}
+
+func (identityNode) evalPath(_ *env, in []byte, p path, fn func([]byte, path) error) error {
+	return fn(in, p)
+}
+
+// fieldNode extracts a key from its input; the key is matched against the raw key tokens of the object
+type fieldNode struct {
+	name string
+	raw  []byte
+	key  []byte
+}
+
+func newFieldNode(name string) *fieldNode {
+	key := quote(name)
+	return &fieldNode{name: name, raw: key[1 : len(key)-1], key: key}
+}
+
+// lookup returns the value of the key within in; as in jq, a key missing from an object and any key of null are null
+func (n *fieldNode) lookup(d *Document, in []byte) ([]byte, error) {
+	k, err := kindOf(in)
+	if err != nil {
+		return nil, err
+	}
+
+	switch k {
+	case kindNull:
+		return nullValue, nil
+	case kindObject:
+		v, err := d.findKey(in, n.raw)
+		if absent(err) {
+			return nullValue, nil
+		}
+		return v, err
+	default:
+		return nil, fmt.Errorf("cannot index %v with %s", k, n.key)
+	}
+}
+
+func (n *fieldNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	v, err := n.lookup(e.doc, in)
+	if err != nil {
+		return err
+	}
+	return fn(v)
+}
+
+func (n *fieldNode) evalPath(e *env, in []byte, p path, fn func([]byte, path) error) error {
+	v, err := n.lookup(e.doc, in)
+	if err != nil {
+		return err
+	}
+	return fn(v, p.append(n.key))
+}
+
+// indexNode extracts an array element or object key computed by index, evaluated against the input, from each value
+// produced by target
+type indexNode struct {
+	target node
+	index  node
+}
+
+func (n *indexNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	return n.target.eval(e, in, func(t []byte) error {
+		return n.index.eval(e, in, func(idx []byte) error {
+			v, err := indexIn(e.doc, t, idx)
+			if err != nil {
+				return err
+			}
+			return fn(v)
+		})
+	})
+}
+
+func (n *indexNode) evalPath(e *env, in []byte, p path, fn func([]byte, path) error) error {
+	return evalPath(e, n.target, in, p, func(t []byte, tp path) error {
+		return n.index.eval(e, in, func(idx []byte) error {
+			v, err := indexIn(e.doc, t, idx)
+			if err != nil {
+				return err
+			}
+			return fn(v, tp.append(idx))
+		})
+	})
+}
+
+// index looks up idx within t; as in jq, a missing key or element is null, and so is anything within null
+func index(t, idx []byte) ([]byte, error) {
+	return indexIn(nil, t, idx)
+}
+
+// indexIn looks up idx within t as index does, using the index of d when t is part of the document
+func indexIn(d *Document, t, idx []byte) ([]byte, error) {
+	kt, ki, err := kinds(t, idx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case kt == kindNull:
+		return nullValue, nil
+	case kt == kindObject && ki == kindString:
+		v, err := d.findKey(t, idx[1:len(idx)-1])
+		if absent(err) {
+			return nullValue, nil
+		}
+		return v, err
+	case kt == kindArray && ki == kindNumber:
+		i, err := toInt(idx)
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 {
+			n, err := count(t)
+			if err != nil {
+				return nil, err
+			}
+			i += n
+		}
+
+		var v []byte
+		if i < 0 {
+			err = scanner.ErrIndexOutOfBounds
+		} else {
+			v, err = d.findIndex(t, i)
+		}
+		if absent(err) {
+			return nullValue, nil
+		}
+		return v, err
+	case kt == kindArray && ki == kindObject:
+		start, end, err := sliceBounds(idx)
+		if err != nil {
+			return nil, err
+		}
+		return sliceRange(t, start, end, false)
+	default:
+		return nil, fmt.Errorf("cannot index %v with %v", kt, ki)
+	}
+}
+
+// absent reports whether err is a key or element missing from the value looked up, which jq takes for null
+func absent(err error) bool {
+	return errors.Is(err, scanner.ErrKeyNotFound) || errors.Is(err, scanner.ErrIndexOutOfBounds)
+}
+
+// count returns the number of elements in an array
+func count(in []byte) (int, error) {
+	n := 0
+	_, err := scanner.Elements(in, 0, func(int, int) error {
+		n++
+		return nil
+	})
+	return n, err
+}
+
+// sliceNode extracts the elements between from and to, inclusive, from each array produced by target; missing bounds
+// default to the start and end of the array and negative bounds count from the end
+type sliceNode struct {
+	target node
+	from   node
+	to     node
+}
+
+func (n *sliceNode) bounds(e *env, in []byte, fn func(from, to []byte) error) error {
+	from, to := node(literalNode{nullValue}), node(literalNode{nullValue})
+	if n.from != nil {
+		from = n.from
+	}
+	if n.to != nil {
+		to = n.to
+	}
+
+	return to.eval(e, in, func(t []byte) error {
+		return from.eval(e, in, func(f []byte) error {
+			return fn(f, t)
+		})
+	})
+}
+
+func (n *sliceNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	return n.target.eval(e, in, func(t []byte) error {
+		return n.bounds(e, in, func(from, to []byte) error {
+			v, err := sliceRange(t, from, to, true)
+			if err != nil {
+				return err
+			}
+			return fn(v)
+		})
+	})
+}
+
+func (n *sliceNode) evalPath(e *env, in []byte, p path, fn func([]byte, path) error) error {
+	return evalPath(e, n.target, in, p, func(t []byte, tp path) error {
+		return n.bounds(e, in, func(from, to []byte) error {
+			v, err := sliceRange(t, from, to, true)
+			if err != nil {
+				return err
+			}
+
+			elem, err := slicePathElement(from, to)
+			if err != nil {
+				return err
+			}
+
+			return fn(v, tp.append(elem))
+		})
+	})
+}
+
+// slicePathElement builds the {"start":..., "end":...} path element for an inclusive slice
+func slicePathElement(from, to []byte) ([]byte, error) {
+	elem := []byte(`{"start":`)
+	elem = append(elem, from...)
+	elem = append(elem, `,"end":`...)
+	if to[0] == 'n' {
+		elem = append(elem, to...)
+	} else {
+		end, err := toInt(to)
+		if err != nil {
+			return nil, err
+		}
+		// path elements use an exclusive end where null selects the end of the array
+		if end++; end == 0 {
+			elem = append(elem, nullValue...)
+		} else {
+			elem = strconv.AppendInt(elem, int64(end), 10)
+		}
+	}
+	return append(elem, '}'), nil
+}
+
+// sliceRange extracts the elements of the array t between from and to, either of which may be null to select the
+// start or end of the array; negative bounds count from the end of the array. As in jq, bounds beyond the array are
+// clamped to it.
+func sliceRange(t, from, to []byte, inclusive bool) ([]byte, error) {
+	k, err := kindOf(t)
+	if err != nil {
+		return nil, err
+	}
+	if k == kindNull {
+		return nullValue, nil
+	}
+	if k != kindArray {
+		return nil, fmt.Errorf("cannot slice %v", k)
+	}
+
+	f := 0
+	if from[0] != 'n' {
+		if f, err = toInt(from); err != nil {
+			return nil, err
+		}
+	}
+
+	l, hasEnd := 0, to[0] != 'n'
+	if hasEnd {
+		if l, err = toInt(to); err != nil {
+			return nil, err
+		}
+		if inclusive {
+			// an inclusive end of -1 selects the last element
+			if l++; l == 0 {
+				hasEnd = false
+			}
+		}
+	}
+
+	// non-negative bounds within the array can be located without first counting the elements; bounds beyond it are
+	// clamped once the elements are counted
+	if f >= 0 && (!hasEnd || l > f) {
+		var v []byte
+		if hasEnd {
+			v, err = scanner.FindRange(t, 0, f, l-1)
+		} else {
+			v, err = scanner.FindFrom(t, 0, f)
+		}
+		if err == nil {
+			return v, nil
+		}
+	}
+
+	n, err := count(t)
+	if err != nil {
+		return nil, err
+	}
+	if f < 0 {
+		f += n
+	}
+	if !hasEnd {
+		l = n
+	} else if l < 0 {
+		l += n
+	}
+
+	f, l = clamp(f, 0, n), clamp(l, 0, n)
+	if l <= f {
+		return []byte("[]"), nil
+	}
+	return scanner.FindRange(t, 0, f, l-1)
+}
+
+func clamp(v, lo, hi int) int {
+	switch {
+	case v < lo:
+		return lo
+	case v > hi:
+		return hi
+	default:
+		return v
+	}
+}
+
+// sliceBounds extracts the start and end of a {"start":..., "end":...} path element
+func sliceBounds(idx []byte) ([]byte, []byte, error) {
+	start, err := scanner.FindKey(idx, 0, []byte("start"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid slice %s", idx)
+	}
+	end, err := scanner.FindKey(idx, 0, []byte("end"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid slice %s", idx)
+	}
+	return start, end, nil
+}
+
+// iterateNode produces each element of the arrays, or each value of the objects, produced by target
+type iterateNode struct {
+	target node
+}
+
+func (n *iterateNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	return n.target.eval(e, in, func(t []byte) error {
+		k, err := kindOf(t)
+		if err != nil {
+			return err
+		}
+
+		switch k {
+		case kindArray:
+			return e.doc.eachElement(t, func(_ int, v []byte) error { return fn(v) })
+		case kindObject:
+			return e.doc.eachMember(t, func(_, v []byte) error { return fn(v) })
+		default:
+			return fmt.Errorf("cannot iterate over %v", k)
+		}
+	})
+}
+
+func (n *iterateNode) evalPath(e *env, in []byte, p path, fn func([]byte, path) error) error {
+	return evalPath(e, n.target, in, p, func(t []byte, tp path) error {
+		return eachChild(t, tp, fn)
+	})
+}
+
+// eachChild calls fn with each element or member value of t along with its path; null has no children
+func eachChild(t []byte, p path, fn func([]byte, path) error) error {
+	k, err := kindOf(t)
+	if err != nil {
+		return err
+	}
+
+	switch k {
+	case kindNull:
+		return nil
+	case kindArray:
+		return eachElement(t, func(i int, v []byte) error {
+			return fn(v, p.append(strconv.AppendInt(nil, int64(i), 10)))
+		})
+	case kindObject:
+		return eachMember(t, func(key, v []byte) error {
+			return fn(v, p.append(key))
+		})
+	default:
+		return fmt.Errorf("cannot iterate over %v", k)
+	}
+}
+
+type pipeNode struct {
+	lhs node
+	rhs node
+}
+
+func (n *pipeNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	return n.lhs.eval(e, in, func(v []byte) error {
+		return n.rhs.eval(e, v, fn)
+	})
+}
+
+func (n *pipeNode) evalPath(e *env, in []byte, p path, fn func([]byte, path) error) error {
+	return evalPath(e, n.lhs, in, p, func(v []byte, vp path) error {
+		return evalPath(e, n.rhs, v, vp, fn)
+	})
+}
+
+type commaNode struct {
+	lhs node
+	rhs node
+}
+
+func (n *commaNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	if err := n.lhs.eval(e, in, fn); err != nil {
+		return err
+	}
+	return n.rhs.eval(e, in, fn)
+}
+
+func (n *commaNode) evalPath(e *env, in []byte, p path, fn func([]byte, path) error) error {
+	if err := evalPath(e, n.lhs, in, p, fn); err != nil {
+		return err
+	}
+	return evalPath(e, n.rhs, in, p, fn)
+}
+
+type literalNode struct {
+	value []byte
+}
+
+func (n literalNode) eval(_ *env, _ []byte, fn func([]byte) error) error {
+	return fn(n.value)
+}
+
+// arrayNode collects the values produced by body into an array
+type arrayNode struct {
+	body node
+}
+
+func (n *arrayNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	if n.body == nil {
+		return fn([]byte("[]"))
+	}
+
+	var values [][]byte
+	err := n.body.eval(e, in, func(v []byte) error {
+		values = append(values, v)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return fn(appendArray(nil, values))
+}
+
+type objectEntry struct {
+	key   node
+	value node
+}
+
+// objectNode constructs an object for every combination of the keys and values its entries produce
+type objectNode struct {
+	entries []objectEntry
+}
+
+func (n *objectNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	return n.build(e, in, 0, nil, fn)
+}
+
+func (n *objectNode) build(e *env, in []byte, i int, ms []member, fn func([]byte) error) error {
+	if i == len(n.entries) {
+		b := objectBuilder{}
+		for _, m := range ms {
+			b.set(m.key, m.raw, m.value)
+		}
+		return fn(b.bytes())
+	}
+
+	entry := n.entries[i]
+	return entry.key.eval(e, in, func(k []byte) error {
+		if kk, err := kindOf(k); err != nil || kk != kindString {
+			return fmt.Errorf("object keys must be strings; got %s", truncate(k))
+		}
+		key, err := decodeString(k)
+		if err != nil {
+			return err
+		}
+
+		return entry.value.eval(e, in, func(v []byte) error {
+			next := append(ms[:len(ms):len(ms)], member{key: key, raw: k, value: v})
+			return n.build(e, in, i+1, next, fn)
+		})
+	})
+}
+
+type negateNode struct {
+	operand node
+}
+
+func (n *negateNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	return n.operand.eval(e, in, func(v []byte) error {
+		k, err := kindOf(v)
+		if err != nil {
+			return err
+		}
+		if k != kindNumber {
+			return fmt.Errorf("%v (%s) cannot be negated", k, truncate(v))
+		}
+		f, err := toFloat(v)
+		if err != nil {
+			return err
+		}
+		return fn(number(-f))
+	})
+}
+
+// binaryNode applies an arithmetic or comparison operator to every combination of the values of its operands
+type binaryNode struct {
+	op  string
+	fn  binaryOp
+	lhs node
+	rhs node
+}
+
+func (n *binaryNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	return n.rhs.eval(e, in, func(r []byte) error {
+		return n.lhs.eval(e, in, func(l []byte) error {
+			v, err := n.fn(l, r)
+			if err != nil {
+				return err
+			}
+			return fn(v)
+		})
+	})
+}
+
+type andNode struct {
+	lhs node
+	rhs node
+}
+
+func (n *andNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	return n.lhs.eval(e, in, func(l []byte) error {
+		if !truthy(l) {
+			return fn(falseValue)
+		}
+		return n.rhs.eval(e, in, func(r []byte) error {
+			return fn(boolValue(truthy(r)))
+		})
+	})
+}
+
+type orNode struct {
+	lhs node
+	rhs node
+}
+
+func (n *orNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	return n.lhs.eval(e, in, func(l []byte) error {
+		if truthy(l) {
+			return fn(trueValue)
+		}
+		return n.rhs.eval(e, in, func(r []byte) error {
+			return fn(boolValue(truthy(r)))
+		})
+	})
+}
+
+// alternativeNode produces the truthy values of lhs, ignoring its errors, or the values of rhs if there are none
+type alternativeNode struct {
+	lhs node
+	rhs node
+}
+
+func (n *alternativeNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	found := false
+	var pt passthrough
+	err := n.lhs.eval(e, in, pt.wrap(func(v []byte) error {
+		if !truthy(v) {
+			return nil
+		}
+		found = true
+		return fn(v)
+	}))
+	if pt.err != nil {
+		return pt.err
+	}
+	if found {
+		return nil
+	}
+	if err != nil && isControl(err) {
+		return err
+	}
+	return n.rhs.eval(e, in, fn)
+}
+
+func (n *alternativeNode) evalPath(e *env, in []byte, p path, fn func([]byte, path) error) error {
+	found := false
+	var pt passthrough
+	err := evalPath(e, n.lhs, in, p, pt.wrapPath(func(v []byte, vp path) error {
+		if !truthy(v) {
+			return nil
+		}
+		found = true
+		return fn(v, vp)
+	}))
+	if pt.err != nil {
+		return pt.err
+	}
+	if found {
+		return nil
+	}
+	if err != nil && isControl(err) {
+		return err
+	}
+	return evalPath(e, n.rhs, in, p, fn)
+}
+
+type ifNode struct {
+	cond node
+	then node
+	els  node
+}
+
+func (n *ifNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	return n.cond.eval(e, in, func(c []byte) error {
+		if truthy(c) {
+			return n.then.eval(e, in, fn)
+		}
+		return n.els.eval(e, in, fn)
+	})
+}
+
+func (n *ifNode) evalPath(e *env, in []byte, p path, fn func([]byte, path) error) error {
+	return n.cond.eval(e, in, func(c []byte) error {
+		if truthy(c) {
+			return evalPath(e, n.then, in, p, fn)
+		}
+		return evalPath(e, n.els, in, p, fn)
+	})
+}
+
+// tryNode suppresses errors raised by body, passing the error message to handler when one is provided
+type tryNode struct {
+	body    node
+	handler node
+}
+
+func (n *tryNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	var pt passthrough
+	err := n.body.eval(e, in, pt.wrap(fn))
+	if pt.err != nil {
+		return pt.err
+	}
+	return n.catch(e, err, fn)
+}
+
+func (n *tryNode) evalPath(e *env, in []byte, p path, fn func([]byte, path) error) error {
+	var pt passthrough
+	err := evalPath(e, n.body, in, p, pt.wrapPath(fn))
+	if pt.err != nil {
+		return pt.err
+	}
+	if err == nil || isControl(err) {
+		return err
+	}
+	if n.handler != nil {
+		return fmt.Errorf("invalid path expression: try with catch")
+	}
+	return nil
+}
+
+func (n *tryNode) catch(e *env, err error, fn func([]byte) error) error {
+	if err == nil || isControl(err) {
+		return err
+	}
+	if n.handler == nil {
+		return nil
+	}
+	return n.handler.eval(e, errorValue(err), fn)
+}
+
+// errorValue returns the value passed to catch handlers for err: the value given to error(v), or the message of any
+// other error
+func errorValue(err error) []byte {
+	var ve *ValueError
+	if errors.As(err, &ve) {
+		return ve.Value
+	}
+	return quote(err.Error())
+}
+
+// isControl reports whether err is used for control flow rather than signalling a failure and so must not be caught
+func isControl(err error) bool {
+	var s *stop
+	return errors.As(err, &s) || isHalt(err)
+}
+
+// stringNode builds a string from literal parts and the values of interpolated expressions
+type stringNode struct {
+	parts []node
+}
+
+func (n *stringNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	return n.build(e, in, 0, nil, fn)
+}
+
+func (n *stringNode) build(e *env, in []byte, i int, buf []byte, fn func([]byte) error) error {
+	if i == len(n.parts) {
+		return fn(appendQuoted(nil, buf))
+	}
+
+	// the text between interpolations is a string literal, which stringifies to its content like any other literal
+	if lit, ok := n.parts[i].(literalNode); ok {
+		s, err := stringify(lit.value)
+		if err != nil {
+			return err
+		}
+		return n.build(e, in, i+1, append(buf[:len(buf):len(buf)], s...), fn)
+	}
+
+	return n.parts[i].eval(e, in, func(v []byte) error {
+		s, err := stringify(v)
+		if err != nil {
+			return err
+		}
+		return n.build(e, in, i+1, append(buf[:len(buf):len(buf)], s...), fn)
+	})
+}
+
+// stringify returns the content of strings and the compact JSON text of any other value
+func stringify(v []byte) ([]byte, error) {
+	if k, _ := kindOf(v); k == kindString {
+		return unquote(v)
+	}
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// callNode invokes a builtin function with its unevaluated arguments
+type callNode struct {
+	name string
+	args []node
+	fn   *builtin
+}
+
+func (n *callNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	return n.fn.eval(e, in, n.args, fn)
+}
+
+func (n *callNode) evalPath(e *env, in []byte, p path, fn func([]byte, path) error) error {
+	if n.fn.path == nil {
+		return evalPath(e, nonPath{n}, in, p, fn)
+	}
+	return n.fn.path(e, in, p, n.args, fn)
+}
+
+// nonPath hides the path support of a node so that evalPath reports it as an invalid path expression
+type nonPath struct {
+	node
+}
+
+// opNode adapts an Op into an expression; an Op returning a nil result produces no value
+type opNode struct {
+	op Op
+}
+
+func (n opNode) eval(_ *env, in []byte, fn func([]byte) error) error {
+	v, err := n.op.Apply(in)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return nil
+	}
+	if v = bytes.TrimSpace(v); len(v) == 0 {
+		return errors.New("unexpected EOF")
+	}
+	return fn(v)
+}
+
+// evalPath allows Ops that select their input, such as numbers, to be used within path expressions
+func (n opNode) evalPath(_ *env, in []byte, p path, fn func([]byte, path) error) error {
+	v, err := n.op.Apply(in)
+	if err != nil {
+		return err
+	}
+	switch {
+	case v == nil:
+		return nil
+	case sameSlice(v, in):
+		return fn(in, p)
+	default:
+		return fmt.Errorf("invalid path expression with result %s", truncate(bytes.TrimSpace(v)))
+	}
+}
@@ -0,0 +1,111 @@
+package jq
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Explain describes the plan the query is evaluated with, after optimization: one expression per line, indented
+// beneath the expression it is part of. Chains of keys and indices fused into a single descent are shown as scan, and
+// constant expressions folded into their values as literal.
+func (q *Query) Explain() string {
+	var b strings.Builder
+	explain(&b, q.root, 0)
+	return b.String()
+}
+
+func explain(b *strings.Builder, n node, depth int) {
+	summary, children := describe(n)
+	b.WriteString(strings.Repeat("  ", depth))
+	b.WriteString(summary)
+	b.WriteByte('\n')
+	for _, c := range children {
+		if c != nil {
+			explain(b, c, depth+1)
+		}
+	}
+}
+
+// describe summarizes a node and returns the expressions within it; the bodies of functions are described where
+// they are defined rather than where they are called
+func describe(n node) (string, []node) {
+	switch n := n.(type) {
+	case identityNode:
+		return "identity", nil
+	case *fieldNode:
+		return "field " + pathString([]pathStep{{name: n.name, index: -1, field: true}}), nil
+	case *scanNode:
+		return "scan " + n.String(), nil
+	case literalNode:
+		return "literal " + string(truncate(n.value)), nil
+	case *variableNode:
+		return "variable $" + n.name, nil
+	case *pipeNode:
+		return "pipe", []node{n.lhs, n.rhs}
+	case *commaNode:
+		return "comma", []node{n.lhs, n.rhs}
+	case *indexNode:
+		return "index", []node{n.target, n.index}
+	case *sliceNode:
+		return "slice", []node{n.target, n.from, n.to}
+	case *iterateNode:
+		return "iterate", []node{n.target}
+	case *globNode:
+		return "keys " + n.pattern.String(), []node{n.target}
+	case *arrayNode:
+		return "array", []node{n.body}
+	case *objectNode:
+		var children []node
+		for _, entry := range n.entries {
+			children = append(children, entry.key, entry.value)
+		}
+		return "object", children
+	case *stringNode:
+		return "string", n.parts
+	case *negateNode:
+		return "negate", []node{n.operand}
+	case *binaryNode:
+		return "binary " + n.op, []node{n.lhs, n.rhs}
+	case *andNode:
+		return "and", []node{n.lhs, n.rhs}
+	case *orNode:
+		return "or", []node{n.lhs, n.rhs}
+	case *alternativeNode:
+		return "alternative", []node{n.lhs, n.rhs}
+	case *ifNode:
+		return "if", []node{n.cond, n.then, n.els}
+	case *tryNode:
+		return "try", []node{n.body, n.handler}
+	case *callNode:
+		return fmt.Sprintf("call %v/%v", n.name, len(n.args)), n.args
+	case *funcCallNode:
+		if n.def.param {
+			return "param " + n.def.name, nil
+		}
+		return "call " + n.def.String(), n.args
+	case *defNode:
+		return "def " + n.def.String(), []node{n.def.body, n.rest}
+	case *reduceNode:
+		return "reduce", []node{n.source, n.init, n.update}
+	case *foreachNode:
+		return "foreach", []node{n.source, n.init, n.update, n.extract}
+	case *labelNode:
+		return "label $" + n.name, []node{n.body}
+	case *breakNode:
+		return "break $" + n.name, nil
+	case *asNode:
+		return "as", []node{n.source, n.body}
+	case *assignNode:
+		return "assign " + n.op, []node{n.target, n.value}
+	case *updateNode:
+		return "update", []node{n.target, n.update}
+	case *walkNode:
+		return "walk", []node{n.f}
+	case opNode:
+		return fmt.Sprintf("op %T", n.op), nil
+	case nonPath:
+		return describe(n.node)
+	default:
+		return strings.TrimSuffix(strings.TrimPrefix(fmt.Sprintf("%T", n), "*jq."), "Node"), nil
+	}
+}
@@ -0,0 +1,244 @@
+package jq
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/bubunyo/go-jq/scanner"
+)
+
+// MultiOp extracts the values at several paths from a document in a single pass. The paths are merged into a trie that
+// is walked alongside the document, and each object or array is only scanned as far as the last member or element
+// the paths lead to, rather than once per path.
+type MultiOp struct {
+	paths []string
+	root  *trieNode
+}
+
+// trieNode is a point of the trie of paths: the paths that end there, by position, and the members and elements the
+// paths continuing from it lead to
+type trieNode struct {
+	results  []int
+	fields   map[string]*trieNode
+	elements map[int]*trieNode
+	last     int // the greatest index of elements
+}
+
+// pathStep is a step of a path of keys and indices: a key to look up, or an element when index isn't -1
+type pathStep struct {
+	name  string
+	index int
+	field bool // the key was written .name rather than .["name"], which errors report differently
+}
+
+// errExtracted stops scanning an object or array once everything the paths lead to within it has been extracted
+var errExtracted = errors.New("extracted")
+
+// ParseMulti compiles paths made of keys and non-negative indices, such as .user.name, .items[0].id or
+// ."app.kubernetes.io/name", into a MultiOp
+func ParseMulti(paths ...string) (*MultiOp, error) {
+	m := &MultiOp{paths: paths, root: &trieNode{}}
+	for i, p := range paths {
+		steps, err := parseStaticPath(p)
+		if err != nil {
+			return nil, err
+		}
+
+		n := m.root
+		for _, s := range steps {
+			n = n.child(s)
+		}
+		n.results = append(n.results, i)
+	}
+	return m, nil
+}
+
+// Extract returns the values at several paths of a document, found in a single pass; see MultiOp
+func Extract(in []byte, paths ...string) ([][]byte, error) {
+	m, err := ParseMulti(paths...)
+	if err != nil {
+		return nil, err
+	}
+	return m.Extract(in)
+}
+
+// Extract returns the value at each path, in the order the paths were given, or nil for a path that doesn't lead to a
+// value because a key is missing, an index is out of range or a value along the way is of another kind. Values are
+// returned without being copied, and the parts of the document no path leads to are not validated.
+func (m *MultiOp) Extract(in []byte) ([][]byte, error) {
+	in = bytes.TrimSpace(in)
+	if len(in) == 0 {
+		return nil, errors.New("unexpected EOF")
+	}
+
+	values := make([][]byte, len(m.paths))
+	if err := m.root.extract(in, values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Apply returns an array of the values at each path, with null for the paths that don't lead to a value
+func (m *MultiOp) Apply(in []byte) ([]byte, error) {
+	values, err := m.Extract(in)
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range values {
+		if v == nil {
+			values[i] = nullValue
+		}
+	}
+	return appendArray(nil, values), nil
+}
+
+func (n *trieNode) child(s pathStep) *trieNode {
+	if s.index >= 0 {
+		if n.elements == nil {
+			n.elements = map[int]*trieNode{}
+		}
+		c, ok := n.elements[s.index]
+		if !ok {
+			c = &trieNode{}
+			n.elements[s.index] = c
+			n.last = max(n.last, s.index)
+		}
+		return c
+	}
+
+	if n.fields == nil {
+		n.fields = map[string]*trieNode{}
+	}
+	c, ok := n.fields[s.name]
+	if !ok {
+		c = &trieNode{}
+		n.fields[s.name] = c
+	}
+	return c
+}
+
+// extract fills the results of the paths that end at v or continue within it
+func (n *trieNode) extract(v []byte, values [][]byte) error {
+	for _, i := range n.results {
+		values[i] = v
+	}
+
+	var err error
+	switch {
+	case len(n.fields) > 0 && v[0] == '{':
+		// the first of duplicate keys is the one extracted, as with FindKey
+		found := make(map[*trieNode]bool, len(n.fields))
+		_, err = scanner.Members(v, 0, func(keyStart, keyEnd, valueStart, valueEnd int) error {
+			c, err := n.field(v[keyStart:keyEnd])
+			if err != nil || c == nil || found[c] {
+				return err
+			}
+
+			found[c] = true
+			if err := c.extract(v[valueStart:valueEnd], values); err != nil {
+				return err
+			}
+			if len(found) == len(n.fields) {
+				return errExtracted
+			}
+			return nil
+		})
+	case len(n.elements) > 0 && v[0] == '[':
+		i := 0
+		_, err = scanner.Elements(v, 0, func(start, end int) error {
+			if c := n.elements[i]; c != nil {
+				if err := c.extract(v[start:end], values); err != nil {
+					return err
+				}
+			}
+			if i++; i > n.last {
+				return errExtracted
+			}
+			return nil
+		})
+	}
+	if err == errExtracted {
+		return nil
+	}
+	return err
+}
+
+// field returns the child for the member with the quoted key given, or nil if no path leads to it
+func (n *trieNode) field(key []byte) (*trieNode, error) {
+	content := key[1 : len(key)-1]
+	if bytes.IndexByte(content, '\\') < 0 {
+		return n.fields[string(content)], nil
+	}
+
+	name, err := unquote(key)
+	if err != nil {
+		return nil, err
+	}
+	return n.fields[string(name)], nil
+}
+
+// parseStaticPath parses a jq path made only of keys and non-negative indices into its steps
+func parseStaticPath(p string) ([]pathStep, error) {
+	op, err := Parse(p)
+	if err != nil {
+		return nil, err
+	}
+
+	steps, ok := staticPath(op.(*Query).root)
+	if !ok {
+		return nil, fmt.Errorf("%v is not a path of keys and non-negative indices", p)
+	}
+	return steps, nil
+}
+
+// staticPath returns the steps of an expression such as .a.b[0] that leads to a single place known before evaluation
+func staticPath(n node) ([]pathStep, bool) {
+	switch n := n.(type) {
+	case identityNode:
+		return nil, true
+	case *fieldNode:
+		return []pathStep{{name: n.name, index: -1, field: true}}, true
+	case *scanNode:
+		return n.steps[:len(n.steps):len(n.steps)], true
+	case *pipeNode:
+		lhs, ok := staticPath(n.lhs)
+		if !ok {
+			return nil, false
+		}
+		rhs, ok := staticPath(n.rhs)
+		return append(lhs, rhs...), ok
+	case *indexNode:
+		steps, ok := staticPath(n.target)
+		idx, isLiteral := n.index.(literalNode)
+		if !ok || !isLiteral {
+			return nil, false
+		}
+		s, ok := literalStep(idx.value)
+		return append(steps, s), ok
+	default:
+		return nil, false
+	}
+}
+
+// literalStep converts the literal index of .[index] into a step, if it is a key or a non-negative integer
+func literalStep(v []byte) (pathStep, bool) {
+	k, err := kindOf(v)
+	if err != nil {
+		return pathStep{}, false
+	}
+
+	switch k {
+	case kindString:
+		name, err := decodeString(v)
+		return pathStep{name: name, index: -1}, err == nil
+	case kindNumber:
+		f, err := toFloat(v)
+		if err != nil || f < 0 || f != float64(int(f)) {
+			return pathStep{}, false
+		}
+		return pathStep{index: int(f)}, true
+	default:
+		return pathStep{}, false
+	}
+}
@@ -0,0 +1,79 @@
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// event is an event record whose fields are extracted together
+const event = `{"id":"e-1","type":"click","user":{"id":7,"name":"Ama","address":{"city":"Accra"}},` +
+	`"items":[{"id":1,"price":10},{"id":2,"price":20}],"meta":{"page":1,"app.version":"1.2"}}`
+
+func BenchmarkExtract(t *testing.B) {
+	op, err := jq.ParseMulti(".id", ".type", ".user.id", ".user.name", ".user.address.city", ".items[0].id", ".items[1].price", `.meta["app.version"]`)
+	require.NoError(t, err)
+	data := []byte(event)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Extract(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Paths    []string
+		Expected []string // "" for a path that doesn't lead to a value
+		HasError bool
+	}{
+		"fields":         {In: event, Paths: []string{".type", ".id"}, Expected: []string{`"click"`, `"e-1"`}},
+		"nested":         {In: event, Paths: []string{".user.name", ".user.address.city", ".user.address"}, Expected: []string{`"Ama"`, `"Accra"`, `{"city":"Accra"}`}},
+		"elements":       {In: event, Paths: []string{".items[1].price", ".items[0].id", ".items[0]"}, Expected: []string{`20`, `1`, `{"id":1,"price":10}`}},
+		"quoted keys":    {In: event, Paths: []string{`.meta["app.version"]`, `."meta"."page"`}, Expected: []string{`"1.2"`, `1`}},
+		"identity":       {In: `{"a":1}`, Paths: []string{"."}, Expected: []string{`{"a":1}`}},
+		"pipes":          {In: event, Paths: []string{".user | .id"}, Expected: []string{`7`}},
+		"duplicates":     {In: event, Paths: []string{".id", ".id"}, Expected: []string{`"e-1"`, `"e-1"`}},
+		"missing":        {In: event, Paths: []string{".user.email", ".items[5].id", ".id"}, Expected: []string{"", "", `"e-1"`}},
+		"other kinds":    {In: event, Paths: []string{".id.x", ".user[0]", ".items.id"}, Expected: []string{"", "", ""}},
+		"null":           {In: `{"a":null}`, Paths: []string{".a", ".a.b"}, Expected: []string{`null`, ""}},
+		"spaced input":   {In: ` { "a" : [ 1 , { "b" : 2 } ] } `, Paths: []string{".a[1].b", ".a[0]"}, Expected: []string{`2`, `1`}},
+		"escaped key":    {In: `{"a\/b":1}`, Paths: []string{`.["a/b"]`}, Expected: []string{`1`}},
+		"duplicate keys": {In: `{"a":1,"a":2,"b":3}`, Paths: []string{".a", ".b"}, Expected: []string{`1`, `3`}},
+		"rest unscanned": {In: `{"a":1,"b":}`, Paths: []string{".a"}, Expected: []string{`1`}},
+		"no paths":       {In: `{}`, Expected: []string{}},
+		"invalid input":  {In: `{"a":}`, Paths: []string{".a"}, HasError: true},
+		"empty input":    {In: ``, Paths: []string{".a"}, HasError: true},
+		"negative index": {In: `[1]`, Paths: []string{".[-1]"}, HasError: true},
+		"not a path":     {In: `{}`, Paths: []string{".a[]"}, HasError: true},
+		"invalid path":   {In: `{}`, Paths: []string{".a["}, HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			values, err := jq.Extract([]byte(tc.In), tc.Paths...)
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			actual := make([]string, len(values))
+			for i, v := range values {
+				actual[i] = string(v)
+			}
+			assert.Equal(t, tc.Expected, actual)
+		})
+	}
+}
+
+func TestMultiOp(t *testing.T) {
+	op := jq.Must(jq.ParseMulti(".user.name", ".user.email", ".items[1].id"))
+
+	data, err := jq.Chain(jq.Dot("payload"), op).Apply([]byte(`{"payload":` + event + `}`))
+	require.NoError(t, err)
+	assert.Equal(t, `["Ama",null,2]`, string(data))
+}
@@ -0,0 +1,186 @@
+package jq
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bubunyo/go-jq/scanner"
+)
+
+// KeyMatches selects what the key matching Ops, DotGlob and DotFold, produce
+type KeyMatches int
+
+const (
+	// MatchedValues produces an array of the values of the members whose keys match
+	MatchedValues KeyMatches = iota
+	// MatchedEntries produces an array of {"key": k, "value": v} objects, as to_entries does
+	MatchedEntries
+)
+
+// DotGlob extracts the members of an object whose keys match a glob such as app.kubernetes.io/*, in which * matches
+// any run of characters, ? matches any single character and \ escapes the character following it
+func DotGlob(pattern string, matches KeyMatches) OpFunc {
+	return parseKeyPattern(pattern, false).op(matches)
+}
+
+// DotFold extracts the members of an object whose keys equal key under Unicode case folding, so that content-type
+// finds Content-Type
+func DotFold(key string, matches KeyMatches) OpFunc {
+	return literalKeyPattern(key, true).op(matches)
+}
+
+// anyKey matches every key, as .* does
+var anyKey = parseKeyPattern("*", false)
+
+// globRune is an element of a key pattern: a character to match, or one of the wildcards * and ?
+type globRune struct {
+	r        rune
+	wildcard byte
+}
+
+// keyPattern matches the keys of object members against a glob, optionally ignoring case
+type keyPattern struct {
+	source string
+	glob   []globRune
+	fold   bool
+	wild   bool // source is a glob rather than a key
+}
+
+func parseKeyPattern(pattern string, fold bool) *keyPattern {
+	k := &keyPattern{source: pattern, fold: fold, wild: true}
+	for i := 0; i < len(pattern); {
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		i += size
+		switch {
+		case r == '*' || r == '?':
+			k.glob = append(k.glob, globRune{wildcard: byte(r)})
+		case r == '\\' && i < len(pattern):
+			r, size = utf8.DecodeRuneInString(pattern[i:])
+			i += size
+			k.glob = append(k.glob, globRune{r: r})
+		default:
+			k.glob = append(k.glob, globRune{r: r})
+		}
+	}
+	return k
+}
+
+// literalKeyPattern matches key itself, without wildcards
+func literalKeyPattern(key string, fold bool) *keyPattern {
+	k := &keyPattern{source: key, fold: fold}
+	for _, r := range key {
+		k.glob = append(k.glob, globRune{r: r})
+	}
+	return k
+}
+
+// match reports whether the decoded content of a key matches; a * that fails to match tries again one character
+// further on, so matching takes at most len(key) * len(glob) steps
+func (k *keyPattern) match(key []byte) bool {
+	g, i := 0, 0
+	star, mark := -1, 0
+	for i < len(key) {
+		r, size := utf8.DecodeRune(key[i:])
+		if g < len(k.glob) {
+			switch p := k.glob[g]; {
+			case p.wildcard == '*':
+				star, mark = g, i
+				g++
+				continue
+			case p.wildcard == '?' || p.r == r || k.fold && equalFold(p.r, r):
+				g++
+				i += size
+				continue
+			}
+		}
+		if star < 0 {
+			return false
+		}
+		_, size = utf8.DecodeRune(key[mark:])
+		mark += size
+		g, i = star+1, mark
+	}
+	for g < len(k.glob) && k.glob[g].wildcard == '*' {
+		g++
+	}
+	return g == len(k.glob)
+}
+
+// equalFold reports whether two characters are equal under simple Unicode case folding
+func equalFold(a, b rune) bool {
+	for r := unicode.SimpleFold(a); r != a; r = unicode.SimpleFold(r) {
+		if r == b {
+			return true
+		}
+	}
+	return false
+}
+
+// members calls fn with the quoted key and value of each member of an object whose key matches; null has no members
+func (k *keyPattern) members(in []byte, fn func(key, v []byte) error) error {
+	kind, err := kindOf(in)
+	if err != nil {
+		return err
+	}
+
+	switch kind {
+	case kindNull:
+		return nil
+	case kindObject:
+		return scanner.FindKeys(in, 0, k.match, fn)
+	default:
+		return fmt.Errorf("cannot index %v with %s", kind, k)
+	}
+}
+
+func (k *keyPattern) String() string {
+	s := string(quote(k.source))
+	if k.wild {
+		s = "*" + s
+	}
+	if k.fold {
+		s = "~" + s
+	}
+	return s
+}
+
+func (k *keyPattern) op(matches KeyMatches) OpFunc {
+	return func(in []byte) ([]byte, error) {
+		out := []byte{'['}
+		err := k.members(in, func(key, v []byte) error {
+			if len(out) > 1 {
+				out = append(out, ',')
+			}
+			if matches == MatchedEntries {
+				out = append(append(append(out, `{"key":`...), key...), `,"value":`...)
+				out = append(append(out, v...), '}')
+				return nil
+			}
+			out = append(out, v...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return append(out, ']'), nil
+	}
+}
+
+// globNode produces the value of each member whose key matches pattern, of each object produced by target
+type globNode struct {
+	target  node
+	pattern *keyPattern
+}
+
+func (n *globNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	return n.target.eval(e, in, func(t []byte) error {
+		return n.pattern.members(t, func(_, v []byte) error { return fn(v) })
+	})
+}
+
+func (n *globNode) evalPath(e *env, in []byte, p path, fn func([]byte, path) error) error {
+	return evalPath(e, n.target, in, p, func(t []byte, tp path) error {
+		return n.pattern.members(t, func(key, v []byte) error { return fn(v, tp.append(key)) })
+	})
+}
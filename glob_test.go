@@ -0,0 +1,150 @@
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// labels are the labels of a Kubernetes object, the recommended ones sharing a prefix
+const labels = `{"app.kubernetes.io/name":"web","app.kubernetes.io/part-of":"shop","tier":"frontend"}`
+
+// manifest is a Kubernetes object with those labels, and annotations differing only in the case of their keys
+const manifest = `{"metadata":{"labels":` + labels + `,"annotations":{"Content-Type":"text/html","content-type":"text/plain"}}}`
+
+func BenchmarkDotGlob(t *testing.B) {
+	op := jq.Chain(jq.Dot("metadata"), jq.Dot("labels"), jq.DotGlob("app.kubernetes.io/*", jq.MatchedValues))
+	data := []byte(manifest)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestDotGlob(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Pattern  string
+		Matches  jq.KeyMatches
+		Expected string
+		HasError bool
+	}{
+		"prefix":         {In: labels, Pattern: "app.kubernetes.io/*", Expected: `["web","shop"]`},
+		"entries":        {In: labels, Pattern: "app.kubernetes.io/*", Matches: jq.MatchedEntries, Expected: `[{"key":"app.kubernetes.io/name","value":"web"},{"key":"app.kubernetes.io/part-of","value":"shop"}]`},
+		"any key":        {In: `{"a":1,"b":[2]}`, Pattern: "*", Expected: `[1,[2]]`},
+		"single":         {In: `{"a1":1,"a22":2,"b1":3}`, Pattern: "a?", Expected: `[1]`},
+		"infix":          {In: `{"x-a-y":1,"x-y":2,"xy":3}`, Pattern: "x-*y", Expected: `[1,2]`},
+		"backtracking":   {In: `{"aXbXbc":1,"aXbXb":2}`, Pattern: "a*b*c", Expected: `[1]`},
+		"escaped":        {In: `{"a*":1,"ab":2}`, Pattern: `a\*`, Expected: `[1]`},
+		"escaped key":    {In: `{"a\/b":1}`, Pattern: "a/*", Expected: `[1]`},
+		"unicode":        {In: `{"héllo":1}`, Pattern: "h?llo", Expected: `[1]`},
+		"case sensitive": {In: `{"Tier":1}`, Pattern: "tier*", Expected: `[]`},
+		"no matches":     {In: `{"a":1}`, Pattern: "b*", Expected: `[]`},
+		"null":           {In: `null`, Pattern: "*", Expected: `[]`},
+		"array":          {In: `[1]`, Pattern: "*", HasError: true},
+		"invalid":        {In: `{"a":}`, Pattern: "*", HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			data, err := jq.DotGlob(tc.Pattern, tc.Matches).Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}
+
+func TestDotFold(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Key      string
+		Matches  jq.KeyMatches
+		Expected string
+		HasError bool
+	}{
+		"other case":   {In: labels, Key: "TIER", Expected: `["frontend"]`},
+		"header":       {In: `{"Content-Type":"text/html","Accept":"*/*"}`, Key: "content-type", Expected: `["text/html"]`},
+		"several":      {In: `{"Content-Type":"text/html","content-type":"text/plain"}`, Key: "CONTENT-TYPE", Matches: jq.MatchedEntries, Expected: `[{"key":"Content-Type","value":"text/html"},{"key":"content-type","value":"text/plain"}]`},
+		"unicode":      {In: `{"ÉTÉ":1,"Straße":2}`, Key: "été", Expected: `[1]`},
+		"kelvin":       {In: `{"K":1}`, Key: "k", Expected: `[1]`},
+		"no wildcards": {In: `{"a*":1,"ab":2}`, Key: "A*", Expected: `[1]`},
+		"array":        {In: `[1]`, Key: "a", HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			data, err := jq.DotFold(tc.Key, tc.Matches).Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}
+
+func TestKeyPatterns(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+		HasError bool
+	}{
+		"any key":          {In: `{"a":1,"b":2}`, Op: `.*`, Expected: `[1,2]`},
+		"any nested key":   {In: `{"a":{"x":{"n":1},"y":{"n":2}}}`, Op: `[.a.* | .n]`, Expected: `[1,2]`},
+		"any key piped":    {In: `{"a":1,"b":2}`, Op: `[.* | . + 1]`, Expected: `[2,3]`},
+		"any key try":      {In: `[1]`, Op: `[.*?]`, Expected: `[]`},
+		"glob":             {In: manifest, Op: `[.metadata.labels[*"app.kubernetes.io/*"]]`, Expected: `["web","shop"]`},
+		"escaped wildcard": {In: `{"a*":1,"ab":2}`, Op: `.[*"a\\*"]`, Expected: `[1]`},
+		"fold":             {In: manifest, Op: `.metadata.annotations[~"CONTENT-TYPE"]`, Expected: `["text/html","text/plain"]`},
+		"fold glob":        {In: `{"X-Trace":1,"x-span":2,"y":3}`, Op: `[.[~*"x-*"]]`, Expected: `[1,2]`},
+		"fold wildcard":    {In: `{"A*":1,"ab":2}`, Op: `.[~"a*"]`, Expected: `[1]`},
+		"exact key":        {In: `{"a":1}`, Op: `.["a"]`, Expected: `1`},
+		"literal ?":        {In: `{"what?":1,"whatx":2}`, Op: `.["what?"]`, Expected: `1`},
+		"literal *":        {In: `{"a*":1,"ab":2}`, Op: `.["a*"]`, Expected: `1`},
+		"missing literal":  {In: `{"ab":2}`, Op: `.["a*"]`, Expected: `null`},
+		"null":             {In: `null`, Op: `[.*]`, Expected: `[]`},
+		"path":             {In: manifest, Op: `[path(.metadata.labels[*"app*"])]`, Expected: `[["metadata","labels","app.kubernetes.io/name"],["metadata","labels","app.kubernetes.io/part-of"]]`},
+		"update":           {In: `{"a1":1,"a2":2,"b":3}`, Op: `.[*"a*"] |= . * 10`, Expected: `{"a1":10,"a2":20,"b":3}`},
+		"delete":           {In: `{"a1":1,"a2":2,"b":3}`, Op: `del(.[*"a*"])`, Expected: `{"b":3}`},
+		"multiplication":   {In: `3`, Op: `. * 2`, Expected: `6`},
+		"adjacent product": {In: `3`, Op: `.*2`, Expected: `6`},
+		"map product":      {In: `[1,2]`, Op: `map(.*2)`, Expected: `[2,4]`},
+		"iterate product":  {In: `[1,2]`, Op: `[.[]|.*10]`, Expected: `[10,20]`},
+		"field product":    {In: `{"a":3,"n":2}`, Op: `.a * .n`, Expected: `6`},
+		"negative product": {In: `3`, Op: `.*-1`, Expected: `-3`},
+		"not an object":    {In: `[1]`, Op: `.*`, HasError: true},
+		"fold expression":  {In: `{}`, Op: `.[~.a]`, HasError: true},
+		"glob expression":  {In: `{}`, Op: `.[*.a]`, HasError: true},
+		"dotted product":   {In: `{"a":3,"n":2}`, Op: `.a.*.n`, HasError: true},
+		"dotted negative":  {In: `{"a":3}`, Op: `.a.*-1`, HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op)
+			if !tc.HasError {
+				require.NoError(t, err)
+			}
+			if err == nil {
+				var data []byte
+				data, err = op.Apply([]byte(tc.In))
+				if !tc.HasError {
+					require.NoError(t, err)
+					assert.Equal(t, tc.Expected, string(data))
+				}
+			}
+			if tc.HasError {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
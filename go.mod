@@ -2,7 +2,10 @@ module github.com/bubunyo/go-jq
 
 go 1.24.2
 
-require github.com/stretchr/testify v1.11.1
+require (
+	github.com/klauspost/compress v1.18.0
+	github.com/stretchr/testify v1.11.1
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
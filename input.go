@@ -0,0 +1,194 @@
+package jq
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/bubunyo/go-jq/scanner"
+)
+
+// Inputs is a sequence of JSON documents evaluated by Query.Run; Next returns io.EOF once the sequence is exhausted.
+// Inputs that also implement Filename() string report the name of the file the last document was read from to
+// input_filename.
+type Inputs interface {
+	Next() ([]byte, error)
+}
+
+// InputsFromSlice returns the documents provided as Inputs
+func InputsFromSlice(docs ...[]byte) Inputs {
+	return &sliceInputs{docs: docs}
+}
+
+type sliceInputs struct {
+	docs [][]byte
+}
+
+func (s *sliceInputs) Next() ([]byte, error) {
+	if len(s.docs) == 0 {
+		return nil, io.EOF
+	}
+	doc := s.docs[0]
+	s.docs = s.docs[1:]
+	return doc, nil
+}
+
+// inputsFromBytes returns the documents held in in, separated by optional whitespace, as Inputs; each document is
+// a slice of in
+func inputsFromBytes(in []byte) Inputs {
+	return &bytesInputs{in: in}
+}
+
+type bytesInputs struct {
+	in  []byte
+	pos int
+}
+
+func (b *bytesInputs) Next() ([]byte, error) {
+	start := len(b.in) - len(bytes.TrimLeft(b.in[b.pos:], " \t\r\n"))
+	if start == len(b.in) {
+		return nil, io.EOF
+	}
+	end, err := scanner.Any(b.in, start)
+	if err != nil {
+		return nil, err
+	}
+	b.pos = end
+	return b.in[start:end], nil
+}
+
+// InputsFromChannel returns the documents received from ch as Inputs; the sequence ends when ch is closed
+func InputsFromChannel(ch <-chan []byte) Inputs {
+	return chanInputs(ch)
+}
+
+type chanInputs <-chan []byte
+
+func (c chanInputs) Next() ([]byte, error) {
+	doc, ok := <-c
+	if !ok {
+		return nil, io.EOF
+	}
+	return doc, nil
+}
+
+// InputsFromReader returns the JSON documents read from r, separated by optional whitespace as in NDJSON, as Inputs.
+// Documents are read as they are requested, so a stream is never held in memory as a whole. When r has a Name method,
+// as *os.File does, its name is reported to input_filename.
+func InputsFromReader(r io.Reader) Inputs {
+	in := &readerInputs{dec: json.NewDecoder(r)}
+	if named, ok := r.(interface{ Name() string }); ok {
+		in.name = named.Name()
+	}
+	return in
+}
+
+type readerInputs struct {
+	dec  *json.Decoder
+	name string
+}
+
+func (r *readerInputs) Next() ([]byte, error) {
+	var doc json.RawMessage
+	if err := r.dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (r *readerInputs) Filename() string {
+	return r.name
+}
+
+// errNoMoreInputs is raised by input once the documents being evaluated are exhausted
+var errNoMoreInputs = errors.New("No more inputs")
+
+// inputState holds the documents shared by a running query and its input and inputs builtins
+type inputState struct {
+	docs     Inputs
+	filename []byte
+	strict   bool // documents are validated with scanner.Validate
+}
+
+// next returns the next document, or io.EOF when there are none
+func (s *inputState) next() ([]byte, error) {
+	if s == nil {
+		return nil, io.EOF
+	}
+	doc, err := s.docs.Next()
+	if err != nil {
+		return nil, err
+	}
+	if named, ok := s.docs.(interface{ Filename() string }); ok && named.Filename() != "" {
+		s.filename = quote(named.Filename())
+	}
+
+	doc = bytes.TrimSpace(doc)
+	if len(doc) == 0 {
+		return nil, errors.New("unexpected EOF")
+	}
+	if s.strict {
+		if err := scanner.Validate(doc); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// slurp returns the remaining documents collected into an array
+func (s *inputState) slurp() ([]byte, error) {
+	var docs [][]byte
+	for {
+		doc, err := s.next()
+		if err == io.EOF {
+			return appendArray(nil, docs), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+}
+
+func inputBuiltins() {
+	define("input", 0, &builtin{
+		eval: func(e *env, _ []byte, _ []node, fn func([]byte) error) error {
+			doc, err := e.inputs.next()
+			if err == io.EOF {
+				return errNoMoreInputs
+			}
+			if err != nil {
+				return err
+			}
+			return fn(doc)
+		},
+	})
+
+	define("inputs", 0, &builtin{
+		eval: func(e *env, _ []byte, _ []node, fn func([]byte) error) error {
+			for {
+				doc, err := e.inputs.next()
+				if err == io.EOF {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				if err := fn(doc); err != nil {
+					return err
+				}
+			}
+		},
+		stream: true,
+	})
+
+	define("input_filename", 0, &builtin{
+		eval: func(e *env, _ []byte, _ []node, fn func([]byte) error) error {
+			if e.inputs == nil || e.inputs.filename == nil {
+				return fn(nullValue)
+			}
+			return fn(e.inputs.filename)
+		},
+	})
+}
@@ -0,0 +1,179 @@
+package jq_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkRun(t *testing.B) {
+	op := jq.Must(jq.Parse(`reduce inputs as $x (0; . + $x.n)`, jq.WithNullInput())).(*jq.Query)
+	data := strings.Repeat(`{"n": 1}`+"\n", 100)
+
+	for i := 0; i < t.N; i++ {
+		err := op.Run(jq.InputsFromReader(strings.NewReader(data)), func([]byte) error { return nil })
+		require.NoError(t, err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	ndjson := "{\"n\": 1}\n{\"n\": 2}\n\n{\"n\": 3}\n"
+
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Opts     []jq.Option
+		Expected []string
+		HasError bool
+	}{
+		"each":               {In: ndjson, Op: `.n`, Expected: []string{`1`, `2`, `3`}},
+		"stream":             {In: ndjson, Op: `.n, .n * 10`, Expected: []string{`1`, `10`, `2`, `20`, `3`, `30`}},
+		"input":              {In: ndjson, Op: `[.n, input.n]`, Expected: []string{`[1,2]`}, HasError: true},
+		"input pairs":        {In: "1 2 3 4", Op: `[., input]`, Expected: []string{`[1,2]`, `[3,4]`}},
+		"inputs":             {In: ndjson, Op: `[., inputs]`, Expected: []string{`[{"n": 1},{"n": 2},{"n": 3}]`}},
+		"reduce inputs":      {In: ndjson, Op: `reduce inputs as $x (0; . + $x.n)`, Opts: []jq.Option{jq.WithNullInput()}, Expected: []string{`6`}},
+		"null input":         {In: ndjson, Op: `.`, Opts: []jq.Option{jq.WithNullInput()}, Expected: []string{`null`}},
+		"null input inputs":  {In: ndjson, Op: `[inputs.n]`, Opts: []jq.Option{jq.WithNullInput()}, Expected: []string{`[1,2,3]`}},
+		"slurp":              {In: ndjson, Op: `map(.n)`, Opts: []jq.Option{jq.WithSlurp()}, Expected: []string{`[1,2,3]`}},
+		"slurp empty":        {In: "", Op: `length`, Opts: []jq.Option{jq.WithSlurp()}, Expected: []string{`0`}},
+		"slurp null input":   {In: "1 2", Op: `[., input]`, Opts: []jq.Option{jq.WithSlurp(), jq.WithNullInput()}, Expected: []string{`[null,[1,2]]`}},
+		"no more inputs":     {In: "1", Op: `input`, HasError: true},
+		"try input":          {In: "1", Op: `try input catch .`, Expected: []string{`"No more inputs"`}},
+		"halt":               {In: ndjson, Op: `if .n == 2 then halt else .n end`, Expected: []string{`1`}},
+		"invalid":            {In: "1 {", Op: `.`, Expected: []string{`1`}, HasError: true},
+		"input_filename":     {In: "1", Op: `input_filename`, Expected: []string{`null`}},
+		"prog_name":          {In: "1", Op: `$__prog_name`, Expected: []string{`"jq"`}},
+		"prog_name provided": {In: "1", Op: `$__prog_name`, Opts: []jq.Option{jq.WithProgramName("indexer")}, Expected: []string{`"indexer"`}},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op, tc.Opts...)
+			require.NoError(t, err)
+
+			var values []string
+			err = op.(*jq.Query).Run(jq.InputsFromReader(strings.NewReader(tc.In)), func(v []byte) error {
+				values = append(values, string(v))
+				return nil
+			})
+			if tc.HasError {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.Expected, values)
+		})
+	}
+}
+
+func TestRunInputs(t *testing.T) {
+	op := jq.Must(jq.Parse(`.n`)).(*jq.Query)
+	collect := func(docs jq.Inputs) []string {
+		var values []string
+		err := op.Run(docs, func(v []byte) error {
+			values = append(values, string(v))
+			return nil
+		})
+		require.NoError(t, err)
+		return values
+	}
+
+	assert.Equal(t, []string{`1`, `2`}, collect(jq.InputsFromSlice([]byte(`{"n":1}`), []byte(` {"n":2} `))))
+
+	ch := make(chan []byte, 2)
+	ch <- []byte(`{"n":3}`)
+	ch <- []byte(`{"n":4}`)
+	close(ch)
+	assert.Equal(t, []string{`3`, `4`}, collect(jq.InputsFromChannel(ch)))
+}
+
+func TestInputFilename(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "events.json")
+	require.NoError(t, os.WriteFile(name, []byte(`{"n":1}`), 0o600))
+
+	f, err := os.Open(name)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var values []string
+	err = jq.Must(jq.Parse(`input_filename`)).(*jq.Query).Run(jq.InputsFromReader(f), func(v []byte) error {
+		values = append(values, string(v))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{`"` + name + `"`}, values)
+}
+
+func TestApplyInputs(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Opts     []jq.Option
+		Expected string
+		HasError bool
+	}{
+		"null input":      {In: `{"a":1}`, Op: `[., input]`, Opts: []jq.Option{jq.WithNullInput()}, Expected: `[null,{"a":1}]`},
+		"null input docs": {In: `1 [2]`, Op: `[inputs]`, Opts: []jq.Option{jq.WithNullInput()}, Expected: `[1,[2]]`},
+		"slurp":           {In: `{"a":1}`, Op: `.`, Opts: []jq.Option{jq.WithSlurp()}, Expected: `[{"a":1}]`},
+		"slurp docs":      {In: "1 2\n{\"a\":3}", Op: `.`, Opts: []jq.Option{jq.WithSlurp()}, Expected: `[1,2,{"a":3}]`},
+		"slurp adjacent":  {In: `[1]{"a":2}"b"`, Op: `length`, Opts: []jq.Option{jq.WithSlurp()}, Expected: `3`},
+		"input":           {In: `1 2`, Op: `[., input]`, Expected: `[1,2]`},
+		"inputs":          {In: "1\n2\n3\n", Op: `reduce inputs as $x (.; . + $x)`, Expected: `6`},
+		"no more inputs":  {In: `{"a":1}`, Op: `input`, HasError: true},
+		"malformed doc":   {In: `1 }`, Op: `.`, Opts: []jq.Option{jq.WithSlurp()}, HasError: true},
+		"single doc":      {In: `{"a": 1} `, Op: `.a`, Expected: `1`},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			data, err := jq.Must(jq.Parse(tc.Op, tc.Opts...)).Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}
+
+func TestStrict(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Expected string
+		HasError bool
+	}{
+		"valid":          {In: "{\"a\": [1, -2.5e3, true, null, \"caf\u00e9 \\n\"]}", Expected: `5`},
+		"trailing comma": {In: `{"a": [1, 2,]}`, HasError: true},
+		"missing comma":  {In: `{"a": [1 2]}`, HasError: true},
+		"plus":           {In: `{"a": [+1]}`, HasError: true},
+		"two dots":       {In: `{"a": [1.2.3]}`, HasError: true},
+		"unicode space":  {In: "{\"a\":\u00a0[]}", HasError: true},
+		"control":        {In: "{\"a\": [\"x\ty\"]}", HasError: true},
+		"literal suffix": {In: `{"a": [trueX]}`, HasError: true},
+		"invalid escape": {In: `{"a": ["\q"]}`, HasError: true},
+		"invalid utf8":   {In: "{\"a\": [\"\xff\"]}", HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			data, err := jq.Must(jq.Parse(`.a | length`, jq.WithStrict())).Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+
+	// documents read by input are validated too
+	op := jq.Must(jq.Parse(`[., input]`, jq.WithStrict())).(*jq.Query)
+	err := op.Run(jq.InputsFromSlice([]byte(`{"a": [1, 2.5e3]}`), []byte(`[1,]`)), func([]byte) error { return nil })
+	assert.Error(t, err)
+}
@@ -0,0 +1,212 @@
+package jmespath
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// node is an expression of the AST. It is evaluated by translating it into a jq filter producing exactly one value,
+// with the helpers of functions.jq, so that every node may be composed with | wherever JMESPath applies one
+// expression to the result of another.
+type node interface {
+	jq() string
+}
+
+// quote writes a string as a jq string literal; JSON strings are valid jq strings, interpolation aside, which JSON
+// can't express
+func quote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// identity is the current node, @, and the implicit left hand side of expressions such as [0] and *
+type identity struct{}
+
+func (identity) jq() string {
+	return "."
+}
+
+type field struct {
+	name string
+}
+
+func (n *field) jq() string {
+	return "jp_field(" + quote(n.name) + ")"
+}
+
+type index struct {
+	index int
+}
+
+func (n *index) jq() string {
+	return "jp_index(" + strconv.Itoa(n.index) + ")"
+}
+
+// slice selects elements as Python slices do; a nil start or stop defaults to the end the step moves away from
+type slice struct {
+	start, stop *int
+	step        int
+}
+
+func (n *slice) jq() string {
+	bound := func(i *int) string {
+		if i == nil {
+			return "null"
+		}
+		return strconv.Itoa(*i)
+	}
+	return "jp_slice(" + bound(n.start) + "; " + bound(n.stop) + "; " + strconv.Itoa(n.step) + ")"
+}
+
+// pipe applies right to the result of left, both for subexpressions, a.b, and pipes, a | b; a projection on the left
+// of a pipe has already been collected into an array, so the right hand side applies to the whole of it
+type pipe struct {
+	left, right node
+}
+
+func (n *pipe) jq() string {
+	return "(" + n.left.jq() + ") | (" + n.right.jq() + ")"
+}
+
+// projectionKind distinguishes the projections of arrays, [*], the values of objects, *, and flattened arrays, []
+type projectionKind int
+
+const (
+	arrayProjection projectionKind = iota
+	valueProjection
+	flattenProjection
+)
+
+// projection applies right to each element of the result of left, collecting the results that aren't null
+type projection struct {
+	kind        projectionKind
+	left, right node
+}
+
+func (n *projection) jq() string {
+	switch n.kind {
+	case valueProjection:
+		return "(" + n.left.jq() + ") | jp_project_values(" + n.right.jq() + ")"
+	case flattenProjection:
+		return "(" + n.left.jq() + ") | jp_flatten | jp_project(" + n.right.jq() + ")"
+	default:
+		return "(" + n.left.jq() + ") | jp_project(" + n.right.jq() + ")"
+	}
+}
+
+// filter projects right over the elements of the result of left for which condition is truthy
+type filter struct {
+	left, right, condition node
+}
+
+func (n *filter) jq() string {
+	return "(" + n.left.jq() + ") | jp_project_filter(" + n.condition.jq() + "; " + n.right.jq() + ")"
+}
+
+type or struct {
+	left, right node
+}
+
+func (n *or) jq() string {
+	return "jp_or(" + n.left.jq() + "; " + n.right.jq() + ")"
+}
+
+type and struct {
+	left, right node
+}
+
+func (n *and) jq() string {
+	return "jp_and(" + n.left.jq() + "; " + n.right.jq() + ")"
+}
+
+type not struct {
+	operand node
+}
+
+func (n *not) jq() string {
+	return "(" + n.operand.jq() + ") | jp_not"
+}
+
+// comparison compares two values; equality applies to any values and ordering only to numbers
+type comparison struct {
+	op          tokenKind
+	left, right node
+}
+
+func (n *comparison) jq() string {
+	left, right := n.left.jq(), n.right.jq()
+	switch n.op {
+	case tokEQ:
+		return "(" + left + ") == (" + right + ")"
+	case tokNE:
+		return "(" + left + ") != (" + right + ")"
+	case tokLT:
+		return "jp_lt(" + left + "; " + right + ")"
+	case tokLTE:
+		return "jp_lte(" + left + "; " + right + ")"
+	case tokGT:
+		return "jp_gt(" + left + "; " + right + ")"
+	default:
+		return "jp_gte(" + left + "; " + right + ")"
+	}
+}
+
+// literal is a raw string or JSON literal, held as compact JSON
+type literal string
+
+func (n literal) jq() string {
+	return string(n)
+}
+
+// multiSelectList collects the results of several expressions into an array, or produces null when applied to null
+type multiSelectList []node
+
+func (n multiSelectList) jq() string {
+	elements := make([]string, len(n))
+	for i, e := range n {
+		elements[i] = "(" + e.jq() + ")"
+	}
+	return "jp_multi([" + strings.Join(elements, ", ") + "])"
+}
+
+// multiSelectHash collects the results of several expressions into an object, or produces null when applied to null
+type multiSelectHash struct {
+	keys   []string
+	values []node
+}
+
+func (n *multiSelectHash) jq() string {
+	entries := make([]string, len(n.keys))
+	for i, key := range n.keys {
+		entries[i] = quote(key) + ": (" + n.values[i].jq() + ")"
+	}
+	return "jp_multi({" + strings.Join(entries, ", ") + "})"
+}
+
+// expref is an expression reference, &expr, which may only be passed to functions; it translates to the expression
+// itself, passed to the helper implementing the function as a filter rather than a value
+type expref struct {
+	expr node
+}
+
+func (n *expref) jq() string {
+	return n.expr.jq()
+}
+
+// call calls one of the built-in functions, passing the arguments of variadic functions as a single array
+type call struct {
+	name string
+	args []node
+}
+
+func (n *call) jq() string {
+	args := make([]string, len(n.args))
+	for i, arg := range n.args {
+		args[i] = "(" + arg.jq() + ")"
+	}
+	if functions[n.name].variadic {
+		return "jp_" + n.name + "([" + strings.Join(args, ", ") + "])"
+	}
+	return "jp_" + n.name + "(" + strings.Join(args, "; ") + ")"
+}
@@ -0,0 +1,56 @@
+package jmespath_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bubunyo/go-jq/jmespath"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// suite is a file of the JMESPath compliance test suite: groups of cases evaluated against a given document
+type suite []struct {
+	Given json.RawMessage `json:"given"`
+	Cases []struct {
+		Expression string          `json:"expression"`
+		Result     json.RawMessage `json:"result"`
+		Error      string          `json:"error"`
+	} `json:"cases"`
+}
+
+func TestCompliance(t *testing.T) {
+	files, err := filepath.Glob("testdata/compliance/*.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		var s suite
+		require.NoError(t, json.Unmarshal(data, &s))
+
+		t.Run(strings.TrimSuffix(filepath.Base(file), ".json"), func(t *testing.T) {
+			for _, group := range s {
+				for _, tc := range group.Cases {
+					op, err := jmespath.Parse(tc.Expression)
+					if err == nil {
+						var out []byte
+						out, err = op.Apply(group.Given)
+						if tc.Error == "" && assert.NoError(t, err, tc.Expression) {
+							assert.JSONEq(t, string(tc.Result), string(out), tc.Expression)
+						}
+					}
+					if tc.Error != "" {
+						assert.Error(t, err, "%v should fail with %v", tc.Expression, tc.Error)
+					} else {
+						assert.NoError(t, err, tc.Expression)
+					}
+				}
+			}
+		})
+	}
+}
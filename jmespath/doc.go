@@ -0,0 +1,3 @@
+// Package jmespath compiles JMESPath expressions into jq Ops. Expressions are translated into jq programs and
+// evaluated by the go-jq evaluator, so they share its scanner and work against raw JSON the same way jq selectors do.
+package jmespath
@@ -0,0 +1,80 @@
+package jmespath
+
+import (
+	_ "embed"
+	"fmt"
+)
+
+// runtime holds the jq definitions compiled expressions are evaluated with, one jp_ prefixed helper for each
+// built-in function and one for each construct that behaves differently in jq, such as projections
+//
+//go:embed functions.jq
+var runtime string
+
+// function describes the parameters of a built-in function; the helper implementing it is named after it, with the
+// jp_ prefix
+type function struct {
+	// exprefs marks the parameters taking expression references rather than values
+	exprefs []bool
+	// variadic functions take one or more arguments, all values
+	variadic bool
+}
+
+func params(n int) function {
+	return function{exprefs: make([]bool, n)}
+}
+
+// functions holds the built-in functions of the JMESPath specification
+var functions = map[string]function{
+	"abs":         params(1),
+	"avg":         params(1),
+	"ceil":        params(1),
+	"contains":    params(2),
+	"ends_with":   params(2),
+	"floor":       params(1),
+	"join":        params(2),
+	"keys":        params(1),
+	"length":      params(1),
+	"map":         {exprefs: []bool{true, false}},
+	"max":         params(1),
+	"max_by":      {exprefs: []bool{false, true}},
+	"merge":       {variadic: true},
+	"min":         params(1),
+	"min_by":      {exprefs: []bool{false, true}},
+	"not_null":    {variadic: true},
+	"reverse":     params(1),
+	"sort":        params(1),
+	"sort_by":     {exprefs: []bool{false, true}},
+	"starts_with": params(2),
+	"sum":         params(1),
+	"to_array":    params(1),
+	"to_number":   params(1),
+	"to_string":   params(1),
+	"type":        params(1),
+	"values":      params(1),
+}
+
+// check reports an unknown function, a call with the wrong number of arguments, or an argument that is an expression
+// reference where a value is expected or the other way round
+func check(name string, args []node) error {
+	fn, ok := functions[name]
+	switch {
+	case !ok:
+		return fmt.Errorf("jmespath: unknown function %v()", name)
+	case fn.variadic && len(args) == 0:
+		return fmt.Errorf("jmespath: invalid arity: %v() takes at least one argument", name)
+	case !fn.variadic && len(args) != len(fn.exprefs):
+		return fmt.Errorf("jmespath: invalid arity: %v() takes %v arguments, got %v", name, len(fn.exprefs), len(args))
+	}
+
+	for i, arg := range args {
+		_, isExpref := arg.(*expref)
+		if wants := !fn.variadic && fn.exprefs[i]; isExpref != wants {
+			if wants {
+				return fmt.Errorf("jmespath: invalid type: argument %v of %v() must be an expression reference", i+1, name)
+			}
+			return fmt.Errorf("jmespath: invalid type: argument %v of %v() can't be an expression reference", i+1, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package jmespath
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/bubunyo/go-jq"
+)
+
+// Parse compiles a JMESPath expression such as reservations[*].instances[?state.name=='running'].id. Applying the
+// Op produces the result of the expression, which is null when it selects nothing.
+func Parse(expression string) (jq.Op, error) {
+	program, err := translate(expression)
+	if err != nil {
+		return nil, err
+	}
+	op, err := jq.Parse(runtime + program)
+	if err != nil {
+		return nil, err
+	}
+	q := op.(*jq.Query)
+
+	return jq.OpFunc(func(in []byte) ([]byte, error) {
+		in = bytes.TrimSpace(in)
+		if len(in) == 0 {
+			return nil, errors.New("unexpected EOF")
+		}
+
+		var result []byte
+		err := q.Run(jq.InputsFromSlice(in), func(v []byte) error {
+			result = v
+			return nil
+		})
+		return result, err
+	}), nil
+}
+
+// translate returns the jq program a JMESPath expression compiles to, which relies on the definitions Parse prepends
+// to it
+func translate(expression string) (string, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return "", err
+	}
+	p := parser{tokens: tokens}
+	n, err := p.parse()
+	if err != nil {
+		return "", err
+	}
+	return n.jq(), nil
+}
@@ -0,0 +1,90 @@
+package jmespath_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq/jmespath"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const reservations = `{"reservations":[` +
+	`{"instances":[{"id":"i-1","state":{"name":"running"},"type":"t3.micro"},{"id":"i-2","state":{"name":"stopped"},"type":"t3.large"}]},` +
+	`{"instances":[{"id":"i-3","state":{"name":"running"},"type":"m5.large"}]}` +
+	`]}`
+
+func BenchmarkJMESPath(t *testing.B) {
+	op, err := jmespath.Parse(`reservations[*].instances[?state.name=='running'].id`)
+	require.NoError(t, err)
+	data := []byte(reservations)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestJMESPath(t *testing.T) {
+	testCases := map[string]struct {
+		In         string
+		Expression string
+		Expected   string
+		HasError   bool
+	}{
+		"field":              {In: `{"a":{"b":1}}`, Expression: `a.b`, Expected: `1`},
+		"missing field":      {In: `{"a":1}`, Expression: `b.c`, Expected: `null`},
+		"field of array":     {In: `[1]`, Expression: `a`, Expected: `null`},
+		"quoted field":       {In: `{"a b":1}`, Expression: `"a b"`, Expected: `1`},
+		"index":              {In: `[1,2,3]`, Expression: `[-1]`, Expected: `3`},
+		"index out of range": {In: `[1,2,3]`, Expression: `[3]`, Expected: `null`},
+		"slice":              {In: `[0,1,2,3,4]`, Expression: `[::-2]`, Expected: `[4,2,0]`},
+		"slice step 0":       {In: `[0,1]`, Expression: `[::0]`, HasError: true},
+		"nested projection":  {In: reservations, Expression: `reservations[*].instances[?state.name=='running'].id`, Expected: `[["i-1"],["i-3"]]`},
+		"flatten":            {In: reservations, Expression: `reservations[].instances[].id`, Expected: `["i-1","i-2","i-3"]`},
+		"value projection":   {In: `{"a":{"n":1},"b":{"n":2},"c":{}}`, Expression: `*.n`, Expected: `[1,2]`},
+		"pipe":               {In: reservations, Expression: `reservations[].instances[] | [0].id`, Expected: `"i-1"`},
+		"multi-select list":  {In: `{"a":1,"b":2}`, Expression: `[a, b]`, Expected: `[1,2]`},
+		"multi-select hash":  {In: reservations, Expression: `reservations[].instances[].{id: id, state: state.name}`, Expected: `[{"id":"i-1","state":"running"},{"id":"i-2","state":"stopped"},{"id":"i-3","state":"running"}]`},
+		"multi-select null":  {In: `{}`, Expression: `a.[b, c]`, Expected: `null`},
+		"or":                 {In: `{"a":[],"b":"x"}`, Expression: `a || b`, Expected: `"x"`},
+		"and":                {In: `{"a":0,"b":"x"}`, Expression: `a && b`, Expected: `"x"`},
+		"not":                {In: `{"a":""}`, Expression: `!a`, Expected: `true`},
+		"order non-numbers":  {In: `{"a":"x","b":"y"}`, Expression: `a < b`, Expected: `null`},
+		"literal":            {In: `null`, Expression: "`{\"a\": [1, 2]}`", Expected: `{"a":[1,2]}`},
+		"raw string":         {In: `null`, Expression: `'it\'s'`, Expected: `"it's"`},
+		"current node":       {In: `[1,2]`, Expression: `@`, Expected: `[1,2]`},
+		"sort_by":            {In: reservations, Expression: `sort_by(reservations[].instances[], &type)[*].id`, Expected: `["i-3","i-2","i-1"]`},
+		"max_by":             {In: `[{"n":1},{"n":3},{"n":2}]`, Expression: `max_by(@, &n).n`, Expected: `3`},
+		"map":                {In: `[{"n":1},{}]`, Expression: `map(&n, @)`, Expected: `[1,null]`},
+		"join":               {In: `["a","b"]`, Expression: `join(', ', @)`, Expected: `"a, b"`},
+		"merge":              {In: `{"a":{"x":1},"b":{"x":2,"y":3}}`, Expression: `merge(a, b)`, Expected: `{"x":2,"y":3}`},
+		"not_null":           {In: `{"b":false}`, Expression: `not_null(a, b)`, Expected: `false`},
+		"to_string":          {In: `{"a":[1]}`, Expression: `to_string(a)`, Expected: `"[1]"`},
+		"invalid type":       {In: `{"a":"x"}`, Expression: `abs(a)`, HasError: true},
+		"invalid arity":      {In: `null`, Expression: `abs(@, @)`, HasError: true},
+		"unknown function":   {In: `null`, Expression: `nope(@)`, HasError: true},
+		"expref as value":    {In: `null`, Expression: `length(&a)`, HasError: true},
+		"syntax":             {In: `null`, Expression: `a.`, HasError: true},
+		"empty input":        {In: ` `, Expression: `a`, HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jmespath.Parse(tc.Expression)
+			if !tc.HasError {
+				require.NoError(t, err)
+			}
+			if err == nil {
+				var data []byte
+				data, err = op.Apply([]byte(tc.In))
+				if !tc.HasError {
+					require.NoError(t, err)
+					assert.Equal(t, tc.Expected, string(data))
+				}
+			}
+			if tc.HasError {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
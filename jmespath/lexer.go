@@ -0,0 +1,189 @@
+package jmespath
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// tokenKind identifies a token of the JMESPath grammar
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdentifier
+	tokQuotedIdentifier
+	tokNumber
+	tokRawString
+	tokLiteral
+	tokDot
+	tokStar
+	tokCurrent
+	tokExpref
+	tokComma
+	tokColon
+	tokPipe
+	tokOr
+	tokAnd
+	tokNot
+	tokLbracket
+	tokRbracket
+	tokFlatten
+	tokFilter
+	tokLbrace
+	tokRbrace
+	tokLparen
+	tokRparen
+	tokEQ
+	tokNE
+	tokLT
+	tokLTE
+	tokGT
+	tokGTE
+)
+
+// bindingPowers decides how tightly each token binds to the expression on its left; tokens with a binding power below
+// projectionStop end the right hand side of a projection
+var bindingPowers = map[tokenKind]int{
+	tokPipe:     1,
+	tokOr:       2,
+	tokAnd:      3,
+	tokEQ:       5,
+	tokNE:       5,
+	tokLT:       5,
+	tokLTE:      5,
+	tokGT:       5,
+	tokGTE:      5,
+	tokFlatten:  9,
+	tokStar:     20,
+	tokFilter:   21,
+	tokDot:      40,
+	tokNot:      45,
+	tokLbrace:   50,
+	tokLbracket: 55,
+	tokLparen:   60,
+}
+
+const projectionStop = 10
+
+// operators maps the punctuation of the grammar to its tokens, longest first where one is a prefix of another
+var operators = []struct {
+	text string
+	kind tokenKind
+}{
+	{"[?", tokFilter}, {"[]", tokFlatten}, {"||", tokOr}, {"&&", tokAnd}, {"==", tokEQ}, {"!=", tokNE},
+	{"<=", tokLTE}, {">=", tokGTE}, {".", tokDot}, {"*", tokStar}, {"@", tokCurrent}, {"&", tokExpref},
+	{",", tokComma}, {":", tokColon}, {"|", tokPipe}, {"!", tokNot}, {"[", tokLbracket}, {"]", tokRbracket},
+	{"{", tokLbrace}, {"}", tokRbrace}, {"(", tokLparen}, {")", tokRparen}, {"<", tokLT}, {">", tokGT},
+}
+
+type token struct {
+	kind tokenKind
+	// text holds the name of an identifier, the digits of a number, the value of a raw string or the JSON of a literal
+	text string
+	pos  int
+}
+
+func syntaxError(pos int, format string, args ...any) error {
+	return fmt.Errorf("jmespath: syntax error at position %v: %v", pos, fmt.Sprintf(format, args...))
+}
+
+// tokenize splits an expression into tokens, ending with tokEOF
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	pos := 0
+	for {
+		for pos < len(expr) && strings.IndexByte(" \t\n\r", expr[pos]) >= 0 {
+			pos++
+		}
+		if pos == len(expr) {
+			return append(tokens, token{kind: tokEOF, pos: pos}), nil
+		}
+
+		t, end, err := next(expr, pos)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+		pos = end
+	}
+}
+
+// closing returns the position of the quote ending a quoted token beginning at start, skipping escaped characters, or
+// -1 when the token is unterminated
+func closing(expr string, start int, quote byte) int {
+	for i := start + 1; i < len(expr); i++ {
+		switch expr[i] {
+		case '\\':
+			i++
+		case quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// next reads the token beginning at pos, returning the position following it
+func next(expr string, pos int) (token, int, error) {
+	c := expr[pos]
+	switch {
+	case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		end := pos + 1
+		for end < len(expr) && isIdentifierChar(expr[end]) {
+			end++
+		}
+		return token{kind: tokIdentifier, text: expr[pos:end], pos: pos}, end, nil
+	case c == '-' || c >= '0' && c <= '9':
+		end := pos + 1
+		for end < len(expr) && expr[end] >= '0' && expr[end] <= '9' {
+			end++
+		}
+		if expr[pos:end] == "-" {
+			return token{}, 0, syntaxError(pos, "expected a digit after -")
+		}
+		return token{kind: tokNumber, text: expr[pos:end], pos: pos}, end, nil
+	case c == '"' || c == '\'' || c == '`':
+		end := closing(expr, pos, c)
+		if end < 0 {
+			return token{}, 0, syntaxError(pos, "unterminated %c", c)
+		}
+		t, err := quoted(expr[pos:end+1], pos)
+		return t, end + 1, err
+	}
+
+	for _, op := range operators {
+		if strings.HasPrefix(expr[pos:], op.text) {
+			return token{kind: op.kind, text: op.text, pos: pos}, pos + len(op.text), nil
+		}
+	}
+	return token{}, 0, syntaxError(pos, "unexpected %q", c)
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// quoted reads a quoted identifier, a raw string or a JSON literal, including its quotes
+func quoted(s string, pos int) (token, error) {
+	body := s[1 : len(s)-1]
+	switch s[0] {
+	case '"':
+		var name string
+		if err := json.Unmarshal([]byte(s), &name); err != nil {
+			return token{}, syntaxError(pos, "invalid quoted identifier %v", s)
+		}
+		return token{kind: tokQuotedIdentifier, text: name, pos: pos}, nil
+	case '\'':
+		return token{kind: tokRawString, text: rawEscapes.Replace(body), pos: pos}, nil
+	default:
+		var literal bytes.Buffer
+		if err := json.Compact(&literal, []byte(strings.ReplaceAll(body, "\\`", "`"))); err != nil {
+			return token{}, syntaxError(pos, "invalid JSON literal %v", s)
+		}
+		return token{kind: tokLiteral, text: literal.String(), pos: pos}, nil
+	}
+}
+
+// rawEscapes undoes the only escapes raw strings have, \' and \\
+var rawEscapes = strings.NewReplacer(`\'`, `'`, `\\`, `\`)
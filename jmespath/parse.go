@@ -0,0 +1,330 @@
+package jmespath
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// parser builds the AST of an expression by top down operator precedence: each token may begin an expression, nud,
+// or continue the expression on its left, led, when it binds more tightly than the operator being parsed
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(kind tokenKind, what string) error {
+	if t := p.next(); t.kind != kind {
+		return p.unexpected(t, what)
+	}
+	return nil
+}
+
+func (p *parser) unexpected(t token, what string) error {
+	if t.kind == tokEOF {
+		return syntaxError(t.pos, "expected %v, found the end of the expression", what)
+	}
+	return syntaxError(t.pos, "expected %v, found %q", what, t.text)
+}
+
+// parse parses a whole expression
+func (p *parser) parse() (node, error) {
+	n, err := p.parseExpression(0)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.unexpected(t, "the end of the expression")
+	}
+	return n, nil
+}
+
+// parseExpression parses an expression made of operators binding more tightly than bindingPower
+func (p *parser) parseExpression(bindingPower int) (node, error) {
+	left, err := p.nud(p.next())
+	if err != nil {
+		return nil, err
+	}
+	for bindingPower < bindingPowers[p.peek().kind] {
+		if left, err = p.led(p.next(), left); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+// nud parses an expression beginning with t
+func (p *parser) nud(t token) (node, error) {
+	switch t.kind {
+	case tokIdentifier, tokQuotedIdentifier:
+		if t.kind == tokQuotedIdentifier && p.peek().kind == tokLparen {
+			return nil, syntaxError(t.pos, "a quoted identifier can't name a function")
+		}
+		return &field{name: t.text}, nil
+	case tokRawString:
+		return literal(quote(t.text)), nil
+	case tokLiteral:
+		return literal(t.text), nil
+	case tokCurrent:
+		return identity{}, nil
+	case tokStar:
+		right, err := p.parseProjectionRHS(bindingPowers[tokStar])
+		return &projection{kind: valueProjection, left: identity{}, right: right}, err
+	case tokFlatten:
+		right, err := p.parseProjectionRHS(bindingPowers[tokFlatten])
+		return &projection{kind: flattenProjection, left: identity{}, right: right}, err
+	case tokFilter:
+		return p.parseFilter(identity{})
+	case tokLbracket:
+		switch next := p.peek().kind; {
+		case next == tokNumber || next == tokColon:
+			return p.parseIndex(identity{})
+		case next == tokStar && p.tokens[p.pos+1].kind == tokRbracket:
+			p.pos += 2
+			right, err := p.parseProjectionRHS(bindingPowers[tokStar])
+			return &projection{kind: arrayProjection, left: identity{}, right: right}, err
+		}
+		return p.parseMultiSelectList()
+	case tokLbrace:
+		return p.parseMultiSelectHash()
+	case tokLparen:
+		n, err := p.parseExpression(0)
+		if err != nil {
+			return nil, err
+		}
+		return n, p.expect(tokRparen, ")")
+	case tokNot:
+		operand, err := p.parseExpression(bindingPowers[tokNot])
+		return &not{operand: operand}, err
+	case tokExpref:
+		expr, err := p.parseExpression(bindingPowers[tokExpref])
+		return &expref{expr: expr}, err
+	}
+	return nil, p.unexpected(t, "an expression")
+}
+
+// led parses the expression t continues, with left on its left
+func (p *parser) led(t token, left node) (node, error) {
+	switch t.kind {
+	case tokDot:
+		if p.peek().kind == tokStar {
+			p.next()
+			right, err := p.parseProjectionRHS(bindingPowers[tokDot])
+			return &projection{kind: valueProjection, left: left, right: right}, err
+		}
+		right, err := p.parseDotRHS(bindingPowers[tokDot])
+		return &pipe{left: left, right: right}, err
+	case tokPipe:
+		right, err := p.parseExpression(bindingPowers[tokPipe])
+		return &pipe{left: left, right: right}, err
+	case tokOr:
+		right, err := p.parseExpression(bindingPowers[tokOr])
+		return &or{left: left, right: right}, err
+	case tokAnd:
+		right, err := p.parseExpression(bindingPowers[tokAnd])
+		return &and{left: left, right: right}, err
+	case tokEQ, tokNE, tokLT, tokLTE, tokGT, tokGTE:
+		right, err := p.parseExpression(bindingPowers[t.kind])
+		return &comparison{op: t.kind, left: left, right: right}, err
+	case tokFlatten:
+		right, err := p.parseProjectionRHS(bindingPowers[tokFlatten])
+		return &projection{kind: flattenProjection, left: left, right: right}, err
+	case tokFilter:
+		return p.parseFilter(left)
+	case tokLbracket:
+		if next := p.peek().kind; next == tokNumber || next == tokColon {
+			return p.parseIndex(left)
+		}
+		if err := p.expect(tokStar, "an index, a slice or *"); err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokRbracket, "]"); err != nil {
+			return nil, err
+		}
+		right, err := p.parseProjectionRHS(bindingPowers[tokStar])
+		return &projection{kind: arrayProjection, left: left, right: right}, err
+	case tokLparen:
+		return p.parseCall(t, left)
+	}
+	return nil, p.unexpected(t, "an operator")
+}
+
+// parseCall parses the arguments of a call to the function left names
+func (p *parser) parseCall(t token, left node) (node, error) {
+	name, ok := left.(*field)
+	if !ok || p.tokens[p.pos-2].kind != tokIdentifier {
+		return nil, syntaxError(t.pos, "only a function name may be called")
+	}
+
+	var args []node
+	for p.peek().kind != tokRparen {
+		if len(args) > 0 {
+			if err := p.expect(tokComma, ", or )"); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseExpression(0)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.next()
+
+	if err := check(name.name, args); err != nil {
+		return nil, err
+	}
+	return &call{name: name.name, args: args}, nil
+}
+
+// parseIndex parses an index, [0], or a slice, [0:2], following its [. A slice projects what follows it, as [*] does.
+func (p *parser) parseIndex(left node) (node, error) {
+	var parts [3]*int
+	part := 0
+	for {
+		switch t := p.next(); t.kind {
+		case tokNumber:
+			if parts[part] != nil {
+				return nil, p.unexpected(t, ": or ]")
+			}
+			i, err := strconv.Atoi(t.text)
+			if err != nil {
+				return nil, syntaxError(t.pos, "invalid index %v", t.text)
+			}
+			parts[part] = &i
+		case tokColon:
+			if part == 2 {
+				return nil, p.unexpected(t, "a number or ]")
+			}
+			part++
+		case tokRbracket:
+			if part == 0 {
+				if parts[0] == nil {
+					return nil, p.unexpected(t, "an index")
+				}
+				return &pipe{left: left, right: &index{index: *parts[0]}}, nil
+			}
+
+			s := &slice{start: parts[0], stop: parts[1], step: 1}
+			if parts[2] != nil {
+				if s.step = *parts[2]; s.step == 0 {
+					return nil, fmt.Errorf("jmespath: invalid value: a slice step can't be 0")
+				}
+			}
+			right, err := p.parseProjectionRHS(bindingPowers[tokStar])
+			return &projection{kind: arrayProjection, left: &pipe{left: left, right: s}, right: right}, err
+		default:
+			return nil, p.unexpected(t, "a number, : or ]")
+		}
+	}
+}
+
+// parseDotRHS parses what may follow a dot: an identifier, a function call, a multi-select list or a multi-select hash
+func (p *parser) parseDotRHS(bindingPower int) (node, error) {
+	switch t := p.peek(); t.kind {
+	case tokIdentifier, tokQuotedIdentifier, tokStar:
+		return p.parseExpression(bindingPower)
+	case tokLbracket:
+		p.next()
+		return p.parseMultiSelectList()
+	case tokLbrace:
+		p.next()
+		return p.parseMultiSelectHash()
+	default:
+		return nil, p.unexpected(t, "an identifier, [ or {")
+	}
+}
+
+// parseProjectionRHS parses the expression a projection applies to each element: everything up to the first token
+// binding less tightly than a projection, such as a pipe or a comparison
+func (p *parser) parseProjectionRHS(bindingPower int) (node, error) {
+	switch t := p.peek(); {
+	case bindingPowers[t.kind] < projectionStop:
+		return identity{}, nil
+	case t.kind == tokLbracket || t.kind == tokFilter:
+		return p.parseExpression(bindingPower)
+	case t.kind == tokDot:
+		p.next()
+		return p.parseDotRHS(bindingPower)
+	default:
+		return nil, p.unexpected(t, "., [ or the end of the projection")
+	}
+}
+
+// parseFilter parses a filter projection, [?condition], following its [?
+func (p *parser) parseFilter(left node) (node, error) {
+	condition, err := p.parseExpression(0)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(tokRbracket, "]"); err != nil {
+		return nil, err
+	}
+
+	var right node = identity{}
+	if p.peek().kind != tokFlatten {
+		if right, err = p.parseProjectionRHS(bindingPowers[tokFilter]); err != nil {
+			return nil, err
+		}
+	}
+	return &filter{left: left, right: right, condition: condition}, nil
+}
+
+// parseMultiSelectList parses [a, b], following its [
+func (p *parser) parseMultiSelectList() (node, error) {
+	var list multiSelectList
+	for {
+		n, err := p.parseExpression(0)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, n)
+
+		switch t := p.next(); t.kind {
+		case tokRbracket:
+			return list, nil
+		case tokComma:
+		default:
+			return nil, p.unexpected(t, ", or ]")
+		}
+	}
+}
+
+// parseMultiSelectHash parses {key: a, other: b}, following its {
+func (p *parser) parseMultiSelectHash() (node, error) {
+	hash := &multiSelectHash{}
+	for {
+		key := p.next()
+		if key.kind != tokIdentifier && key.kind != tokQuotedIdentifier {
+			return nil, p.unexpected(key, "a key")
+		}
+		if err := p.expect(tokColon, ":"); err != nil {
+			return nil, err
+		}
+		value, err := p.parseExpression(0)
+		if err != nil {
+			return nil, err
+		}
+		hash.keys = append(hash.keys, key.text)
+		hash.values = append(hash.values, value)
+
+		switch t := p.next(); t.kind {
+		case tokRbrace:
+			return hash, nil
+		case tokComma:
+		default:
+			return nil, p.unexpected(t, ", or }")
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package jmespath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func BenchmarkParse(t *testing.B) {
+	for i := 0; i < t.N; i++ {
+		_, err := translate(`reservations[*].instances[?state.name=='running'].{id: id, type: type} | sort_by(@, &id)`)
+		assert.NoError(t, err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	testCases := map[string]struct {
+		Expression string
+		Expected   string
+		HasError   bool
+	}{
+		"field":                {Expression: `a`, Expected: `jp_field("a")`},
+		"subexpression":        {Expression: `a.b`, Expected: `(jp_field("a")) | (jp_field("b"))`},
+		"projection":           {Expression: `a[*].b`, Expected: `(jp_field("a")) | jp_project(jp_field("b"))`},
+		"projection ends":      {Expression: `a[*].b | c`, Expected: `((jp_field("a")) | jp_project(jp_field("b"))) | (jp_field("c"))`},
+		"nested projections":   {Expression: `a[].b[*]`, Expected: `(jp_field("a")) | jp_flatten | jp_project((jp_field("b")) | jp_project(.))`},
+		"slice projects":       {Expression: `[1:].a`, Expected: `((.) | (jp_slice(1; null; 1))) | jp_project(jp_field("a"))`},
+		"index":                {Expression: `a[0]`, Expected: `(jp_field("a")) | (jp_index(0))`},
+		"filter":               {Expression: `[?a > ` + "`1`" + `]`, Expected: `(.) | jp_project_filter(jp_gt(jp_field("a"); 1); .)`},
+		"or binds loosest":     {Expression: `a || b && c`, Expected: `jp_or(jp_field("a"); jp_and(jp_field("b"); jp_field("c")))`},
+		"not binds tightly":    {Expression: `!a.b`, Expected: `((jp_field("a")) | jp_not) | (jp_field("b"))`},
+		"raw string":           {Expression: `'a"b'`, Expected: `"a\"b"`},
+		"literal":              {Expression: "` {\"a\": [1, 2]} `", Expected: `{"a":[1,2]}`},
+		"escaped backtick":     {Expression: "`\"\\`\"`", Expected: "\"`\""},
+		"variadic function":    {Expression: `not_null(a, b)`, Expected: `jp_not_null([(jp_field("a")), (jp_field("b"))])`},
+		"expref":               {Expression: `sort_by(@, &a)`, Expected: `jp_sort_by((.); (jp_field("a")))`},
+		"empty":                {Expression: ``, HasError: true},
+		"trailing dot":         {Expression: `a.`, HasError: true},
+		"single equals":        {Expression: `a = b`, HasError: true},
+		"invalid literal":      {Expression: "`{a}`", HasError: true},
+		"unterminated":         {Expression: `'a`, HasError: true},
+		"empty hash":           {Expression: `{}`, HasError: true},
+		"bracketed identifier": {Expression: `[a`, HasError: true},
+		"quoted function name": {Expression: `"abs"(@)`, HasError: true},
+		"call on parentheses":  {Expression: `(abs)(@)`, HasError: true},
+		"missing expref":       {Expression: `sort_by(@, a)`, HasError: true},
+		"variadic arity":       {Expression: `merge()`, HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			program, err := translate(tc.Expression)
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, program)
+		})
+	}
+}
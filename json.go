@@ -0,0 +1,125 @@
+package jq
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/bubunyo/go-jq/scanner"
+)
+
+// FromJSON decodes a JSON string value containing an embedded JSON document and returns the embedded document
+func FromJSON() OpFunc {
+	return func(in []byte) ([]byte, error) {
+		in = bytes.TrimSpace(in)
+
+		decoded, err := unquote(in)
+		if err != nil {
+			return nil, fmt.Errorf("fromjson expects a JSON string; %v", err)
+		}
+
+		end, err := scanner.Any(decoded, 0)
+		if err != nil {
+			return nil, fmt.Errorf("fromjson failed: %v (while parsing '%s')", err, decoded)
+		}
+		if pos := skipSpace(decoded, end); pos < len(decoded) {
+			return nil, fmt.Errorf("fromjson failed: unexpected content at position %v (while parsing '%s')", pos, decoded)
+		}
+
+		return decoded[skipSpace(decoded, 0):end], nil
+	}
+}
+
+// ToJSON serializes any JSON value into a JSON string containing its compact text
+func ToJSON() OpFunc {
+	return func(in []byte) ([]byte, error) {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, in); err != nil {
+			return nil, fmt.Errorf("tojson failed: %v", err)
+		}
+
+		return appendQuoted(make([]byte, 0, buf.Len()+2), buf.Bytes()), nil
+	}
+}
+
+// unquote returns the decoded content of the JSON string token provided
+func unquote(in []byte) ([]byte, error) {
+	end, err := scanner.String(in, 0)
+	if err != nil {
+		return nil, err
+	}
+	if end != len(in) {
+		return nil, fmt.Errorf("unexpected content at position %v", end)
+	}
+
+	content := in[1 : len(in)-1]
+	if bytes.IndexByte(content, '\\') < 0 {
+		return content, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(in, &s); err != nil {
+		return nil, err
+	}
+
+	return []byte(s), nil
+}
+
+// appendQuoted appends s to dst as a JSON string token, escaping as jq does
+func appendQuoted(dst, s []byte) []byte {
+	const hex = "0123456789abcdef"
+
+	dst = append(dst, '"')
+	for i := 0; i < len(s); {
+		b := s[i]
+		if b >= utf8.RuneSelf {
+			r, size := utf8.DecodeRune(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				dst = append(dst, "�"...)
+			} else {
+				dst = append(dst, s[i:i+size]...)
+			}
+			i += size
+			continue
+		}
+
+		switch b {
+		case '"', '\\':
+			dst = append(dst, '\\', b)
+		case '\n':
+			dst = append(dst, '\\', 'n')
+		case '\r':
+			dst = append(dst, '\\', 'r')
+		case '\t':
+			dst = append(dst, '\\', 't')
+		case '\b':
+			dst = append(dst, '\\', 'b')
+		case '\f':
+			dst = append(dst, '\\', 'f')
+		default:
+			if b < 0x20 || b == 0x7f {
+				dst = append(dst, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xf])
+			} else {
+				dst = append(dst, b)
+			}
+		}
+		i++
+	}
+
+	return append(dst, '"')
+}
+
+// skipSpace returns the position of the first non-whitespace byte at or after pos
+func skipSpace(in []byte, pos int) int {
+	for pos < len(in) {
+		switch in[pos] {
+		case ' ', '\t', '\n', '\r':
+			pos++
+		default:
+			return pos
+		}
+	}
+
+	return pos
+}
@@ -0,0 +1,109 @@
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromJSON(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+		HasError bool
+		ErrorMsg string
+	}{
+		"object": {
+			In:       `{"payload":"{\"a\":1}"}`,
+			Op:       ".payload|fromjson|.a",
+			Expected: `1`,
+		},
+		"nested escapes": {
+			In:       `{"payload":"{\"a\":\"line\\nbreak \\\"quoted\\\"\"}"}`,
+			Op:       ".payload|fromjson|.a",
+			Expected: `"line\nbreak \"quoted\""`,
+		},
+		"scalar": {
+			In:       `" 42 "`,
+			Op:       "fromjson",
+			Expected: `42`,
+		},
+		"not a string": {
+			In:       `{"a":1}`,
+			Op:       "fromjson",
+			HasError: true,
+		},
+		"malformed": {
+			In:       `"{\"a\":}"`,
+			Op:       "fromjson",
+			HasError: true,
+			ErrorMsg: "position 5",
+		},
+		"trailing content": {
+			In:       `"[1] 2"`,
+			Op:       "fromjson",
+			HasError: true,
+			ErrorMsg: "position 4",
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op)
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			if tc.HasError {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.ErrorMsg)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.Expected, string(data))
+			}
+		})
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Expected string
+		HasError bool
+	}{
+		"object": {
+			In:       `{ "a" : [1, 2] }`,
+			Expected: `"{\"a\":[1,2]}"`,
+		},
+		"string": {
+			In:       `"tab\there"`,
+			Expected: `"\"tab\\there\""`,
+		},
+		"number": {
+			In:       `1.5`,
+			Expected: `"1.5"`,
+		},
+		"invalid": {
+			In:       `{"a":`,
+			HasError: true,
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			data, err := jq.ToJSON().Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.Expected, string(data))
+
+				decoded, err := jq.FromJSON().Apply(data)
+				require.NoError(t, err)
+				assert.JSONEq(t, tc.In, string(decoded))
+			}
+		})
+	}
+}
@@ -0,0 +1,4 @@
+// Package jsonpath compiles RFC 9535 JSONPath queries into jq Ops. Queries are translated into jq programs and
+// evaluated by the go-jq evaluator, so they work against raw JSON, and may be applied to a jq.Document, as jq selectors
+// do.
+package jsonpath
@@ -0,0 +1,275 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// exprType is one of the types of the expressions of filters, which decide where an expression may appear
+type exprType int
+
+const (
+	// valueType expressions produce a JSON value or nothing
+	valueType exprType = iota
+	// logicalType expressions produce true or false
+	logicalType
+	// nodesType expressions produce a nodelist
+	nodesType
+)
+
+func (t exprType) String() string {
+	switch t {
+	case valueType:
+		return "ValueType"
+	case logicalType:
+		return "LogicalType"
+	default:
+		return "NodesType"
+	}
+}
+
+// logical is an expression of LogicalType; it translates to a filter producing true or false with @ as its input
+type logical interface {
+	jq() string
+}
+
+// valueExpr is an expression of ValueType; it translates to a filter producing one value, or nothing
+type valueExpr interface {
+	jq() string
+}
+
+// nodesExpr is an expression of NodesType; it translates to a filter producing each node of the nodelist
+type nodesExpr interface {
+	jq() string
+}
+
+type orExpr []logical
+
+func (x orExpr) jq() string {
+	return join(x, " or ")
+}
+
+type andExpr []logical
+
+func (x andExpr) jq() string {
+	return join(x, " and ")
+}
+
+func join(operands []logical, op string) string {
+	s := make([]string, len(operands))
+	for i, operand := range operands {
+		s[i] = operand.jq()
+	}
+	return "(" + strings.Join(s, op) + ")"
+}
+
+type notExpr struct {
+	expr logical
+}
+
+func (x *notExpr) jq() string {
+	return "(" + x.expr.jq() + " | not)"
+}
+
+// existsExpr holds when a query selects at least one node
+type existsExpr struct {
+	query nodesExpr
+}
+
+func (x *existsExpr) jq() string {
+	return "(isempty(" + x.query.jq() + ") | not)"
+}
+
+// literal is a JSON value given in a filter
+type literal []byte
+
+func (x literal) jq() string {
+	return "(" + string(x) + ")"
+}
+
+// comparison compares two values; an operand producing nothing is equal only to another producing nothing, and
+// orders against nothing. Only numbers and strings are ordered, and only against their own type.
+type comparison struct {
+	op          string
+	left, right valueExpr
+}
+
+// mirrored holds the operator that compares the operands of each the other way round
+var mirrored = map[string]string{"==": "==", "!=": "!=", "<": ">", ">": "<", "<=": ">=", ">=": "<="}
+
+func (x *comparison) jq() string {
+	op, left, right := x.op, x.left, x.right
+	if _, ok := left.(literal); ok {
+		op, left, right = mirrored[op], right, left
+	}
+	if lit, ok := right.(literal); ok {
+		return compareLiteral(op, left, lit)
+	}
+
+	a, b := left.jq(), right.jq()
+	eq := "([" + a + "] == [" + b + "])"
+	switch op {
+	case "==":
+		return eq
+	case "!=":
+		return "(" + eq + " | not)"
+	}
+	if op[0] == '>' {
+		a, b = b, a
+	}
+	lt := "first(((" + a + ") as $a | (" + b + ") as $b | ($a | type) as $t" +
+		` | ($b | type) == $t and ($t == "number" or $t == "string") and $a < $b), false)`
+	if len(op) == 1 {
+		return lt
+	}
+	return "(" + lt + " or " + eq + ")"
+}
+
+// compareLiteral compares a value with a literal, which produces a value and has a known type, so that jq's own
+// comparisons apply once the type of the other operand is checked
+func compareLiteral(op string, operand valueExpr, lit literal) string {
+	v := operand.jq()
+	if op == "==" || op == "!=" {
+		eq := "((" + v + " | . == " + lit.jq() + ") // false)"
+		if op == "!=" {
+			return "(" + eq + " | not)"
+		}
+		return eq
+	}
+
+	var t string
+	switch lit[0] {
+	case '"':
+		t = "string"
+	case 't', 'f', 'n':
+		// true, false and null aren't ordered, so only the equality of <= and >= may hold
+		if len(op) == 1 {
+			return "false"
+		}
+		return compareLiteral("==", operand, lit)
+	default:
+		t = "number"
+	}
+	return "((" + v + ` | type == "` + t + `" and . ` + op + " " + lit.jq() + ") // false)"
+}
+
+// function describes one of the function extensions that filters may call
+type function struct {
+	params []exprType
+	result exprType
+	build  func(args []any) any
+}
+
+// functions holds the function extensions of section 2.4 of RFC 9535
+var functions = map[string]function{
+	"length": {params: []exprType{valueType}, result: valueType, build: func(args []any) any {
+		return &lengthFunc{arg: args[0].(valueExpr)}
+	}},
+	"count": {params: []exprType{nodesType}, result: valueType, build: func(args []any) any {
+		return &countFunc{arg: args[0].(nodesExpr)}
+	}},
+	"value": {params: []exprType{nodesType}, result: valueType, build: func(args []any) any {
+		return &valueFunc{arg: args[0].(nodesExpr)}
+	}},
+	"match": {params: []exprType{valueType, valueType}, result: logicalType, build: func(args []any) any {
+		return newMatchFunc(args[0].(valueExpr), args[1].(valueExpr), true)
+	}},
+	"search": {params: []exprType{valueType, valueType}, result: logicalType, build: func(args []any) any {
+		return newMatchFunc(args[0].(valueExpr), args[1].(valueExpr), false)
+	}},
+}
+
+// lengthFunc produces the number of characters of a string, elements of an array or members of an object
+type lengthFunc struct {
+	arg valueExpr
+}
+
+func (f *lengthFunc) jq() string {
+	return "(" + f.arg.jq() + " | jp_length)"
+}
+
+// countFunc produces the number of nodes a query selects
+type countFunc struct {
+	arg nodesExpr
+}
+
+func (f *countFunc) jq() string {
+	return "([" + f.arg.jq() + "] | length)"
+}
+
+// valueFunc produces the value of the node a query selects, or nothing when it selects none or several
+type valueFunc struct {
+	arg nodesExpr
+}
+
+func (f *valueFunc) jq() string {
+	return "jp_value(" + f.arg.jq() + ")"
+}
+
+// matchFunc tests a string against an I-Regexp, as match does when anchored to the whole string and search does for
+// any substring; anything that isn't a string, or isn't a valid I-Regexp, doesn't match. Go regular expressions
+// aren't available to jq, so the test is made by the Ops Parse binds to jp_match and jp_search.
+type matchFunc struct {
+	arg, pattern valueExpr
+	anchored     bool
+}
+
+func newMatchFunc(arg, pattern valueExpr, anchored bool) *matchFunc {
+	return &matchFunc{arg: arg, pattern: pattern, anchored: anchored}
+}
+
+func (f *matchFunc) jq() string {
+	if lit, ok := f.pattern.(literal); ok {
+		// a literal that isn't a valid I-Regexp never matches
+		var pattern string
+		if json.Unmarshal(lit, &pattern) != nil {
+			return "false"
+		}
+		if _, err := compileIRegexp(pattern, f.anchored); err != nil {
+			return "false"
+		}
+	}
+	name := "jp_search"
+	if f.anchored {
+		name = "jp_match"
+	}
+	return "([[" + f.arg.jq() + "], [" + f.pattern.jq() + "]] | " + name + ")"
+}
+
+// matcher is the Op behind jp_match and jp_search, testing the string given as the only element of the first array of
+// its input against the pattern given likewise in the second; compiled patterns are kept for the next evaluation
+type matcher struct {
+	anchored bool
+	patterns sync.Map
+}
+
+func newMatcher(anchored bool) *matcher {
+	return &matcher{anchored: anchored}
+}
+
+func (m *matcher) Apply(in []byte) ([]byte, error) {
+	var args [2][]any
+	if err := json.Unmarshal(in, &args); err != nil {
+		return nil, err
+	}
+	if len(args[0]) != 1 || len(args[1]) != 1 {
+		return []byte("false"), nil
+	}
+	s, ok := args[0][0].(string)
+	pattern, isString := args[1][0].(string)
+	if !ok || !isString {
+		return []byte("false"), nil
+	}
+
+	re, ok := m.patterns.Load(pattern)
+	if !ok {
+		// an invalid I-Regexp is kept as a nil pattern, which matches nothing
+		compiled, _ := compileIRegexp(pattern, m.anchored)
+		re, _ = m.patterns.LoadOrStore(pattern, compiled)
+	}
+	matched := re.(*regexp.Regexp) != nil && re.(*regexp.Regexp).MatchString(s)
+	return []byte(strconv.FormatBool(matched)), nil
+}
@@ -0,0 +1,279 @@
+package jsonpath
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// compileIRegexp compiles an RFC 9485 I-Regexp, anchored to the whole string or not. The pattern is checked against
+// the I-Regexp grammar and translated into the RE2 syntax of the regexp package, where . excludes \r as well as \n
+// and characters RE2 treats as operators, such as ^ and $, are literal.
+func compileIRegexp(pattern string, anchored bool) (*regexp.Regexp, error) {
+	t := iregexp{in: pattern}
+	if err := t.branches(); err != nil {
+		return nil, err
+	}
+	if t.pos < len(t.in) {
+		return nil, t.errorf("unexpected %q", t.in[t.pos])
+	}
+
+	re := t.out.String()
+	if anchored {
+		re = `^(?:` + re + `)$`
+	}
+	return regexp.Compile(re)
+}
+
+// iregexp translates an I-Regexp into RE2 syntax as it checks it
+type iregexp struct {
+	in    string
+	pos   int
+	depth int
+	out   strings.Builder
+}
+
+func (t *iregexp) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid I-Regexp %q at position %v: %v", t.in, t.pos, fmt.Sprintf(format, args...))
+}
+
+func (t *iregexp) peek() rune {
+	if t.pos == len(t.in) {
+		return -1
+	}
+	r, _ := utf8.DecodeRuneInString(t.in[t.pos:])
+	return r
+}
+
+func (t *iregexp) next() rune {
+	r, size := utf8.DecodeRuneInString(t.in[t.pos:])
+	t.pos += size
+	return r
+}
+
+// branches translates branch *( "|" branch )
+func (t *iregexp) branches() error {
+	for {
+		if err := t.branch(); err != nil {
+			return err
+		}
+		if t.peek() != '|' {
+			return nil
+		}
+		t.next()
+		t.out.WriteByte('|')
+	}
+}
+
+// branch translates a sequence of atoms, each optionally quantified
+func (t *iregexp) branch() error {
+	for {
+		switch r := t.peek(); r {
+		case -1, '|':
+			return nil
+		case ')':
+			if t.depth == 0 {
+				return t.errorf("unbalanced )")
+			}
+			return nil
+		case '*', '+', '?', '{':
+			return t.errorf("%q quantifies nothing", r)
+		}
+
+		if err := t.atom(); err != nil {
+			return err
+		}
+		if err := t.quantifier(); err != nil {
+			return err
+		}
+	}
+}
+
+func (t *iregexp) atom() error {
+	switch r := t.next(); r {
+	case '(':
+		t.depth++
+		t.out.WriteString("(?:")
+		if err := t.branches(); err != nil {
+			return err
+		}
+		if t.peek() != ')' {
+			return t.errorf("missing )")
+		}
+		t.next()
+		t.depth--
+		t.out.WriteByte(')')
+	case '.':
+		t.out.WriteString(`[^\n\r]`)
+	case '[':
+		return t.class()
+	case '\\':
+		if c, ok, err := t.escape(false); err != nil {
+			return err
+		} else if ok {
+			t.out.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	case ']', '}':
+		return t.errorf("unescaped %q", r)
+	default:
+		t.out.WriteString(regexp.QuoteMeta(string(r)))
+	}
+	return nil
+}
+
+// escape translates the escape following a backslash. A single character escape is returned for the caller to write,
+// as it is written differently within character classes; category escapes are written directly.
+func (t *iregexp) escape(inClass bool) (rune, bool, error) {
+	switch r := t.next(); r {
+	case 'n':
+		return '\n', true, nil
+	case 'r':
+		return '\r', true, nil
+	case 't':
+		return '\t', true, nil
+	case '(', ')', '*', '+', '-', '.', '?', '[', '\\', ']', '^', '{', '|', '}':
+		return r, true, nil
+	case 'p', 'P':
+		end := strings.IndexByte(t.in[t.pos:], '}')
+		if t.peek() != '{' || end < 0 {
+			return 0, false, t.errorf("invalid category escape")
+		}
+		name := t.in[t.pos+1 : t.pos+end]
+		if !isCategory(name) {
+			return 0, false, t.errorf("unknown category %q", name)
+		}
+		t.pos += end + 1
+
+		// RE2 has no table of unassigned characters, so Cn is written as the complement of every other category
+		switch {
+		case name != "Cn":
+			fmt.Fprintf(&t.out, `\%c{%v}`, r, name)
+		case r == 'P' && inClass:
+			t.out.WriteString(assigned)
+		case r == 'P':
+			t.out.WriteString("[" + assigned + "]")
+		case inClass:
+			return 0, false, t.errorf(`\p{Cn} is not supported within a character class`)
+		default:
+			t.out.WriteString("[^" + assigned + "]")
+		}
+		return 0, false, nil
+	default:
+		return 0, false, t.errorf("invalid escape \\%c", r)
+	}
+}
+
+// assigned lists the categories of every assigned character and surrogate, the complement of Cn
+const assigned = `\p{L}\p{M}\p{N}\p{P}\p{Z}\p{S}\p{Cc}\p{Cf}\p{Co}\p{Cs}`
+
+// isCategory reports whether name is one of the Unicode general categories I-Regexp supports
+func isCategory(name string) bool {
+	switch {
+	case len(name) == 0 || len(name) > 2:
+		return false
+	case len(name) == 1:
+		return strings.ContainsRune("LMNPZSC", rune(name[0]))
+	}
+
+	subcategories := map[byte]string{'L': "lmotu", 'M': "cen", 'N': "dlo", 'P': "cdefios", 'Z': "lps", 'S': "ckmo", 'C': "cfno"}
+	s, ok := subcategories[name[0]]
+	return ok && strings.IndexByte(s, name[1]) >= 0
+}
+
+// class translates a character class expression, writing each character as a hexadecimal escape
+func (t *iregexp) class() error {
+	t.out.WriteByte('[')
+	if t.peek() == '^' {
+		t.next()
+		t.out.WriteByte('^')
+	}
+
+	for first := true; ; first = false {
+		r := t.peek()
+		switch {
+		case r == -1:
+			return t.errorf("missing ]")
+		case r == ']' && !first:
+			t.next()
+			t.out.WriteByte(']')
+			return nil
+		case r == '-' && (first || strings.HasPrefix(t.in[t.pos:], "-]")):
+			t.next()
+			t.out.WriteString(`\-`)
+			continue
+		}
+
+		lo, ok, err := t.classChar()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			// a category escape, which can't begin a range
+			continue
+		}
+		fmt.Fprintf(&t.out, `\x{%x}`, lo)
+
+		if t.peek() != '-' || strings.HasPrefix(t.in[t.pos:], "-]") {
+			continue
+		}
+		t.next()
+		hi, ok, err := t.classChar()
+		if err != nil {
+			return err
+		}
+		if !ok || hi < lo {
+			return t.errorf("invalid range")
+		}
+		fmt.Fprintf(&t.out, `-\x{%x}`, hi)
+	}
+}
+
+// classChar reads a character within a class, reporting false for a category escape, which it writes
+func (t *iregexp) classChar() (rune, bool, error) {
+	switch r := t.next(); r {
+	case '\\':
+		return t.escape(true)
+	case '-', '[', ']':
+		return 0, false, t.errorf("unescaped %q in character class", r)
+	default:
+		return r, true, nil
+	}
+}
+
+// quantifier translates an optional *, +, ? or {n}, {n,} or {n,m}
+func (t *iregexp) quantifier() error {
+	switch t.peek() {
+	case '*', '+', '?':
+		t.out.WriteRune(t.next())
+	case '{':
+		end := strings.IndexByte(t.in[t.pos:], '}')
+		if end < 0 {
+			return t.errorf("missing }")
+		}
+		q := t.in[t.pos+1 : t.pos+end]
+		lo, hi, ranged := strings.Cut(q, ",")
+		if !isDigits(lo) || ranged && hi != "" && !isDigits(hi) {
+			return t.errorf("invalid quantifier {%v}", q)
+		}
+		t.out.WriteString(t.in[t.pos : t.pos+end+1])
+		t.pos += end + 1
+	default:
+		return nil
+	}
+
+	switch t.peek() {
+	case '*', '+', '?', '{':
+		return t.errorf("repeated quantifier")
+	}
+	return nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,75 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIRegexp(t *testing.T) {
+	testCases := map[string]struct {
+		Pattern  string
+		Matches  []string
+		Rejects  []string
+		HasError bool
+	}{
+		"literal":            {Pattern: `abc`, Matches: []string{"abc"}, Rejects: []string{"abcd", "xabc"}},
+		"dot":                {Pattern: `a.c`, Matches: []string{"abc", "a☺c"}, Rejects: []string{"a\nc", "a\rc"}},
+		"anchors literal":    {Pattern: `^a$`, Matches: []string{"^a$"}, Rejects: []string{"a"}},
+		"branches":           {Pattern: `ab|cd`, Matches: []string{"ab", "cd"}, Rejects: []string{"abcd"}},
+		"group":              {Pattern: `(ab)+`, Matches: []string{"abab"}, Rejects: []string{"aba"}},
+		"empty group":        {Pattern: `a()b`, Matches: []string{"ab"}},
+		"quantifiers":        {Pattern: `a*b+c?`, Matches: []string{"b", "aabbc"}, Rejects: []string{"ac"}},
+		"range quantifier":   {Pattern: `a{2,3}`, Matches: []string{"aa", "aaa"}, Rejects: []string{"a", "aaaa"}},
+		"open quantifier":    {Pattern: `a{2,}`, Matches: []string{"aaaaa"}, Rejects: []string{"a"}},
+		"exact quantifier":   {Pattern: `a{2}`, Matches: []string{"aa"}, Rejects: []string{"aaa"}},
+		"class":              {Pattern: `[a-c]+`, Matches: []string{"abc"}, Rejects: []string{"d"}},
+		"negated class":      {Pattern: `[^a-c]`, Matches: []string{"d", "\n"}, Rejects: []string{"a"}},
+		"class hyphens":      {Pattern: `[-a]+[a-]`, Matches: []string{"-a-"}},
+		"class caret":        {Pattern: `[a^]+`, Matches: []string{"^a"}},
+		"class escapes":      {Pattern: `[\n\]\-\\]+`, Matches: []string{"\n]-\\"}},
+		"class category":     {Pattern: `[\p{Nd}x]+`, Matches: []string{"1x٣"}, Rejects: []string{"a"}},
+		"escapes":            {Pattern: `\.\*\t\{\}\|\(\)`, Matches: []string{".*\t{}|()"}},
+		"category":           {Pattern: `\p{L}\P{L}`, Matches: []string{"é1"}, Rejects: []string{"ab"}},
+		"unassigned":         {Pattern: `\p{Cn}`, Matches: []string{"\U000E0080"}, Rejects: []string{"a"}},
+		"assigned":           {Pattern: `\P{Cn}`, Matches: []string{"a"}, Rejects: []string{"\U000E0080"}},
+		"digit shorthand":    {Pattern: `\d`, HasError: true},
+		"word boundary":      {Pattern: `\b`, HasError: true},
+		"backreference":      {Pattern: `(a)\1`, HasError: true},
+		"lazy quantifier":    {Pattern: `a*?`, HasError: true},
+		"possessive":         {Pattern: `a++`, HasError: true},
+		"non-capturing":      {Pattern: `(?:a)`, HasError: true},
+		"leading quantifier": {Pattern: `*a`, HasError: true},
+		"unbalanced open":    {Pattern: `(a`, HasError: true},
+		"unbalanced close":   {Pattern: `a)`, HasError: true},
+		"unescaped bracket":  {Pattern: `a]`, HasError: true},
+		"unescaped brace":    {Pattern: `a}`, HasError: true},
+		"empty class":        {Pattern: `[]`, HasError: true},
+		"unclosed class":     {Pattern: `[a`, HasError: true},
+		"nested class":       {Pattern: `[[a]]`, HasError: true},
+		"reversed range":     {Pattern: `[c-a]`, HasError: true},
+		"bad quantifier":     {Pattern: `a{x}`, HasError: true},
+		"block escape":       {Pattern: `\p{IsBasicLatin}`, HasError: true},
+		"unknown category":   {Pattern: `\p{Lx}`, HasError: true},
+		"trailing escape":    {Pattern: `a\`, HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			re, err := compileIRegexp(tc.Pattern, true)
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			for _, s := range tc.Matches {
+				assert.True(t, re.MatchString(s), "%q should match %q", tc.Pattern, s)
+			}
+			for _, s := range tc.Rejects {
+				assert.False(t, re.MatchString(s), "%q should not match %q", tc.Pattern, s)
+			}
+		})
+	}
+}
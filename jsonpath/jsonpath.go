@@ -0,0 +1,163 @@
+package jsonpath
+
+import (
+	_ "embed"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/bubunyo/go-jq"
+)
+
+// runtime holds the jq definitions compiled queries are evaluated with, jp_ prefixed helpers for the selectors and the
+// constructs of filters that behave differently in jq, such as comparisons; name and index selectors are written out
+// in full instead, as calling a definition with arguments costs more than the lookup itself
+//
+//go:embed functions.jq
+var runtime string
+
+// Parse compiles a JSONPath query such as $.store.book[?@.price < 10].title. Applying the Op produces the nodelist the
+// query selects as a JSON array, empty when the query selects nothing. The Op is the *jq.Query the query translates
+// to, so it may be applied to a jq.Document.
+func Parse(query string) (jq.Op, error) {
+	program, err := translate(query)
+	if err != nil {
+		return nil, err
+	}
+	return jq.Parse(runtime+program,
+		jq.WithFunction("jp_match", newMatcher(true)),
+		jq.WithFunction("jp_search", newMatcher(false)))
+}
+
+// translate returns the jq program a JSONPath query compiles to, which relies on the definitions Parse prepends to it;
+// $ is bound to $jp_root for the queries of filters
+func translate(query string) (string, error) {
+	p := parser{in: query}
+	q, err := p.parseQuery()
+	if err != nil {
+		return "", err
+	}
+	return ". as $jp_root | [" + q.jq() + "]", nil
+}
+
+// quote writes a string as a jq string literal; JSON strings are valid jq strings, interpolation aside, which JSON
+// can't express
+func quote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// query is a sequence of segments applied to the root node, $, or within a filter to the current node, @
+type query struct {
+	relative bool
+	segments []*segment
+}
+
+// jq produces the nodes the query selects
+func (q *query) jq() string {
+	steps := []string{"$jp_root"}
+	if q.relative {
+		steps[0] = "."
+	}
+	for _, s := range q.segments {
+		steps = append(steps, s.jq())
+	}
+	return "(" + strings.Join(steps, " | ") + ")"
+}
+
+// singular reports whether the query selects at most one node: one made only of segments holding a single name or
+// index selector
+func (q *query) singular() bool {
+	for _, s := range q.segments {
+		if s.descendant || len(s.selectors) != 1 {
+			return false
+		}
+		switch s.selectors[0].(type) {
+		case *nameSelector, *indexSelector:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// segment applies its selectors to its input, or with descendant, as .. does, to its input and every value within it
+type segment struct {
+	selectors  []selector
+	descendant bool
+}
+
+func (s *segment) jq() string {
+	selectors := make([]string, len(s.selectors))
+	for i, sel := range s.selectors {
+		selectors[i] = sel.jq()
+	}
+	union := strings.Join(selectors, ", ")
+	if s.descendant {
+		return "(.. | " + union + ")"
+	}
+	return "(" + union + ")"
+}
+
+// selector selects nodes among the children of a node; it translates to a filter producing them from the node
+type selector interface {
+	jq() string
+}
+
+// nameSelector selects the value of the member of an object with the name given
+type nameSelector struct {
+	name string
+}
+
+func (s *nameSelector) jq() string {
+	name := quote(s.name)
+	return "(if type == \"object\" and has(" + name + ") then .[" + name + "] else empty end)"
+}
+
+// wildcardSelector selects every child of a node
+type wildcardSelector struct{}
+
+func (wildcardSelector) jq() string {
+	return "jp_children"
+}
+
+// indexSelector selects an element of an array; negative indices count back from the end of the array
+type indexSelector struct {
+	index int
+}
+
+func (s *indexSelector) jq() string {
+	if s.index < 0 {
+		n := strconv.Itoa(-s.index)
+		return "(if type == \"array\" and length >= " + n + " then .[length - " + n + "] else empty end)"
+	}
+	i := strconv.Itoa(s.index)
+	return "(if type == \"array\" and length > " + i + " then .[" + i + "] else empty end)"
+}
+
+// sliceSelector selects the elements of an array from start up to but excluding end, every step elements; a negative
+// step selects them in reverse
+type sliceSelector struct {
+	start, end       int
+	hasStart, hasEnd bool
+	step             int
+}
+
+func (s *sliceSelector) jq() string {
+	bound := func(i int, ok bool) string {
+		if !ok {
+			return "null"
+		}
+		return strconv.Itoa(i)
+	}
+	return "jp_slice(" + bound(s.start, s.hasStart) + "; " + bound(s.end, s.hasEnd) + "; " + strconv.Itoa(s.step) + ")"
+}
+
+// filterSelector selects the children of a node for which a logical expression holds, with @ bound to each child
+type filterSelector struct {
+	expr logical
+}
+
+func (s *filterSelector) jq() string {
+	return "(jp_children | select(" + s.expr.jq() + "))"
+}
@@ -0,0 +1,170 @@
+package jsonpath_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/bubunyo/go-jq/jsonpath"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// store is the example document of section 1.5 of RFC 9535
+const store = `{"store":{"book":[` +
+	`{"category":"reference","author":"Nigel Rees","title":"Sayings of the Century","price":8.95},` +
+	`{"category":"fiction","author":"Evelyn Waugh","title":"Sword of Honour","price":12.99},` +
+	`{"category":"fiction","author":"Herman Melville","title":"Moby Dick","isbn":"0-553-21311-3","price":8.99},` +
+	`{"category":"fiction","author":"J. R. R. Tolkien","title":"The Lord of the Rings","isbn":"0-395-19395-8","price":22.99}` +
+	`],"bicycle":{"color":"red","price":399}}}`
+
+func BenchmarkJSONPath(t *testing.B) {
+	op, err := jsonpath.Parse(`$.store.book[?@.price < 10].title`)
+	require.NoError(t, err)
+	data := []byte(store)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Query    string
+		Expected string
+		HasError bool
+	}{
+		// the examples of section 1.5
+		"authors":           {In: store, Query: `$.store.book[*].author`, Expected: `["Nigel Rees","Evelyn Waugh","Herman Melville","J. R. R. Tolkien"]`},
+		"all authors":       {In: store, Query: `$..author`, Expected: `["Nigel Rees","Evelyn Waugh","Herman Melville","J. R. R. Tolkien"]`},
+		"store contents":    {In: store, Query: `$.store.*`, Expected: `[[{"category":"reference","author":"Nigel Rees","title":"Sayings of the Century","price":8.95},{"category":"fiction","author":"Evelyn Waugh","title":"Sword of Honour","price":12.99},{"category":"fiction","author":"Herman Melville","title":"Moby Dick","isbn":"0-553-21311-3","price":8.99},{"category":"fiction","author":"J. R. R. Tolkien","title":"The Lord of the Rings","isbn":"0-395-19395-8","price":22.99}],{"color":"red","price":399}]`},
+		"prices":            {In: store, Query: `$.store..price`, Expected: `[8.95,12.99,8.99,22.99,399]`},
+		"third book":        {In: store, Query: `$..book[2].title`, Expected: `["Moby Dick"]`},
+		"third book author": {In: store, Query: `$..book[2].author`, Expected: `["Herman Melville"]`},
+		"no publisher":      {In: store, Query: `$..book[2].publisher`, Expected: `[]`},
+		"last book":         {In: store, Query: `$..book[-1].title`, Expected: `["The Lord of the Rings"]`},
+		"first two":         {In: store, Query: `$..book[0,1].title`, Expected: `["Sayings of the Century","Sword of Honour"]`},
+		"first two slice":   {In: store, Query: `$..book[:2].title`, Expected: `["Sayings of the Century","Sword of Honour"]`},
+		"with isbn":         {In: store, Query: `$..book[?@.isbn].title`, Expected: `["Moby Dick","The Lord of the Rings"]`},
+		"cheap":             {In: store, Query: `$..book[?@.price<10].title`, Expected: `["Sayings of the Century","Moby Dick"]`},
+		"cheap legacy":      {In: store, Query: `$.store.book[?(@.price < 10)].title`, Expected: `["Sayings of the Century","Moby Dick"]`},
+
+		// section 2.2 and 2.3: the root and selectors
+		"root":             {In: ` {"k": "v"} `, Query: `$`, Expected: `[{"k": "v"}]`},
+		"name":             {In: `{"o":{"j j":{"k.k":3}},"'":{"@":2}}`, Query: `$.o['j j']`, Expected: `[{"k.k":3}]`},
+		"name nested":      {In: `{"o":{"j j":{"k.k":3}},"'":{"@":2}}`, Query: `$.o['j j']['k.k']`, Expected: `[3]`},
+		"name double":      {In: `{"o":{"j j":{"k.k":3}},"'":{"@":2}}`, Query: `$.o["j j"]["k.k"]`, Expected: `[3]`},
+		"name escaped":     {In: `{"o":{"j j":{"k.k":3}},"'":{"@":2}}`, Query: `$["'"]["@"]`, Expected: `[2]`},
+		"name unicode":     {In: `{"☺":1}`, Query: `$["☺"]`, Expected: `[1]`},
+		"name surrogates":  {In: `{"𝄞":1}`, Query: `$["𝄞"]`, Expected: `[1]`},
+		"name key escaped": {In: `{"\u263a":1}`, Query: `$.☺`, Expected: `[1]`},
+		"name escape":      {In: `{"☺":1,"a\"b":2}`, Query: `$['\u263A','a"b']`, Expected: `[1,2]`},
+		"name shorthand":   {In: `{"_ü1":1}`, Query: `$._ü1`, Expected: `[1]`},
+		"name not object":  {In: `[1]`, Query: `$.a`, Expected: `[]`},
+		"wildcard object":  {In: `{"o":{"j":1,"k":2},"a":[5,3]}`, Query: `$[*]`, Expected: `[{"j":1,"k":2},[5,3]]`},
+		"wildcard":         {In: `{"o":{"j":1,"k":2},"a":[5,3]}`, Query: `$.o[*]`, Expected: `[1,2]`},
+		"wildcard twice":   {In: `{"o":{"j":1,"k":2},"a":[5,3]}`, Query: `$.o[*, *]`, Expected: `[1,2,1,2]`},
+		"wildcard array":   {In: `{"o":{"j":1,"k":2},"a":[5,3]}`, Query: `$.a[*]`, Expected: `[5,3]`},
+		"wildcard scalar":  {In: `3`, Query: `$.*`, Expected: `[]`},
+		"index":            {In: `["a","b"]`, Query: `$[1]`, Expected: `["b"]`},
+		"index negative":   {In: `["a","b"]`, Query: `$[-2]`, Expected: `["a"]`},
+		"index beyond":     {In: `["a","b"]`, Query: `$[2]`, Expected: `[]`},
+		"index before":     {In: `["a","b"]`, Query: `$[-3]`, Expected: `[]`},
+		"index object":     {In: `{"0":1}`, Query: `$[0]`, Expected: `[]`},
+		"slice":            {In: `["a","b","c","d","e","f","g"]`, Query: `$[1:3]`, Expected: `["b","c"]`},
+		"slice open":       {In: `["a","b","c","d","e","f","g"]`, Query: `$[5:]`, Expected: `["f","g"]`},
+		"slice step":       {In: `["a","b","c","d","e","f","g"]`, Query: `$[1:5:2]`, Expected: `["b","d"]`},
+		"slice reverse":    {In: `["a","b","c","d","e","f","g"]`, Query: `$[5:1:-2]`, Expected: `["f","d"]`},
+		"slice reversed":   {In: `["a","b","c","d","e","f","g"]`, Query: `$[::-1]`, Expected: `["g","f","e","d","c","b","a"]`},
+		"slice clamped":    {In: `["a","b","c"]`, Query: `$[-10:10]`, Expected: `["a","b","c"]`},
+		"slice negative":   {In: `["a","b","c"]`, Query: `$[-2:]`, Expected: `["b","c"]`},
+		"slice step zero":  {In: `["a","b","c"]`, Query: `$[::0]`, Expected: `[]`},
+		"slice spaces":     {In: `["a","b","c"]`, Query: `$[ 0 : 2 : 1 ]`, Expected: `["a","b"]`},
+		"slice object":     {In: `{"a":1}`, Query: `$[:]`, Expected: `[]`},
+
+		// section 2.3.5: filters
+		"filter equal":        {In: `{"a":[3,5,1,2,4,6,{"b":"j"},{"b":"k"},{"b":{}},{"b":"kilo"}]}`, Query: `$.a[?@.b == 'kilo']`, Expected: `[{"b":"kilo"}]`},
+		"filter paren":        {In: `{"a":[3,5,1,2,4,6,{"b":"j"},{"b":"k"},{"b":{}},{"b":"kilo"}]}`, Query: `$.a[?(@.b == 'kilo')]`, Expected: `[{"b":"kilo"}]`},
+		"filter greater":      {In: `{"a":[3,5,1,2,4,6,{"b":"j"},{"b":"k"},{"b":{}},{"b":"kilo"}]}`, Query: `$.a[?@>3.5]`, Expected: `[5,4,6]`},
+		"filter exists":       {In: `{"a":[3,5,1,2,4,6,{"b":"j"},{"b":"k"},{"b":{}},{"b":"kilo"}]}`, Query: `$.a[?@.b]`, Expected: `[{"b":"j"},{"b":"k"},{"b":{}},{"b":"kilo"}]`},
+		"filter nested":       {In: `{"a":[3,5,1,2,4,6,{"b":"j"},{"b":"k"},{"b":{}},{"b":"kilo"}],"o":{"p":1,"q":2,"r":3,"s":5,"t":{"u":6}},"e":"f"}`, Query: `$[?@.*]`, Expected: `[[3,5,1,2,4,6,{"b":"j"},{"b":"k"},{"b":{}},{"b":"kilo"}],{"p":1,"q":2,"r":3,"s":5,"t":{"u":6}}]`},
+		"filter filter":       {In: `{"a":[3,5,1,2,4,6,{"b":"j"},{"b":"k"},{"b":{}},{"b":"kilo"}],"o":{"p":1,"q":2,"r":3,"s":5,"t":{"u":6}},"e":"f"}`, Query: `$[?@[?@.b]]`, Expected: `[[3,5,1,2,4,6,{"b":"j"},{"b":"k"},{"b":{}},{"b":"kilo"}]]`},
+		"filter or":           {In: `{"o":{"p":1,"q":2,"r":3,"s":5,"t":{"u":6}}}`, Query: `$.o[?@<3, ?@<3]`, Expected: `[1,2,1,2]`},
+		"filter logical":      {In: `{"a":[3,5,1,2,4,6,{"b":"j"},{"b":"k"},{"b":{}},{"b":"kilo"}]}`, Query: `$.a[?@<2 || @.b == "k"]`, Expected: `[1,{"b":"k"}]`},
+		"filter not":          {In: `{"a":[3,5,1,2,4,6,{"b":"j"},{"b":"k"},{"b":{}},{"b":"kilo"}]}`, Query: `$.a[?!(@.b || @ >= 2)]`, Expected: `[1]`},
+		"filter not exists":   {In: `{"a":[1,{"b":1}]}`, Query: `$.a[?!@.b]`, Expected: `[1]`},
+		"filter and":          {In: `{"o":{"p":1,"q":2,"r":3,"s":5,"t":{"u":6}}}`, Query: `$.o[?@>1 && @<4]`, Expected: `[2,3]`},
+		"filter precedence":   {In: `[1,2,3]`, Query: `$[?@==1 || @==2 && @==3]`, Expected: `[1]`},
+		"filter root":         {In: `{"a":[3,5,1,2,4,6,{"b":"j"},{"b":"k"},{"b":{}},{"b":"kilo"}],"e":"f"}`, Query: `$.a[?@.b == $.x]`, Expected: `[3,5,1,2,4,6]`},
+		"filter object":       {In: `{"o":{"p":1,"q":2,"r":3,"s":5,"t":{"u":6}}}`, Query: `$.o[?@.u || @.x]`, Expected: `[{"u":6}]`},
+		"filter array equal":  {In: `[{"a":[1,{"b":2}]},{"a":[1,{"b":3}]}]`, Query: `$[?@.a == $[0].a]`, Expected: `[{"a":[1,{"b":2}]}]`},
+		"filter number equal": {In: `[1,1.0,1e0,10e-1,2]`, Query: `$[?@ == 1]`, Expected: `[1,1.0,1e0,10e-1]`},
+		"filter string order": {In: `["a","b","ab",1]`, Query: `$[?@ < "b"]`, Expected: `["a","ab"]`},
+		"filter type order":   {In: `[1,"1",true,null]`, Query: `$[?@ <= 1]`, Expected: `[1]`},
+		"filter nothing":      {In: `[{"a":1},{}]`, Query: `$[?@.a == @.b]`, Expected: `[{}]`},
+		"filter nothing less": {In: `[{},{"a":1}]`, Query: `$[?@.a <= @.b]`, Expected: `[{}]`},
+		"filter literals":     {In: `[1,true,null,false]`, Query: `$[?@ == true || @ == null]`, Expected: `[true,null]`},
+		"filter literal left": {In: `[1,2]`, Query: `$[?1 < @]`, Expected: `[2]`},
+		"filter scalar":       {In: `{"a":1}`, Query: `$.a[?@]`, Expected: `[]`},
+
+		// section 2.4: function extensions
+		"length":         {In: `["ab","☺",[1,2],{"a":1},3]`, Query: `$[?length(@) == 2]`, Expected: `["ab",[1,2]]`},
+		"length unicode": {In: `["ab","☺"]`, Query: `$[?length(@) == 1]`, Expected: `["☺"]`},
+		"length nothing": {In: `[3,true]`, Query: `$[?length(@) == length(@.x)]`, Expected: `[3,true]`},
+		"count":          {In: `[{"a":1,"b":2},{"a":1}]`, Query: `$[?count(@.*) == 1]`, Expected: `[{"a":1}]`},
+		"count nested":   {In: `[[1,[2]],[3]]`, Query: `$[?count(@..*) > 2]`, Expected: `[[1,[2]]]`},
+		"value":          {In: `[{"a":[5]},{"a":[5,6]}]`, Query: `$[?value(@.a[*]) == 5]`, Expected: `[{"a":[5]}]`},
+		"value descend":  {In: `[{"x":{"b":"x"}},{"x":{"b":"y","c":{"b":"x"}}}]`, Query: `$[?value(@..b) == "x"]`, Expected: `[{"x":{"b":"x"}}]`},
+		"match":          {In: `[{"d":"1974-05-01"},{"d":"1974-05-011"}]`, Query: `$[?match(@.d, '1974-05-..')]`, Expected: `[{"d":"1974-05-01"}]`},
+		"search":         {In: `["Bob","Robert","Alice"]`, Query: `$[?search(@, '[BR]o')]`, Expected: `["Bob","Robert"]`},
+		"match dot":      {In: `["a\nb","a\rb","a b"]`, Query: `$[?match(@, 'a.b')]`, Expected: `["a b"]`},
+		"match literal":  {In: `["^a$","a"]`, Query: `$[?match(@, '^a$')]`, Expected: `["^a$"]`},
+		"match invalid":  {In: `["a"]`, Query: `$[?match(@, 'a(')]`, Expected: `[]`},
+		"match query":    {In: `{"p":"a.c","v":["abc","a.c"]}`, Query: `$.v[?match(@, $.p)]`, Expected: `["abc","a.c"]`},
+		"match category": {In: `["Ab","ab"]`, Query: `$[?match(@, '\\p{Lu}\\p{Ll}')]`, Expected: `["Ab"]`},
+		"search invalid": {In: `["a","3"]`, Query: `$[?!search(@, '\\d')]`, Expected: `["a","3"]`},
+		"match number":   {In: `[1]`, Query: `$[?match(@, '1')]`, Expected: `[]`},
+
+		// section 2.5: segments
+		"child union":         {In: `["a","b","c","d","e","f","g"]`, Query: `$[0, 3]`, Expected: `["a","d"]`},
+		"child slice index":   {In: `["a","b","c","d","e","f","g"]`, Query: `$[0:2, 5]`, Expected: `["a","b","f"]`},
+		"child repeated":      {In: `["a","b","c","d","e","f","g"]`, Query: `$[0, 0]`, Expected: `["a","a"]`},
+		"descendant":          {In: `{"o":{"j":1,"k":2},"a":[5,3,[{"j":4},{"k":6}]]}`, Query: `$..j`, Expected: `[1,4]`},
+		"descendant index":    {In: `{"o":{"j":1,"k":2},"a":[5,3,[{"j":4},{"k":6}]]}`, Query: `$..[0]`, Expected: `[5,{"j":4}]`},
+		"descendant all":      {In: `{"o":{"j":1,"k":2},"a":[5,3,[{"j":4},{"k":6}]]}`, Query: `$..*`, Expected: `[{"j":1,"k":2},[5,3,[{"j":4},{"k":6}]],1,2,5,3,[{"j":4},{"k":6}],{"j":4},{"k":6},4,6]`},
+		"descendant wildcard": {In: `{"o":{"j":1,"k":2},"a":[5,3,[{"j":4},{"k":6}]]}`, Query: `$..[*]`, Expected: `[{"j":1,"k":2},[5,3,[{"j":4},{"k":6}]],1,2,5,3,[{"j":4},{"k":6}],{"j":4},{"k":6},4,6]`},
+		"descendant union":    {In: `{"o":{"j":1,"k":2},"a":[5,3,[{"j":4},{"k":6}]]}`, Query: `$.o..[*, *]`, Expected: `[1,2,1,2]`},
+		"descendant nested":   {In: `{"o":{"j":1,"k":2},"a":[5,3,[{"j":4},{"k":6}]]}`, Query: `$.a..[0, 1]`, Expected: `[5,3,{"j":4},{"k":6}]`},
+		"null member":         {In: `{"a":null,"b":[null],"c":[{}],"null":1}`, Query: `$.a`, Expected: `[null]`},
+		"null element":        {In: `{"a":null,"b":[null],"c":[{}],"null":1}`, Query: `$.b[0]`, Expected: `[null]`},
+		"null key":            {In: `{"a":null,"b":[null],"c":[{}],"null":1}`, Query: `$.null`, Expected: `[1]`},
+		"null not indexable":  {In: `{"a":null,"b":[null],"c":[{}],"null":1}`, Query: `$.a.d`, Expected: `[]`},
+		"whitespace":          {In: `{"a":{"b":[1]}}`, Query: `$ .a ["b"] [ 0 ]`, Expected: `[1]`},
+
+		"invalid document": {In: `{"a": [1,`, Query: `$.a[1]`, HasError: true},
+		"empty document":   {In: ``, Query: `$`, HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jsonpath.Parse(tc.Query)
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+
+			// queries compile to jq queries, which take their values from the index of a document
+			doc, err := jq.NewDocument([]byte(tc.In))
+			require.NoError(t, err)
+			data, err = doc.Apply(op)
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}
@@ -0,0 +1,628 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxInt is the largest magnitude of an index or slice bound, that of the integers exactly representable as IEEE 754
+// doubles
+const maxInt = 1<<53 - 1
+
+// parser parses a query with the grammar of RFC 9535, checking the types of filter expressions as it goes
+type parser struct {
+	in  string
+	pos int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("jsonpath: syntax error at position %v: %v", p.pos, fmt.Sprintf(format, args...))
+}
+
+// peek returns the next byte, or 0 at the end of the query
+func (p *parser) peek() byte {
+	if p.pos == len(p.in) {
+		return 0
+	}
+	return p.in[p.pos]
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.in) && strings.IndexByte(" \t\n\r", p.in[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *parser) consume(s string) bool {
+	if strings.HasPrefix(p.in[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(s string) error {
+	if !p.consume(s) {
+		return p.errorf("expected %q", s)
+	}
+	return nil
+}
+
+// parseQuery parses a whole query, which must begin with $ and may not be surrounded by whitespace
+func (p *parser) parseQuery() (*query, error) {
+	if p.peek() != '$' {
+		return nil, p.errorf("a query must begin with $")
+	}
+	p.pos++
+
+	q, err := p.parseSegments(false)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.in) {
+		return nil, p.errorf("unexpected %q", p.in[p.pos:])
+	}
+	return q, nil
+}
+
+// parseSegments parses the segments following $ or @; whitespace is allowed before each segment but not after the last
+func (p *parser) parseSegments(relative bool) (*query, error) {
+	q := &query{relative: relative}
+	for {
+		start := p.pos
+		p.skipSpace()
+
+		var s *segment
+		var err error
+		switch {
+		case p.consume(".."):
+			s, err = p.parseDescendant()
+		case p.consume("."):
+			s, err = p.parseShorthand()
+		case p.peek() == '[':
+			s, err = p.parseBracketed()
+		default:
+			p.pos = start
+			return q, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		q.segments = append(q.segments, s)
+	}
+}
+
+// parseDescendant parses the selection following ..
+func (p *parser) parseDescendant() (*segment, error) {
+	var s *segment
+	var err error
+	if p.peek() == '[' {
+		s, err = p.parseBracketed()
+	} else {
+		s, err = p.parseShorthand()
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.descendant = true
+	return s, nil
+}
+
+// parseShorthand parses the wildcard or member name following . or ..
+func (p *parser) parseShorthand() (*segment, error) {
+	if p.consume("*") {
+		return &segment{selectors: []selector{wildcardSelector{}}}, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.in) {
+		r, size := utf8.DecodeRuneInString(p.in[p.pos:])
+		if !isNameChar(r) || p.pos == start && r >= '0' && r <= '9' || r == utf8.RuneError && size == 1 {
+			break
+		}
+		p.pos += size
+	}
+	if p.pos == start {
+		return nil, p.errorf("expected a member name or *")
+	}
+	return &segment{selectors: []selector{&nameSelector{name: p.in[start:p.pos]}}}, nil
+}
+
+// isNameChar reports whether r may appear in a member name shorthand: letters, digits, _ and anything beyond ASCII
+func isNameChar(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r >= 0x80
+}
+
+// parseBracketed parses [selector, ...]
+func (p *parser) parseBracketed() (*segment, error) {
+	p.pos++
+
+	s := &segment{}
+	for {
+		p.skipSpace()
+		sel, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		s.selectors = append(s.selectors, sel)
+
+		p.skipSpace()
+		switch {
+		case p.consume(","):
+		case p.consume("]"):
+			return s, nil
+		default:
+			return nil, p.errorf("expected , or ]")
+		}
+	}
+}
+
+func (p *parser) parseSelector() (selector, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		name, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return &nameSelector{name: name}, nil
+	case c == '*':
+		p.pos++
+		return wildcardSelector{}, nil
+	case c == '?':
+		p.pos++
+		p.skipSpace()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return &filterSelector{expr: expr}, nil
+	}
+
+	// an index, or a slice start:end:step where each part is optional
+	s := &sliceSelector{step: 1}
+	var err error
+	if p.peek() != ':' {
+		if s.start, err = p.parseInt(); err != nil {
+			return nil, err
+		}
+		s.hasStart = true
+		p.skipSpace()
+		if p.peek() != ':' {
+			return &indexSelector{index: s.start}, nil
+		}
+	}
+
+	p.pos++
+	p.skipSpace()
+	if c := p.peek(); c == '-' || c >= '0' && c <= '9' {
+		if s.end, err = p.parseInt(); err != nil {
+			return nil, err
+		}
+		s.hasEnd = true
+		p.skipSpace()
+	}
+	if p.consume(":") {
+		p.skipSpace()
+		if c := p.peek(); c == '-' || c >= '0' && c <= '9' {
+			if s.step, err = p.parseInt(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s, nil
+}
+
+// parseInt parses an integer without leading zeros, within the range of exactly representable integers
+func (p *parser) parseInt() (int, error) {
+	start := p.pos
+	p.consume("-")
+	digits := p.pos
+	for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+		p.pos++
+	}
+
+	s := p.in[start:p.pos]
+	switch {
+	case p.pos == digits:
+		return 0, p.errorf("expected an integer")
+	case p.in[digits] == '0' && (p.pos-digits > 1 || digits > start):
+		return 0, p.errorf("invalid integer %v", s)
+	}
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || i > maxInt || i < -maxInt {
+		return 0, p.errorf("integer %v out of range", s)
+	}
+	return int(i), nil
+}
+
+// parseString parses a string literal quoted with ' or ", in which only the quote used needs escaping
+func (p *parser) parseString() (string, error) {
+	quote := p.in[p.pos]
+	p.pos++
+
+	var b strings.Builder
+	for {
+		if p.pos == len(p.in) {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.in[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return b.String(), nil
+		case c < 0x20:
+			return "", p.errorf("control character in string")
+		case c != '\\':
+			b.WriteByte(c)
+			p.pos++
+			continue
+		}
+
+		p.pos++
+		switch e := p.peek(); e {
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '/', '\\':
+			b.WriteByte(e)
+		case '\'', '"':
+			if e != quote {
+				return "", p.errorf("invalid escape \\%c", e)
+			}
+			b.WriteByte(e)
+		case 'u':
+			r, err := p.parseUnicodeEscape()
+			if err != nil {
+				return "", err
+			}
+			b.WriteRune(r)
+			continue
+		default:
+			return "", p.errorf("invalid escape \\%c", e)
+		}
+		p.pos++
+	}
+}
+
+// parseUnicodeEscape parses the u of \uXXXX and its digits, and a second escape completing a surrogate pair
+func (p *parser) parseUnicodeEscape() (rune, error) {
+	hex := func() (rune, error) {
+		if p.pos+5 > len(p.in) {
+			return 0, p.errorf("invalid unicode escape")
+		}
+		n, err := strconv.ParseUint(p.in[p.pos+1:p.pos+5], 16, 16)
+		if err != nil {
+			return 0, p.errorf("invalid unicode escape")
+		}
+		p.pos += 5
+		return rune(n), nil
+	}
+
+	r, err := hex()
+	switch {
+	case err != nil:
+		return 0, err
+	case r >= 0xDC00 && r <= 0xDFFF:
+		return 0, p.errorf("unpaired surrogate")
+	case r < 0xD800 || r > 0xDBFF:
+		return r, nil
+	}
+
+	if !p.consume(`\`) || p.peek() != 'u' {
+		return 0, p.errorf("unpaired surrogate")
+	}
+	low, err := hex()
+	if err != nil {
+		return 0, err
+	}
+	if low < 0xDC00 || low > 0xDFFF {
+		return 0, p.errorf("unpaired surrogate")
+	}
+	return (r-0xD800)<<10 + (low - 0xDC00) + 0x10000, nil
+}
+
+// parseOr parses logical-and-expr *(S "||" S logical-and-expr)
+func (p *parser) parseOr() (logical, error) {
+	var operands orExpr
+	for {
+		x, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, x)
+
+		start := p.pos
+		p.skipSpace()
+		if !p.consume("||") {
+			p.pos = start
+			break
+		}
+		p.skipSpace()
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return operands, nil
+}
+
+// parseAnd parses basic-expr *(S "&&" S basic-expr)
+func (p *parser) parseAnd() (logical, error) {
+	var operands andExpr
+	for {
+		x, err := p.parseBasic()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, x)
+
+		start := p.pos
+		p.skipSpace()
+		if !p.consume("&&") {
+			p.pos = start
+			break
+		}
+		p.skipSpace()
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return operands, nil
+}
+
+// comparisonOps holds the comparison operators, those sharing a prefix longest first
+var comparisonOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// parseBasic parses a parenthesized expression, a comparison or a test of a query or function, any of which but a
+// comparison may be negated with !
+func (p *parser) parseBasic() (logical, error) {
+	if p.consume("!") {
+		p.skipSpace()
+		if p.peek() == '(' {
+			x, err := p.parseParen()
+			if err != nil {
+				return nil, err
+			}
+			return &notExpr{expr: x}, nil
+		}
+
+		operand, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		x, err := p.testExpr(operand)
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{expr: x}, nil
+	}
+	if p.peek() == '(' {
+		return p.parseParen()
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	start := p.pos
+	p.skipSpace()
+	for _, op := range comparisonOps {
+		if !p.consume(op) {
+			continue
+		}
+		l, err := p.comparable(left, start)
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		rightStart := p.pos
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		r, err := p.comparable(right, rightStart)
+		if err != nil {
+			return nil, err
+		}
+		return &comparison{op: op, left: l, right: r}, nil
+	}
+	p.pos = start
+	return p.testExpr(left)
+}
+
+func (p *parser) parseParen() (logical, error) {
+	p.pos++
+	p.skipSpace()
+	x, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// call is a parsed function expression with its declared result type
+type call struct {
+	name   string
+	result exprType
+	expr   any
+}
+
+// parseOperand parses a literal, a query from @ or $ or a function expression
+func (p *parser) parseOperand() (any, error) {
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		p.pos++
+		return p.parseSegments(c == '@')
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(s)
+		return literal(v), err
+	case c == '-' || c >= '0' && c <= '9':
+		return p.parseNumber()
+	case c >= 'a' && c <= 'z':
+		start := p.pos
+		for c := p.peek(); c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_'; c = p.peek() {
+			p.pos++
+		}
+		name := p.in[start:p.pos]
+		if p.peek() == '(' {
+			return p.parseCall(name, start)
+		}
+		switch name {
+		case "true", "false", "null":
+			return literal(name), nil
+		}
+		p.pos = start
+		return nil, p.errorf("unexpected %q", name)
+	default:
+		return nil, p.errorf("expected a literal, query or function")
+	}
+}
+
+// parseNumber parses a number literal: an integer or -0, then an optional fraction and exponent
+func (p *parser) parseNumber() (literal, error) {
+	start := p.pos
+	p.consume("-")
+	digits := p.pos
+	for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+		p.pos++
+	}
+	if p.pos == digits || p.in[digits] == '0' && p.pos-digits > 1 {
+		return nil, p.errorf("invalid number")
+	}
+
+	if p.consume(".") {
+		fraction := p.pos
+		for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+			p.pos++
+		}
+		if p.pos == fraction {
+			return nil, p.errorf("invalid number")
+		}
+	}
+	if c := p.peek(); c == 'e' || c == 'E' {
+		p.pos++
+		if c := p.peek(); c == '+' || c == '-' {
+			p.pos++
+		}
+		exponent := p.pos
+		for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+			p.pos++
+		}
+		if p.pos == exponent {
+			return nil, p.errorf("invalid number")
+		}
+	}
+	return literal(p.in[start:p.pos]), nil
+}
+
+// parseCall parses the arguments of a function expression, checking them against the types of its parameters
+func (p *parser) parseCall(name string, start int) (*call, error) {
+	f, ok := functions[name]
+	if !ok {
+		p.pos = start
+		return nil, p.errorf("unknown function %v", name)
+	}
+	p.pos++
+
+	var args []any
+	p.skipSpace()
+	for !p.consume(")") {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+		}
+		if len(args) == len(f.params) {
+			return nil, p.errorf("too many arguments to %v", name)
+		}
+
+		argStart := p.pos
+		operand, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		arg, err := p.argument(operand, f.params[len(args)], argStart)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		p.skipSpace()
+	}
+	if len(args) < len(f.params) {
+		return nil, p.errorf("%v expects %v arguments", name, len(f.params))
+	}
+	return &call{name: name, result: f.result, expr: f.build(args)}, nil
+}
+
+// argument converts an operand into the type of the parameter it is passed to
+func (p *parser) argument(operand any, param exprType, start int) (any, error) {
+	switch param {
+	case valueType:
+		return p.comparable(operand, start)
+	case nodesType:
+		if q, ok := operand.(*query); ok {
+			return q, nil
+		}
+	}
+	p.pos = start
+	return nil, p.errorf("expected an argument of %v", param)
+}
+
+// comparable converts an operand into a ValueType expression: a literal, a singular query or a function producing a
+// value
+func (p *parser) comparable(operand any, start int) (valueExpr, error) {
+	switch x := operand.(type) {
+	case literal:
+		return x, nil
+	case *query:
+		if x.singular() {
+			return x, nil
+		}
+		p.pos = start
+		return nil, p.errorf("a query selecting more than one node can't be compared")
+	case *call:
+		if x.result == valueType {
+			return x.expr.(valueExpr), nil
+		}
+		p.pos = start
+		return nil, p.errorf("%v produces %v, not a value", x.name, x.result)
+	}
+	return nil, p.errorf("expected a value")
+}
+
+// testExpr converts an operand into a test: a query, which holds when it selects any node, or a function producing a
+// logical value
+func (p *parser) testExpr(operand any) (logical, error) {
+	switch x := operand.(type) {
+	case *query:
+		return &existsExpr{query: x}, nil
+	case *call:
+		switch x.result {
+		case logicalType:
+			return x.expr.(logical), nil
+		case nodesType:
+			return &existsExpr{query: x.expr.(nodesExpr)}, nil
+		}
+		return nil, p.errorf("%v produces %v, which can't be tested", x.name, x.result)
+	}
+	return nil, p.errorf("a literal can't be tested")
+}
@@ -0,0 +1,118 @@
+package jsonpath_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq/jsonpath"
+	"github.com/stretchr/testify/assert"
+)
+
+func BenchmarkParse(t *testing.B) {
+	for i := 0; i < t.N; i++ {
+		_, err := jsonpath.Parse(`$.store.book[?@.price < 10 && match(@.category, 'fic.*')].title`)
+		assert.NoError(t, err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	testCases := map[string]struct {
+		Query    string
+		HasError bool
+	}{
+		"root":                   {Query: `$`},
+		"empty":                  {Query: ``, HasError: true},
+		"no root":                {Query: `.a`, HasError: true},
+		"leading space":          {Query: ` $.a`, HasError: true},
+		"trailing space":         {Query: `$.a `, HasError: true},
+		"space before segment":   {Query: "$\t.a\n[0]"},
+		"space after dot":        {Query: `$. a`, HasError: true},
+		"space after dots":       {Query: `$.. a`, HasError: true},
+		"triple dot":             {Query: `$...a`, HasError: true},
+		"dot only":               {Query: `$.`, HasError: true},
+		"shorthand digit":        {Query: `$.1a`, HasError: true},
+		"shorthand hyphen":       {Query: `$.a-b`, HasError: true},
+		"shorthand unicode":      {Query: `$.☺`},
+		"empty brackets":         {Query: `$[]`, HasError: true},
+		"unclosed brackets":      {Query: `$[0`, HasError: true},
+		"trailing comma":         {Query: `$[0,]`, HasError: true},
+		"bare name":              {Query: `$[a]`, HasError: true},
+		"string escapes":         {Query: `$['\b\f\n\r\t\/\\\'']`},
+		"double quote escape":    {Query: `$["\""]`},
+		"wrong quote escape":     {Query: `$["\'"]`, HasError: true},
+		"unknown escape":         {Query: `$['\a']`, HasError: true},
+		"control character":      {Query: "$['\x01']", HasError: true},
+		"unicode escape":         {Query: `$['\u263A']`},
+		"surrogate pair":         {Query: `$['\uD834\uDD1E']`},
+		"lone high surrogate":    {Query: `$['\uD834']`, HasError: true},
+		"lone low surrogate":     {Query: `$['\uDD1E']`, HasError: true},
+		"short unicode escape":   {Query: `$['\u26']`, HasError: true},
+		"unterminated string":    {Query: `$['a`, HasError: true},
+		"index leading zero":     {Query: `$[01]`, HasError: true},
+		"index minus zero":       {Query: `$[-0]`, HasError: true},
+		"index max":              {Query: `$[9007199254740991]`},
+		"index too large":        {Query: `$[9007199254740992]`, HasError: true},
+		"index too small":        {Query: `$[-9007199254740992]`, HasError: true},
+		"index fraction":         {Query: `$[1.0]`, HasError: true},
+		"slice empty":            {Query: `$[:]`},
+		"slice steps only":       {Query: `$[::]`},
+		"slice leading zero":     {Query: `$[01:]`, HasError: true},
+		"slice too many":         {Query: `$[1:2:3:4]`, HasError: true},
+		"filter":                 {Query: `$[?@]`},
+		"filter space":           {Query: `$[? @.a ]`},
+		"filter root":            {Query: `$[?$.a]`},
+		"filter empty":           {Query: `$[?]`, HasError: true},
+		"filter literal":         {Query: `$[?1]`, HasError: true},
+		"filter true":            {Query: `$[?true]`, HasError: true},
+		"filter single equals":   {Query: `$[?@.a = 1]`, HasError: true},
+		"filter unclosed paren":  {Query: `$[?(@.a]`, HasError: true},
+		"filter double not":      {Query: `$[?!!@.a]`, HasError: true},
+		"filter not comparison":  {Query: `$[?!@.a == 1]`, HasError: true},
+		"filter not paren":       {Query: `$[?!(@.a == 1)]`},
+		"filter uppercase":       {Query: `$[?@.a == True]`, HasError: true},
+		"filter non-singular":    {Query: `$[?@.* == 1]`, HasError: true},
+		"filter descendant":      {Query: `$[?@..a == 1]`, HasError: true},
+		"filter singular":        {Query: `$[?@.a[0]['b'] == $[-1]]`},
+		"filter literals":        {Query: `$[?"a" == 'a' && 1 != -0.5e+3]`},
+		"filter number":          {Query: `$[?@ == 1.5E-3]`},
+		"filter bad number":      {Query: `$[?@ == 1.]`, HasError: true},
+		"filter leading zero":    {Query: `$[?@ == 01]`, HasError: true},
+		"filter minus zero":      {Query: `$[?@ == -0]`},
+		"filter and spaces":      {Query: `$[?@.a&&@.b||@.c]`},
+		"function":               {Query: `$[?length(@) < 3]`},
+		"function spaces":        {Query: `$[?length( @ ) < 3]`},
+		"function name space":    {Query: `$[?length (@) < 3]`, HasError: true},
+		"function unknown":       {Query: `$[?foo(@)]`, HasError: true},
+		"function uppercase":     {Query: `$[?LENGTH(@) < 3]`, HasError: true},
+		"function no arguments":  {Query: `$[?length() == 1]`, HasError: true},
+		"function two arguments": {Query: `$[?length(@, @) == 1]`, HasError: true},
+		"length literal":         {Query: `$[?length('abc') == 3]`},
+		"length non-singular":    {Query: `$[?length(@.*) == 1]`, HasError: true},
+		"length nested":          {Query: `$[?length(value(@.*)) == 1]`},
+		"length untested":        {Query: `$[?length(@)]`, HasError: true},
+		"length of match":        {Query: `$[?length(match(@, 'a')) == 1]`, HasError: true},
+		"length of comparison":   {Query: `$[?length(@ == 1) == 1]`, HasError: true},
+		"count":                  {Query: `$[?count(@.*) == 1]`},
+		"count literal":          {Query: `$[?count(1) == 1]`, HasError: true},
+		"count function":         {Query: `$[?count(length(@)) == 1]`, HasError: true},
+		"count untested":         {Query: `$[?count(@.*)]`, HasError: true},
+		"value":                  {Query: `$[?value(@..a) == 1]`},
+		"match":                  {Query: `$[?match(@.a, 'a.*')]`},
+		"match negated":          {Query: `$[?!match(@.a, 'a.*')]`},
+		"match compared":         {Query: `$[?match(@.a, 'a') == true]`, HasError: true},
+		"match non-singular":     {Query: `$[?match(@.*, 'a')]`, HasError: true},
+		"search":                 {Query: `$[?search(@.a, $.pattern)]`},
+		"nested filter":          {Query: `$[?@[?@.a]]`},
+		"relative outside":       {Query: `@.a`, HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			_, err := jsonpath.Parse(tc.Query)
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,345 @@
+package jq
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokField
+	tokVariable
+	tokNumber
+	tokString
+	tokPunct
+)
+
+// token is a single lexical element of a selector; text holds the identifier, field or variable name, the number
+// literal or the punctuation symbol
+type token struct {
+	kind tokenKind
+	text string
+	str  []stringPart
+	pos  int
+}
+
+// stringPart is either a literal run of a string or the source of an interpolated \(...) expression
+type stringPart struct {
+	lit    string
+	expr   string
+	pos    int
+	isExpr bool
+}
+
+// punctuation is ordered so that longer symbols are matched first
+var punctuation = []string{
+	"//=", "..", "==", "!=", "<=", ">=", "//", "|=", "+=", "-=", "*=", "/=", "%=", "=",
+	".", "[", "]", "{", "}", "(", ")", "|", ",", ":", ";", "<", ">", "+", "-", "*", "/", "%", "?", "~",
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of input"
+	case tokField:
+		return "." + t.text
+	case tokVariable:
+		return "$" + t.text
+	case tokString:
+		return "string"
+	default:
+		return t.text
+	}
+}
+
+// tokenize splits the selector into tokens; offset is added to every position reported
+func tokenize(src string, offset int) ([]token, error) {
+	l := lexer{src: src, offset: offset}
+
+	var tokens []token
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, tok)
+		if tok.kind == tokEOF {
+			return tokens, nil
+		}
+	}
+}
+
+type lexer struct {
+	src    string
+	pos    int
+	offset int
+}
+
+func (l *lexer) errorf(pos int, format string, args ...interface{}) error {
+	return fmt.Errorf("syntax error at position %v: %v", pos+l.offset, fmt.Sprintf(format, args...))
+}
+
+func (l *lexer) skipSpaceAndComments() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipSpaceAndComments()
+
+	start := l.pos
+	if start == len(l.src) {
+		return token{kind: tokEOF, pos: start + l.offset}, nil
+	}
+
+	c := l.src[start]
+	switch {
+	case isIdentStart(c):
+		return token{kind: tokIdent, text: l.ident(), pos: start + l.offset}, nil
+
+	case c == '$':
+		l.pos++
+		if l.pos == len(l.src) || !isIdentStart(l.src[l.pos]) {
+			return token{}, l.errorf(start, "expected variable name after $")
+		}
+		return token{kind: tokVariable, text: l.ident(), pos: start + l.offset}, nil
+
+	case isDigit(c):
+		return l.number()
+
+	case c == '"':
+		parts, err := l.string()
+		if err != nil {
+			return token{}, err
+		}
+		return token{kind: tokString, str: parts, pos: start + l.offset}, nil
+
+	case c == '.' && start+1 < len(l.src) && isIdentStart(l.src[start+1]):
+		l.pos++
+		return token{kind: tokField, text: l.ident(), pos: start + l.offset}, nil
+
+	case c == '.' && start+1 < len(l.src) && l.src[start+1] == '"':
+		l.pos++
+		parts, err := l.string()
+		if err != nil {
+			return token{}, err
+		}
+		if len(parts) > 1 || (len(parts) == 1 && parts[0].isExpr) {
+			return token{}, l.errorf(start, "interpolation is not supported in field names")
+		}
+		name := ""
+		if len(parts) == 1 {
+			name = parts[0].lit
+		}
+		return token{kind: tokField, text: name, pos: start + l.offset}, nil
+	}
+
+	for _, p := range punctuation {
+		if strings.HasPrefix(l.src[start:], p) {
+			l.pos += len(p)
+			return token{kind: tokPunct, text: p, pos: start + l.offset}, nil
+		}
+	}
+
+	return token{}, l.errorf(start, "unexpected character %q", c)
+}
+
+// ident consumes an identifier, including any module qualifiers such as mod::name
+func (l *lexer) ident() string {
+	start := l.pos
+	for {
+		for l.pos < len(l.src) && (isIdentStart(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		if strings.HasPrefix(l.src[l.pos:], "::") && l.pos+2 < len(l.src) && isIdentStart(l.src[l.pos+2]) {
+			l.pos += 2
+			continue
+		}
+		return l.src[start:l.pos]
+	}
+}
+
+func (l *lexer) number() (token, error) {
+	start := l.pos
+	for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+		l.pos++
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		l.pos++
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		l.pos++
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		if l.pos == len(l.src) || !isDigit(l.src[l.pos]) {
+			return token{}, l.errorf(start, "invalid number %v", l.src[start:l.pos])
+		}
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+	}
+
+	return token{kind: tokNumber, text: l.src[start:l.pos], pos: start + l.offset}, nil
+}
+
+// string consumes a string literal, splitting it into literal runs and interpolated expressions
+func (l *lexer) string() ([]stringPart, error) {
+	start := l.pos
+	l.pos++
+
+	var parts []stringPart
+	var lit strings.Builder
+	flush := func() {
+		if lit.Len() > 0 {
+			parts = append(parts, stringPart{lit: lit.String()})
+			lit.Reset()
+		}
+	}
+
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.pos++
+			flush()
+			if len(parts) == 0 {
+				parts = append(parts, stringPart{})
+			}
+			return parts, nil
+
+		case c == '\\':
+			if l.pos+1 < len(l.src) && l.src[l.pos+1] == '(' {
+				flush()
+				expr, err := l.interpolation()
+				if err != nil {
+					return nil, err
+				}
+				parts = append(parts, expr)
+				continue
+			}
+			if err := l.escape(&lit); err != nil {
+				return nil, err
+			}
+
+		default:
+			lit.WriteByte(c)
+			l.pos++
+		}
+	}
+
+	return nil, l.errorf(start, "unterminated string")
+}
+
+func (l *lexer) escape(lit *strings.Builder) error {
+	start := l.pos
+	if l.pos+1 >= len(l.src) {
+		return l.errorf(start, "unterminated escape")
+	}
+
+	c := l.src[l.pos+1]
+	l.pos += 2
+	switch c {
+	case '"', '\\', '/':
+		lit.WriteByte(c)
+	case 'b':
+		lit.WriteByte('\b')
+	case 'f':
+		lit.WriteByte('\f')
+	case 'n':
+		lit.WriteByte('\n')
+	case 'r':
+		lit.WriteByte('\r')
+	case 't':
+		lit.WriteByte('\t')
+	case 'u':
+		r, err := l.hex4(start)
+		if err != nil {
+			return err
+		}
+		// a high surrogate combines with a low one escaped right after it; any other surrogate is invalid on its own and
+		// leaves the escape after it to be read as usual
+		if utf16.IsSurrogate(r) && strings.HasPrefix(l.src[l.pos:], `\u`) {
+			next := *l
+			next.pos += 2
+			if r2, err := next.hex4(l.pos); err == nil {
+				if pair := utf16.DecodeRune(r, r2); pair != utf8.RuneError {
+					r, *l = pair, next
+				}
+			}
+		}
+		if !utf8.ValidRune(r) {
+			r = utf8.RuneError
+		}
+		lit.WriteRune(r)
+	default:
+		return l.errorf(start, "invalid escape \\%c", c)
+	}
+
+	return nil
+}
+
+func (l *lexer) hex4(start int) (rune, error) {
+	if l.pos+4 > len(l.src) {
+		return 0, l.errorf(start, "invalid unicode escape")
+	}
+	v, err := strconv.ParseUint(l.src[l.pos:l.pos+4], 16, 32)
+	if err != nil {
+		return 0, l.errorf(start, "invalid unicode escape")
+	}
+	l.pos += 4
+
+	return rune(v), nil
+}
+
+// interpolation consumes a \( ... ) sequence and returns the source of the enclosed expression
+func (l *lexer) interpolation() (stringPart, error) {
+	start := l.pos
+	l.pos += 2
+
+	depth := 1
+	for l.pos < len(l.src) {
+		switch l.src[l.pos] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				part := stringPart{expr: l.src[start+2 : l.pos], pos: start + 2 + l.offset, isExpr: true}
+				l.pos++
+				return part, nil
+			}
+		case '"':
+			if _, err := l.string(); err != nil {
+				return stringPart{}, err
+			}
+			continue
+		}
+		l.pos++
+	}
+
+	return stringPart{}, l.errorf(start, "unterminated interpolation")
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,67 @@
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkLexer(t *testing.B) {
+	selector := `.items[] | select(.name == "café 😀\n") | {id, total: (.price * 1.5e2)} # total`
+
+	for i := 0; i < t.N; i++ {
+		_, err := jq.Parse(selector)
+		require.NoError(t, err)
+	}
+}
+
+func TestLexer(t *testing.T) {
+	testCases := map[string]struct {
+		Op       string
+		Expected string
+		HasError bool
+	}{
+		"escapes":             {Op: `"\"\\\/\b\f\n\r\t"`, Expected: `"\"\\/\b\f\n\r\t"`},
+		"unicode escape":      {Op: `"\u0041\u00e9"`, Expected: `"Aé"`},
+		"nul escape":          {Op: `"\u0000"`, Expected: `"\u0000"`},
+		"utf-8":               {Op: `"é😀"`, Expected: `"é😀"`},
+		"surrogate pair":      {Op: `"\ud83d\ude00"`, Expected: `"😀"`},
+		"uppercase hex":       {Op: `"\uD83D\uDE00"`, Expected: `"😀"`},
+		"lone high surrogate": {Op: `"a\ud83d"`, Expected: `"a�"`},
+		"lone low surrogate":  {Op: `"\ude00a"`, Expected: `"�a"`},
+		"high then text":      {Op: `"\ud83dA"`, Expected: `"�A"`},
+		"high then escape":    {Op: `"\ud83d\u0041"`, Expected: `"�A"`},
+		"high then high":      {Op: `"\ud83d\ud83d\ude00"`, Expected: `"�😀"`},
+		"high then bad hex":   {Op: `"\ud83d\uzzzz"`, HasError: true},
+		"invalid escape":      {Op: `"\x"`, HasError: true},
+		"short unicode":       {Op: `"\u12"`, HasError: true},
+		"invalid hex":         {Op: `"\uzzzz"`, HasError: true},
+		"unterminated escape": {Op: `"\`, HasError: true},
+		"unterminated string": {Op: `"abc`, HasError: true},
+		"unterminated interp": {Op: `"\(1`, HasError: true},
+		"nested interp":       {Op: `"a\("b\("c")")"`, Expected: `"abc"`},
+		"string in interp":    {Op: `"\(")")"`, Expected: `")"`},
+		"exponent":            {Op: `1.5e2`, Expected: `1.5e2`},
+		"signed exponent":     {Op: `15E-1`, Expected: `15E-1`},
+		"missing exponent":    {Op: `1e`, HasError: true},
+		"comment":             {Op: "1 # one\n+ 1", Expected: `2`},
+		"unexpected":          {Op: `1 ! 2`, HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op)
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(`null`))
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.Expected, string(data))
+		})
+	}
+}
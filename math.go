@@ -0,0 +1,103 @@
+package jq
+
+import (
+	"fmt"
+	"math"
+)
+
+func mathBuiltins() {
+	unary := map[string]func(float64) float64{
+		"floor":       math.Floor,
+		"ceil":        math.Ceil,
+		"round":       math.Round,
+		"trunc":       math.Trunc,
+		"sqrt":        math.Sqrt,
+		"log":         math.Log,
+		"log2":        math.Log2,
+		"log10":       math.Log10,
+		"exp":         math.Exp,
+		"exp2":        math.Exp2,
+		"exp10":       func(x float64) float64 { return math.Pow(10, x) },
+		"fabs":        math.Abs,
+		"significand": significand,
+	}
+	for name, f := range unary {
+		define(name, 0, function(func(in []byte, _ [][]byte) ([]byte, error) {
+			x, err := numberArg(in)
+			if err != nil {
+				return nil, err
+			}
+			return number(f(x)), nil
+		}))
+	}
+
+	// abs returns numbers that aren't negative as they are, as jq does, keeping literals such as -0 and 1.50
+	define("abs", 0, function(func(in []byte, _ [][]byte) ([]byte, error) {
+		x, err := numberArg(in)
+		if err != nil {
+			return nil, err
+		}
+		if x < 0 {
+			return number(-x), nil
+		}
+		return in, nil
+	}))
+
+	define("pow", 2, function(func(_ []byte, args [][]byte) ([]byte, error) {
+		x, err := numberArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		y, err := numberArg(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return number(math.Pow(x, y)), nil
+	}))
+
+	define("infinite", 0, function(func([]byte, [][]byte) ([]byte, error) {
+		return infValue, nil
+	}))
+
+	define("nan", 0, function(func([]byte, [][]byte) ([]byte, error) {
+		return nanValue, nil
+	}))
+
+	predicates := map[string]func(float64) bool{
+		"isnan":      math.IsNaN,
+		"isinfinite": func(x float64) bool { return math.IsInf(x, 0) },
+		"isnormal": func(x float64) bool {
+			return !math.IsNaN(x) && !math.IsInf(x, 0) && math.Abs(x) >= 0x1p-1022
+		},
+	}
+	for name, f := range predicates {
+		define(name, 0, function(func(in []byte, _ [][]byte) ([]byte, error) {
+			x, err := numberArg(in)
+			if err != nil {
+				return nil, err
+			}
+			return boolValue(f(x)), nil
+		}))
+	}
+}
+
+// numberArg parses a number given to a math function
+func numberArg(in []byte) (float64, error) {
+	k, err := kindOf(in)
+	if err != nil {
+		return 0, err
+	}
+	if k != kindNumber {
+		return 0, fmt.Errorf("%v (%s) number required", k, truncate(in))
+	}
+	return toFloat(in)
+}
+
+// significand returns the mantissa of x scaled to lie within [1, 2), as the C function of the same name does
+func significand(x float64) float64 {
+	if x == 0 || math.IsNaN(x) || math.IsInf(x, 0) {
+		return x
+	}
+	frac, _ := math.Frexp(x)
+	return frac * 2
+}
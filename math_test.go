@@ -0,0 +1,88 @@
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkLog(t *testing.B) {
+	op := jq.Must(jq.Parse(`.latency | log | floor`))
+	data := []byte(`{"latency": 1234.5}`)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestMath(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+		HasError bool
+	}{
+		"floor":             {In: `3.7`, Op: `floor`, Expected: `3`},
+		"floor negative":    {In: `-3.2`, Op: `floor`, Expected: `-4`},
+		"ceil":              {In: `3.2`, Op: `ceil`, Expected: `4`},
+		"round":             {In: `[2.5, -2.5, 2.4]`, Op: `map(round)`, Expected: `[3,-3,2]`},
+		"trunc":             {In: `-2.7`, Op: `trunc`, Expected: `-2`},
+		"sqrt":              {In: `16`, Op: `sqrt`, Expected: `4`},
+		"sqrt negative":     {In: `-1`, Op: `sqrt`, Expected: `null`},
+		"pow":               {In: `null`, Op: `pow(2; 10)`, Expected: `1024`},
+		"pow input":         {In: `{"b": 3}`, Op: `pow(.b; 2)`, Expected: `9`},
+		"log":               {In: `1`, Op: `log`, Expected: `0`},
+		"log zero":          {In: `0`, Op: `log`, Expected: `-1.7976931348623157e+308`},
+		"log2":              {In: `8`, Op: `log2`, Expected: `3`},
+		"log10":             {In: `1000`, Op: `log10`, Expected: `3`},
+		"exp":               {In: `0`, Op: `exp`, Expected: `1`},
+		"exp10":             {In: `2`, Op: `exp10`, Expected: `100`},
+		"abs":               {In: `-5.5`, Op: `abs`, Expected: `5.5`},
+		"abs positive":      {In: `1.50`, Op: `abs`, Expected: `1.50`},
+		"abs string":        {In: `"abc"`, Op: `abs`, HasError: true},
+		"fabs":              {In: `-2`, Op: `fabs`, Expected: `2`},
+		"significand":       {In: `[8, 0, 3]`, Op: `map(significand)`, Expected: `[1,0,1.5]`},
+		"infinite":          {In: `null`, Op: `infinite`, Expected: `1.7976931348623157e+308`},
+		"negative infinite": {In: `null`, Op: `-infinite`, Expected: `-1.7976931348623157e+308`},
+		"nan":               {In: `null`, Op: `nan`, Expected: `null`},
+		"literal overflow":  {In: `null`, Op: `1e1000`, Expected: `1.7976931348623157e+308`},
+		"negative overflow": {In: `null`, Op: `-1e1000`, Expected: `-1.7976931348623157e+308`},
+		"overflow infinite": {In: `null`, Op: `1e1000 | isinfinite`, Expected: `true`},
+		"literal kept":      {In: `null`, Op: `1.50e2`, Expected: `1.50e2`},
+		"input overflow":    {In: `1e1000`, Op: `.`, Expected: `1e1000`},
+		"input infinite":    {In: `[1e1000]`, Op: `.[0] | isinfinite`, Expected: `true`},
+		"input computed":    {In: `1e1000`, Op: `. + 0`, Expected: `1.7976931348623157e+308`},
+		"nan type":          {In: `null`, Op: `nan | type`, Expected: `"number"`},
+		"nan in array":      {In: `null`, Op: `[nan, infinite]`, Expected: `[null,1.7976931348623157e+308]`},
+		"nan truthy":        {In: `null`, Op: `nan | not`, Expected: `false`},
+		"nan sorts first":   {In: `null`, Op: `nan < 1, nan == nan`, Expected: `[true,false]`},
+		"isnan":             {In: `null`, Op: `nan | isnan`, Expected: `true`},
+		"isnan number":      {In: `1`, Op: `isnan`, Expected: `false`},
+		"isinfinite":        {In: `null`, Op: `infinite, -infinite, 1 | isinfinite`, Expected: `[true,true,false]`},
+		"isinfinite max":    {In: `1.7976931348623157e+308`, Op: `isinfinite`, Expected: `false`},
+		"infinite math":     {In: `null`, Op: `infinite - infinite | isnan`, Expected: `true`},
+		"isnormal":          {In: `null`, Op: `1, 0, nan, infinite, 1e-310 | isnormal`, Expected: `[true,false,false,false,false]`},
+		"large fraction":    {In: `1709209815`, Op: `. + 0.5`, Expected: `1709209815.5`},
+		"small fraction":    {In: `0.00001`, Op: `. * 1, . * 10`, Expected: `[1e-05,0.0001]`},
+		"large integer":     {In: `1e17`, Op: `. * 1`, Expected: `1e+17`},
+		"not a number":      {In: `"a"`, Op: `floor`, HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op)
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}
@@ -0,0 +1,403 @@
+package jq
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	slashpath "path"
+	"path/filepath"
+)
+
+// WithModulePath adds directories to the paths searched for the modules and data loaded by import and include. It
+// opens the host file system to modules, so that imports may also give absolute search paths.
+func WithModulePath(dirs ...string) Option {
+	return func(o *options) {
+		o.moduleHost = true
+		for _, dir := range dirs {
+			o.modulePath = append(o.modulePath, os.DirFS(dir))
+		}
+	}
+}
+
+// WithModuleFS adds the root of fsys to the paths searched for the modules and data loaded by import and include, so
+// that a library can be embedded into a binary. Modules are confined to the file systems given: absolute search paths
+// are rejected unless WithModulePath is also given.
+func WithModuleFS(fsys fs.FS) Option {
+	return func(o *options) {
+		o.modulePath = append(o.modulePath, fsys)
+	}
+}
+
+func moduleBuiltins() {
+	define("modulemeta", 0, &builtin{
+		eval: func(e *env, in []byte, _ []node, fn func([]byte) error) error {
+			if k, err := kindOf(in); err != nil || k != kindString {
+				return fmt.Errorf("modulemeta input module not a string")
+			}
+			name, err := decodeString(in)
+			if err != nil {
+				return err
+			}
+			m, err := newModuleLoader(e.opts).load(name, nil, mainLocation)
+			if err != nil {
+				return err
+			}
+			return fn(m.metadata())
+		},
+	})
+}
+
+// location is a directory modules are searched for in; root indexes the search roots of a moduleLoader
+type location struct {
+	root int
+	dir  string
+}
+
+// mainLocation is the location of the selector passed to Parse, whose relative search paths apply to every root
+var mainLocation = location{root: -1, dir: "."}
+
+// module is a library of definitions loaded from a .jq file
+type module struct {
+	meta []byte     // the object given by the module directive
+	deps [][]byte   // the metadata of each import and include
+	defs []*funcDef // the definitions of the module, including those it includes
+}
+
+// metadata returns the module's metadata along with its dependencies and definitions, as modulemeta reports them
+func (m *module) metadata() []byte {
+	var b objectBuilder
+	_ = eachMember(m.meta, func(raw, v []byte) error {
+		key, err := decodeString(raw)
+		if err == nil {
+			b.set(key, raw, v)
+		}
+		return err
+	})
+
+	defs := make([][]byte, len(m.defs))
+	for i, d := range m.defs {
+		defs[i] = quote(d.String())
+	}
+	b.set("deps", nil, appendArray(nil, m.deps))
+	b.set("defs", nil, appendArray(nil, defs))
+	return b.bytes()
+}
+
+// moduleLoader loads modules and data for a single call to Parse, loading each file once
+type moduleLoader struct {
+	opts    *options
+	roots   []fs.FS
+	abs     map[string]int // roots added for absolute search paths, by path
+	modules map[string]*module
+	loading map[string]bool
+}
+
+func newModuleLoader(o *options) *moduleLoader {
+	return &moduleLoader{
+		opts:    o,
+		roots:   append([]fs.FS(nil), o.modulePath...),
+		abs:     map[string]int{},
+		modules: map[string]*module{},
+		loading: map[string]bool{},
+	}
+}
+
+// load loads the module at the relative path name; a module a/b is read from a/b.jq or a/b/b.jq
+func (l *moduleLoader) load(name string, meta []byte, from location) (*module, error) {
+	src, loc, key, err := l.find(name, meta, from, []string{name + ".jq", slashpath.Join(name, slashpath.Base(name)+".jq")})
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := l.modules[key]; ok {
+		return m, nil
+	}
+	if l.loading[key] {
+		return nil, fmt.Errorf("module %v imports itself", name)
+	}
+	l.loading[key] = true
+	defer delete(l.loading, key)
+
+	tokens, err := tokenize(string(src), 0)
+	if err != nil {
+		return nil, fmt.Errorf("module %v: %w", name, err)
+	}
+	p := parser{
+		tokens:  tokens,
+		opts:    l.opts,
+		scope:   append([]string(nil), predefined...),
+		modules: l,
+		origin:  loc,
+	}
+	m, err := p.parseModule()
+	if err != nil {
+		return nil, fmt.Errorf("module %v: %w", name, err)
+	}
+
+	l.modules[key] = m
+	return m, nil
+}
+
+// loadData loads the JSON documents of the file at the relative path name with a .json extension into an array
+func (l *moduleLoader) loadData(name string, meta []byte, from location) ([]byte, error) {
+	src, _, _, err := l.find(name, meta, from, []string{name + ".json"})
+	if err != nil {
+		return nil, err
+	}
+	data, err := (&inputState{docs: InputsFromReader(bytes.NewReader(src))}).slurp()
+	if err != nil {
+		return nil, fmt.Errorf("data %v: %w", name, err)
+	}
+	return data, nil
+}
+
+// find reads the first of the files provided found in the search path, returning its contents, its location and a
+// key identifying it. The search metadata of the import is searched first, relative to the importing module, then
+// the paths given by WithModulePath and WithModuleFS.
+func (l *moduleLoader) find(name string, meta []byte, from location, files []string) ([]byte, location, string, error) {
+	locs, err := l.search(meta, from)
+	if err != nil {
+		return nil, location{}, "", err
+	}
+
+	for _, loc := range locs {
+		for _, file := range files {
+			file = slashpath.Join(loc.dir, file)
+			if !fs.ValidPath(file) {
+				continue
+			}
+			src, err := fs.ReadFile(l.roots[loc.root], file)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, location{}, "", err
+			}
+			return src, location{root: loc.root, dir: slashpath.Dir(file)}, fmt.Sprintf("%v:%v", loc.root, file), nil
+		}
+	}
+	return nil, location{}, "", fmt.Errorf("module not found: %v", name)
+}
+
+// search returns the locations an import is searched for in
+func (l *moduleLoader) search(meta []byte, from location) ([]location, error) {
+	var paths [][]byte
+	if meta != nil {
+		if v, ok, err := lookupKey(meta, "search"); err != nil {
+			return nil, err
+		} else if ok {
+			switch k, _ := kindOf(v); k {
+			case kindString:
+				paths = [][]byte{v}
+			case kindArray:
+				if paths, err = elements(v); err != nil {
+					return nil, err
+				}
+			default:
+				return nil, fmt.Errorf("module search path must be a string or an array of strings")
+			}
+		}
+	}
+
+	var locs []location
+	for _, v := range paths {
+		dir, err := decodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("module search path must be a string or an array of strings")
+		}
+		switch {
+		case filepath.IsAbs(dir) && !l.opts.moduleHost:
+			return nil, fmt.Errorf("module search path %v is absolute, which requires WithModulePath", dir)
+		case filepath.IsAbs(dir):
+			i, ok := l.abs[dir]
+			if !ok {
+				i = len(l.roots)
+				l.roots = append(l.roots, os.DirFS(dir))
+				l.abs[dir] = i
+			}
+			locs = append(locs, location{root: i, dir: "."})
+		case from.root >= 0:
+			locs = append(locs, location{root: from.root, dir: slashpath.Join(from.dir, dir)})
+		default:
+			for i := range l.opts.modulePath {
+				locs = append(locs, location{root: i, dir: slashpath.Clean(dir)})
+			}
+		}
+	}
+
+	for i := range l.opts.modulePath {
+		locs = append(locs, location{root: i, dir: "."})
+	}
+	return locs, nil
+}
+
+// lookupKey returns the value of the member of the object in with the key provided
+func lookupKey(in []byte, key string) ([]byte, bool, error) {
+	var found []byte
+	err := eachMember(in, func(raw, v []byte) error {
+		if k, err := decodeString(raw); err == nil && k == key {
+			found = v
+		}
+		return nil
+	})
+	return found, found != nil, err
+}
+
+// parseModule parses a module: an optional module directive, imports and includes, then function definitions
+func (p *parser) parseModule() (*module, error) {
+	meta, err := p.parseModuleDirective()
+	if err != nil {
+		return nil, err
+	}
+	deps, err := p.parseDirectives()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword("def") {
+		d, err := p.parseDef()
+		if err != nil {
+			return nil, err
+		}
+		p.funcs = append(p.funcs, scopedFunc{name: d.name, def: d})
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, "expected a definition but found %v", t)
+	}
+
+	m := &module{meta: meta, deps: deps}
+	for _, f := range p.funcs {
+		if f.name == f.def.name {
+			m.defs = append(m.defs, f.def)
+		}
+	}
+	return m, nil
+}
+
+// parseModuleDirective parses module {...}; returning the metadata it holds, or an empty object when absent
+func (p *parser) parseModuleDirective() ([]byte, error) {
+	if !p.isKeyword("module") || p.tokens[p.pos+1].kind != tokPunct || p.tokens[p.pos+1].text != "{" {
+		return []byte("{}"), nil
+	}
+	p.next()
+	meta, err := p.parseMetadata()
+	if err != nil {
+		return nil, err
+	}
+	return meta, p.expect(";")
+}
+
+// parseDirectives parses the import "path" as name;, import "path" as $name; and include "path"; directives that
+// begin a selector or module, bringing the definitions and data they load into scope, and returns the metadata of each
+func (p *parser) parseDirectives() ([][]byte, error) {
+	var deps [][]byte
+	for p.isKeyword("import") || p.isKeyword("include") {
+		keyword := p.next().text
+
+		t := p.next()
+		if t.kind != tokString || len(t.str) > 1 || (len(t.str) == 1 && t.str[0].isExpr) {
+			return nil, p.errorf(t, "expected a constant module path but found %v", t)
+		}
+		name := ""
+		if len(t.str) == 1 {
+			name = t.str[0].lit
+		}
+
+		var as token
+		if keyword == "import" {
+			if err := p.expect("as"); err != nil {
+				return nil, err
+			}
+			as = p.next()
+			if (as.kind != tokIdent || keywords[as.text]) && as.kind != tokVariable {
+				return nil, p.errorf(as, "expected a module name but found %v", as)
+			}
+		}
+
+		meta := []byte("{}")
+		if p.isPunct("{") {
+			var err error
+			if meta, err = p.parseMetadata(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expect(";"); err != nil {
+			return nil, err
+		}
+
+		if err := p.bindImport(keyword, name, as, meta); err != nil {
+			return nil, err
+		}
+		deps = append(deps, dependency(meta, name, as))
+	}
+	return deps, nil
+}
+
+// bindImport loads an import or include and brings its definitions or data into scope
+func (p *parser) bindImport(keyword, name string, as token, meta []byte) error {
+	if as.kind == tokVariable {
+		data, err := p.modules.loadData(name, meta, p.origin)
+		if err != nil {
+			return err
+		}
+		if p.data == nil {
+			p.data = map[string][]byte{}
+		}
+		p.data[as.text] = data
+		p.data[as.text+"::"+as.text] = data
+		return nil
+	}
+
+	m, err := p.modules.load(name, meta, p.origin)
+	if err != nil {
+		return err
+	}
+	for _, d := range m.defs {
+		if keyword == "include" {
+			p.funcs = append(p.funcs, scopedFunc{name: d.name, def: d})
+		} else {
+			p.funcs = append(p.funcs, scopedFunc{name: as.text + "::" + d.name, def: d})
+		}
+	}
+	return nil
+}
+
+// parseMetadata parses the constant object given to a module directive or an import
+func (p *parser) parseMetadata() ([]byte, error) {
+	t := p.peek()
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	n, err := p.parseObject()
+	if err != nil {
+		return nil, err
+	}
+
+	e := &env{opts: p.opts}
+	e.top = e
+	v, ok, err := first(e, n, nullValue)
+	if err != nil || !ok {
+		return nil, p.errorf(t, "module metadata must be constant")
+	}
+	return v, nil
+}
+
+// dependency returns the metadata of an import or include as modulemeta reports it
+func dependency(meta []byte, name string, as token) []byte {
+	var b objectBuilder
+	_ = eachMember(meta, func(raw, v []byte) error {
+		key, err := decodeString(raw)
+		if err == nil {
+			b.set(key, raw, v)
+		}
+		return err
+	})
+
+	if as.kind != tokEOF {
+		b.set("as", nil, quote(as.text))
+	}
+	b.set("is_data", nil, boolValue(as.kind == tokVariable))
+	b.set("relpath", nil, quote(name))
+	return b.bytes()
+}
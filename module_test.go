@@ -0,0 +1,132 @@
+package jq_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var library = fstest.MapFS{
+	"company/common.jq": {Data: []byte(`
+		module {name: "common", version: 1};
+		import "company/strings" as s;
+		include "company/numbers";
+		def normalize: {id: .id | tostring, name: .name | s::upper};
+		def normalize($prefix): normalize | .id = $prefix + .id;
+	`)},
+	"company/strings/strings.jq": {Data: []byte(`def upper: "<" + . + ">";`)},
+	"company/numbers.jq":         {Data: []byte(`def double: . * 2;`)},
+	"company/lookup.json":        {Data: []byte(`{"1": "admin"}` + "\n" + `{"2": "user"}`)},
+	"vendor/extra.jq":            {Data: []byte(`def extra: "vendored";`)},
+	"company/uses_vendor.jq":     {Data: []byte(`import "extra" as e {search: "../vendor"}; def extra: e::extra;`)},
+	"company/uses_data.jq":       {Data: []byte(`import "company/lookup" as $roles; def role: $roles[0][.];`)},
+	"cycle/a.jq":                 {Data: []byte(`import "cycle/b" as b; def a: 1;`)},
+	"cycle/b.jq":                 {Data: []byte(`import "cycle/a" as a; def b: 1;`)},
+	"broken.jq":                  {Data: []byte(`def broken: (;`)},
+	"expression.jq":              {Data: []byte(`def f: 1; f`)},
+}
+
+func BenchmarkImport(t *testing.B) {
+	op := jq.Must(jq.Parse(`import "company/common" as c; map(c::normalize)`, jq.WithModuleFS(library)))
+	data := []byte(`[{"id":1,"name":"a"},{"id":2,"name":"b"}]`)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestModules(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+		HasError bool
+	}{
+		"import":           {In: `{"id": 1, "name": "a"}`, Op: `import "company/common" as c; c::normalize`, Expected: `{"id":"1","name":"<a>"}`},
+		"import arity":     {In: `{"id": 1, "name": "a"}`, Op: `import "company/common" as c; c::normalize("u-") | .id`, Expected: `"u-1"`},
+		"import included":  {In: `2`, Op: `import "company/common" as c; c::double`, Expected: `4`},
+		"import private":   {In: `"a"`, Op: `import "company/common" as c; c::s::upper`, HasError: true},
+		"import qualified": {In: `2`, Op: `import "company/numbers" as n; double`, HasError: true},
+		"include":          {In: `2`, Op: `include "company/numbers"; double`, Expected: `4`},
+		"directory":        {In: `"a"`, Op: `import "company/strings" as s; s::upper`, Expected: `"<a>"`},
+		"data":             {In: `null`, Op: `import "company/lookup" as $roles; $roles::roles`, Expected: `[{"1": "admin"},{"2": "user"}]`},
+		"data short":       {In: `"1"`, Op: `import "company/lookup" as $roles; $roles[0][.]`, Expected: `"admin"`},
+		"data in module":   {In: `"1"`, Op: `import "company/uses_data" as d; d::role`, Expected: `"admin"`},
+		"search":           {In: `null`, Op: `import "company/uses_vendor" as v; v::extra`, Expected: `"vendored"`},
+		"search main":      {In: `null`, Op: `import "extra" as e {search: "vendor"}; e::extra`, Expected: `"vendored"`},
+		"shadow":           {In: `2`, Op: `include "company/numbers"; def double: . * 3; double`, Expected: `6`},
+		"modulemeta": {
+			In:       `"company/common"`,
+			Op:       `modulemeta`,
+			Expected: `{"name":"common","version":1,"deps":[{"as":"s","is_data":false,"relpath":"company/strings"},{"is_data":false,"relpath":"company/numbers"}],"defs":["double/0","normalize/0","normalize/1"]}`,
+		},
+		"modulemeta search": {
+			In:       `"company/uses_vendor"`,
+			Op:       `modulemeta | .deps`,
+			Expected: `[{"search":"../vendor","as":"e","is_data":false,"relpath":"extra"}]`,
+		},
+		"modulemeta data": {
+			In:       `"company/uses_data"`,
+			Op:       `modulemeta | .deps[0].is_data`,
+			Expected: `true`,
+		},
+		"modulemeta missing": {In: `"missing"`, Op: `modulemeta`, HasError: true},
+		"not found":          {In: `null`, Op: `import "missing" as m; 1`, HasError: true},
+		"cycle":              {In: `null`, Op: `import "cycle/a" as a; a::a`, HasError: true},
+		"syntax":             {In: `null`, Op: `import "broken" as b; 1`, HasError: true},
+		"expression":         {In: `null`, Op: `import "expression" as e; 1`, HasError: true},
+		"metadata":           {In: `null`, Op: `import "company/numbers" as n {search: .x}; 1`, HasError: true},
+		"escape":             {In: `null`, Op: `import "../company/numbers" as n; 1`, HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op, jq.WithModuleFS(library))
+			if tc.HasError && err != nil {
+				return
+			}
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}
+
+func TestModulePath(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "lib"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib", "math.jq"), []byte(`def square: . * .;`), 0o600))
+
+	op, err := jq.Parse(`import "lib/math" as m; m::square`, jq.WithModulePath(dir))
+	require.NoError(t, err)
+	data, err := op.Apply([]byte(`3`))
+	require.NoError(t, err)
+	assert.Equal(t, `9`, string(data))
+
+	abs := `import "math" as m {search: "` + filepath.Join(dir, "lib") + `"}; m::square`
+	op, err = jq.Parse(abs, jq.WithModulePath(t.TempDir()))
+	require.NoError(t, err)
+	data, err = op.Apply([]byte(`4`))
+	require.NoError(t, err)
+	assert.Equal(t, `16`, string(data))
+
+	// absolute search paths reach the host file system, which modules confined to a file system can't
+	_, err = jq.Parse(abs, jq.WithModuleFS(library))
+	assert.Error(t, err)
+	_, err = jq.Parse(abs)
+	assert.Error(t, err)
+	_, err = jq.Parse(`import "passwd" as $p {search: "/etc"}; $p`, jq.WithModuleFS(library))
+	assert.Error(t, err)
+}
@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"encoding/base64"
 	"fmt"
+	"reflect"
+	"slices"
 	"strings"
 
 	"github.com/bubunyo/go-jq/scanner"
 )
 
-// Op defines a single transformation to be applied to a []byte
+// Op defines a single transformation to be applied to a []byte. An Op that produces no value, such as a type selector
+// given a value of another type, returns a nil []byte and a nil error.
 type Op interface {
 	Apply([]byte) ([]byte, error)
 }
@@ -27,18 +30,16 @@ func (fn OpFunc) Apply(in []byte) ([]byte, error) {
 func Dot(key string) OpFunc {
 	key = strings.TrimSpace(key)
 	if key == "" {
-		return func(in []byte) ([]byte, error) { return in, nil }
-	}
-
-	k := []byte(key)
-
-	return func(in []byte) ([]byte, error) {
-		return scanner.FindKey(in, 0, k)
+		return seek(nil)
 	}
+	return seek([]seekStep{{key: []byte(key)}})
 }
 
-// Chain executes a series of operations in the order provided
+// Chain executes a series of operations in the order provided, stopping early if an operation produces no value.
+// Consecutive Dot and Index Ops are joined into one, which finds the value they lead to in a single descent of its
+// input without validating the values it passes over.
 func Chain(filters ...Op) OpFunc {
+	filters = joinSeeks(filters)
 	return func(in []byte) ([]byte, error) {
 		if filters == nil {
 			return in, nil
@@ -51,17 +52,113 @@ func Chain(filters ...Op) OpFunc {
 			if err != nil {
 				return nil, err
 			}
+			if data == nil {
+				return nil, nil
+			}
 		}
 
 		return data, nil
 	}
 }
 
+// joinSeeks replaces each run of consecutive Ops returned by seek with a single one following all of their steps
+func joinSeeks(filters []Op) []Op {
+	var joined []Op
+	var last *seekOp
+	for _, filter := range filters {
+		s := seekOf(filter)
+		if s != nil && last != nil {
+			last = &seekOp{steps: append(slices.Clip(last.steps), s.steps...)}
+			joined[len(joined)-1] = OpFunc(last.Apply)
+			continue
+		}
+		last = s
+		joined = append(joined, filter)
+	}
+	return joined
+}
+
+// seekOp follows keys and indices into its input, as Dot and Index do. A single key or index is found as
+// scanner.FindKey and scanner.FindIndex find it; several are each sought without scanning the values they pass into,
+// and only the value found at the end is scanned in full. A seekOp without steps returns its input.
+type seekOp struct {
+	steps []seekStep
+}
+
+// seekStep is a key, given as the content of a JSON string, or an index when key is nil
+type seekStep struct {
+	key   []byte
+	index int
+}
+
+// seek returns the Apply method of a seekOp as an OpFunc. Every such method value shares the code pointer seekCode,
+// which is how seekOf tells them apart from other Ops.
+func seek(steps []seekStep) OpFunc {
+	return (&seekOp{steps: steps}).Apply
+}
+
+var seekCode = reflect.ValueOf(seek(nil)).Pointer()
+
+// seekProbe is the input given to an OpFunc returned by seek to have it report its seekOp rather than apply it
+var seekProbe = []byte("null")
+
+// probed is the error with which a seekOp reports itself when applied to seekProbe
+type probed struct {
+	op *seekOp
+}
+
+func (probed) Error() string {
+	return "seek probe"
+}
+
+// seekOf returns the seekOp of an Op returned by seek, or nil for any other Op
+func seekOf(op Op) *seekOp {
+	fn, ok := op.(OpFunc)
+	if !ok || fn == nil || reflect.ValueOf(fn).Pointer() != seekCode {
+		return nil
+	}
+	_, err := fn(seekProbe)
+	if p, ok := err.(probed); ok {
+		return p.op
+	}
+	return nil
+}
+
+func (o *seekOp) Apply(in []byte) ([]byte, error) {
+	switch {
+	case sameSlice(in, seekProbe):
+		return nil, probed{op: o}
+	case len(o.steps) == 0:
+		return in, nil
+	case len(o.steps) == 1 && o.steps[0].key != nil:
+		return scanner.FindKey(in, 0, o.steps[0].key)
+	case len(o.steps) == 1:
+		return scanner.FindIndex(in, 0, o.steps[0].index)
+	}
+
+	pos := 0
+	var err error
+	for _, s := range o.steps {
+		if s.key != nil {
+			pos, err = scanner.SeekKey(in, pos, s.key)
+		} else {
+			pos, err = scanner.SeekIndex(in, pos, s.index)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	end, err := scanner.Any(in, pos)
+	if err != nil {
+		return nil, err
+	}
+	return in[pos:end], nil
+}
+
 // Index extracts a specific element from the array provided
 func Index(index int) OpFunc {
-	return func(in []byte) ([]byte, error) {
-		return scanner.FindIndex(in, 0, index)
-	}
+	return seek([]seekStep{{index: index}})
 }
 
 // Range extracts a selection of elements from the array provided, inclusive
@@ -109,3 +206,14 @@ func B64Decode() OpFunc {
 		return decoded, nil
 	}
 }
+
+// B64Encode encodes its input as a base64 JSON string value
+func B64Encode() OpFunc {
+	return func(in []byte) ([]byte, error) {
+		result := make([]byte, 0, base64.StdEncoding.EncodedLen(len(in))+2)
+		result = append(result, '"')
+		result = base64.StdEncoding.AppendEncode(result, in)
+		result = append(result, '"')
+		return result, nil
+	}
+}
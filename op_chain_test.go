@@ -18,6 +18,16 @@ func BenchmarkChain(t *testing.B) {
 	}
 }
 
+func BenchmarkChainKeysIndex(t *testing.B) {
+	op := jq.Chain(jq.Dot("a"), jq.Dot("b"), jq.Index(3))
+	data := []byte(`{"a":{"b":[0,1,2,{"c":"value"},4,5]},"d":[{"e":1},{"e":2},{"e":3}]}`)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
 func TestChain(t *testing.T) {
 	testCases := map[string]struct {
 		In       string
@@ -35,6 +45,55 @@ func TestChain(t *testing.T) {
 			Op:       jq.Chain(jq.Dot("a"), jq.Dot("b")),
 			Expected: `"world"`,
 		},
+		"keys and index": {
+			In:       `{"a":{"b":[0,1,2,{"c":3}]}}`,
+			Op:       jq.Chain(jq.Dot("a"), jq.Dot("b"), jq.Index(3)),
+			Expected: `{"c":3}`,
+		},
+		"single descent": {
+			// joined keys and indices don't scan the values they pass, so the malformed member after b isn't reached
+			In:       `{"a":{"b":[0,1,2,3],"c":tru}}`,
+			Op:       jq.Chain(jq.Dot("a"), jq.Dot("b"), jq.Index(3)),
+			Expected: `3`,
+		},
+		"single key validates": {
+			In:       `{"a":{"b":[0,1,2,3],"c":tru}}`,
+			Op:       jq.Chain(jq.Dot("a")),
+			HasError: true,
+		},
+		"wrapped ops": {
+			// an OpFunc wrapping Dot is opaque to Chain, so each step scans the value it returns
+			In: `{"a":{"b":[0,1,2,3],"c":tru}}`,
+			Op: jq.Chain(jq.Dot("a"), jq.OpFunc(func(in []byte) ([]byte, error) {
+				return jq.Dot("b")(in)
+			})),
+			HasError: true,
+		},
+		"empty key": {
+			In:       `{"a":{"b":1}}`,
+			Op:       jq.Chain(jq.Dot("a"), jq.Dot(""), jq.Dot("b")),
+			Expected: `1`,
+		},
+		"between other ops": {
+			In:       `{"a":"{\"b\":[1,2]}"}`,
+			Op:       jq.Chain(jq.Dot("a"), jq.FromJSON(), jq.Dot("b"), jq.Index(1)),
+			Expected: `2`,
+		},
+		"missing key": {
+			In:       `{"a":{"b":[0]}}`,
+			Op:       jq.Chain(jq.Dot("a"), jq.Dot("c"), jq.Index(0)),
+			HasError: true,
+		},
+		"index beyond": {
+			In:       `{"a":{"b":[0]}}`,
+			Op:       jq.Chain(jq.Dot("a"), jq.Dot("b"), jq.Index(1)),
+			HasError: true,
+		},
+		"index of object": {
+			In:       `{"a":{"b":{"0":1}}}`,
+			Op:       jq.Chain(jq.Dot("a"), jq.Dot("b"), jq.Index(0)),
+			HasError: true,
+		},
 	}
 
 	for label, tc := range testCases {
@@ -0,0 +1,116 @@
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkRange(t *testing.B) {
+	op := jq.Range(1, 2)
+	data := []byte(`["a","b","c","d"]`)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestRange(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Op       jq.Op
+		Expected string
+		HasError bool
+	}{
+		"range": {
+			In:       `["a","b","c","d"]`,
+			Op:       jq.Range(1, 2),
+			Expected: `["b","c"]`,
+		},
+		"range of one": {
+			In:       `["a","b","c","d"]`,
+			Op:       jq.Range(3, 3),
+			Expected: `["d"]`,
+		},
+		"range with spaces": {
+			In:       `[ "a" , {"b": [1, 2]} , "c" ]`,
+			Op:       jq.Range(0, 1),
+			Expected: `["a" , {"b": [1, 2]}]`,
+		},
+		"range beyond end": {
+			In:       `["a","b"]`,
+			Op:       jq.Range(1, 5),
+			HasError: true,
+		},
+		"range reversed": {
+			In:       `["a","b","c"]`,
+			Op:       jq.Range(2, 1),
+			HasError: true,
+		},
+		"range of empty array": {
+			In:       `[]`,
+			Op:       jq.Range(0, 0),
+			HasError: true,
+		},
+		"range of object": {
+			In:       `{"a":1}`,
+			Op:       jq.Range(0, 0),
+			HasError: true,
+		},
+		"from": {
+			In:       `["a","b","c","d"]`,
+			Op:       jq.From(2),
+			Expected: `["c","d"]`,
+		},
+		"from start": {
+			In:       `["a","b"]`,
+			Op:       jq.From(0),
+			Expected: `["a","b"]`,
+		},
+		"from beyond end": {
+			In:       `["a","b"]`,
+			Op:       jq.From(2),
+			HasError: true,
+		},
+		"from malformed": {
+			In:       `["a",}`,
+			Op:       jq.From(0),
+			HasError: true,
+		},
+		"to": {
+			In:       `["a","b","c","d"]`,
+			Op:       jq.To(1),
+			Expected: `["a","b"]`,
+		},
+		"to last": {
+			In:       `["a","b"]`,
+			Op:       jq.To(1),
+			Expected: `["a","b"]`,
+		},
+		"to beyond end": {
+			In:       `["a","b"]`,
+			Op:       jq.To(2),
+			HasError: true,
+		},
+		"to of string": {
+			In:       `"ab"`,
+			Op:       jq.To(0),
+			HasError: true,
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			data, err := tc.Op.Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.Expected, string(data))
+			}
+		})
+	}
+}
@@ -0,0 +1,314 @@
+package jq
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// binaryOp computes the result of applying an arithmetic or comparison operator to two values
+type binaryOp func(a, b []byte) ([]byte, error)
+
+var binaryOps = map[string]binaryOp{
+	"+":  add,
+	"-":  subtract,
+	"*":  multiply,
+	"/":  divide,
+	"%":  modulo,
+	"==": compareWith(func(c int) bool { return c == 0 }),
+	"!=": compareWith(func(c int) bool { return c != 0 }),
+	"<":  compareWith(func(c int) bool { return c < 0 }),
+	"<=": compareWith(func(c int) bool { return c <= 0 }),
+	">":  compareWith(func(c int) bool { return c > 0 }),
+	">=": compareWith(func(c int) bool { return c >= 0 }),
+}
+
+func compareWith(test func(int) bool) binaryOp {
+	return func(a, b []byte) ([]byte, error) {
+		c, err := compareValues(a, b)
+		if err != nil {
+			return nil, err
+		}
+		return boolValue(test(c)), nil
+	}
+}
+
+func operandError(a, b []byte, ka, kb kind, verb string) error {
+	return fmt.Errorf("%v (%s) and %v (%s) cannot be %v", ka, truncate(a), kb, truncate(b), verb)
+}
+
+// truncate shortens values quoted in error messages
+func truncate(v []byte) []byte {
+	const max = 11
+	if len(v) > max {
+		return append(v[:max-3:max-3], "..."...)
+	}
+	return v
+}
+
+func kinds(a, b []byte) (kind, kind, error) {
+	ka, err := kindOf(a)
+	if err != nil {
+		return 0, 0, err
+	}
+	kb, err := kindOf(b)
+	if err != nil {
+		return 0, 0, err
+	}
+	return ka, kb, nil
+}
+
+func floats(a, b []byte) (float64, float64, error) {
+	fa, err := toFloat(a)
+	if err != nil {
+		return 0, 0, err
+	}
+	fb, err := toFloat(b)
+	if err != nil {
+		return 0, 0, err
+	}
+	return fa, fb, nil
+}
+
+func add(a, b []byte) ([]byte, error) {
+	ka, kb, err := kinds(a, b)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case ka == kindNull:
+		return b, nil
+	case kb == kindNull:
+		return a, nil
+	case ka != kb:
+		return nil, operandError(a, b, ka, kb, "added")
+	}
+
+	switch ka {
+	case kindNumber:
+		fa, fb, err := floats(a, b)
+		if err != nil {
+			return nil, err
+		}
+		return number(fa + fb), nil
+	case kindString:
+		sa, err := unquote(a)
+		if err != nil {
+			return nil, err
+		}
+		sb, err := unquote(b)
+		if err != nil {
+			return nil, err
+		}
+		dst := make([]byte, 0, len(sa)+len(sb)+2)
+		return appendQuoted(dst, append(append(make([]byte, 0, len(sa)+len(sb)), sa...), sb...)), nil
+	case kindArray:
+		ea, err := elements(a)
+		if err != nil {
+			return nil, err
+		}
+		eb, err := elements(b)
+		if err != nil {
+			return nil, err
+		}
+		return appendArray(make([]byte, 0, len(a)+len(b)), append(ea, eb...)), nil
+	case kindObject:
+		return mergeObjects(a, b, false)
+	default:
+		return nil, operandError(a, b, ka, kb, "added")
+	}
+}
+
+func subtract(a, b []byte) ([]byte, error) {
+	ka, kb, err := kinds(a, b)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case ka == kindNumber && kb == kindNumber:
+		fa, fb, err := floats(a, b)
+		if err != nil {
+			return nil, err
+		}
+		return number(fa - fb), nil
+	case ka == kindArray && kb == kindArray:
+		ea, err := elements(a)
+		if err != nil {
+			return nil, err
+		}
+		eb, err := elements(b)
+		if err != nil {
+			return nil, err
+		}
+
+		kept := ea[:0:0]
+		for _, v := range ea {
+			found := false
+			for _, r := range eb {
+				c, err := compareValues(v, r)
+				if err != nil {
+					return nil, err
+				}
+				if c == 0 {
+					found = true
+					break
+				}
+			}
+			if !found {
+				kept = append(kept, v)
+			}
+		}
+		return appendArray(nil, kept), nil
+	default:
+		return nil, operandError(a, b, ka, kb, "subtracted")
+	}
+}
+
+func multiply(a, b []byte) ([]byte, error) {
+	ka, kb, err := kinds(a, b)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case ka == kindNumber && kb == kindNumber:
+		fa, fb, err := floats(a, b)
+		if err != nil {
+			return nil, err
+		}
+		return number(fa * fb), nil
+	case ka == kindString && kb == kindNumber:
+		return repeat(a, b)
+	case ka == kindNumber && kb == kindString:
+		return repeat(b, a)
+	case ka == kindObject && kb == kindObject:
+		return mergeObjects(a, b, true)
+	default:
+		return nil, operandError(a, b, ka, kb, "multiplied")
+	}
+}
+
+func repeat(s, n []byte) ([]byte, error) {
+	count, err := toFloat(n)
+	if err != nil {
+		return nil, err
+	}
+	if count <= 0 {
+		return nullValue, nil
+	}
+
+	content, err := unquote(s)
+	if err != nil {
+		return nil, err
+	}
+	times := int(math.Ceil(count))
+	return appendQuoted(nil, bytes.Repeat(content, times)), nil
+}
+
+func divide(a, b []byte) ([]byte, error) {
+	ka, kb, err := kinds(a, b)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case ka == kindNumber && kb == kindNumber:
+		fa, fb, err := floats(a, b)
+		if err != nil {
+			return nil, err
+		}
+		if fb == 0 {
+			return nil, operandError(a, b, ka, kb, "divided because the divisor is zero")
+		}
+		return number(fa / fb), nil
+	case ka == kindString && kb == kindString:
+		sa, err := decodeString(a)
+		if err != nil {
+			return nil, err
+		}
+		sb, err := decodeString(b)
+		if err != nil {
+			return nil, err
+		}
+		return splitString(sa, sb), nil
+	default:
+		return nil, operandError(a, b, ka, kb, "divided")
+	}
+}
+
+// splitString splits s around each occurrence of sep and returns the parts as a JSON array of strings
+func splitString(s, sep string) []byte {
+	if s == "" {
+		return []byte("[]")
+	}
+
+	var parts []string
+	if sep == "" {
+		parts = strings.Split(s, "")
+	} else {
+		parts = strings.Split(s, sep)
+	}
+
+	values := make([][]byte, len(parts))
+	for i, part := range parts {
+		values[i] = quote(part)
+	}
+	return appendArray(nil, values)
+}
+
+func modulo(a, b []byte) ([]byte, error) {
+	ka, kb, err := kinds(a, b)
+	if err != nil {
+		return nil, err
+	}
+	if ka != kindNumber || kb != kindNumber {
+		return nil, operandError(a, b, ka, kb, "divided")
+	}
+
+	fa, fb, err := floats(a, b)
+	if err != nil {
+		return nil, err
+	}
+	ia, ib := int64(fa), int64(fb)
+	if ib == 0 {
+		return nil, operandError(a, b, ka, kb, "divided because the divisor is zero")
+	}
+	if ib < 0 {
+		ib = -ib
+	}
+	return number(float64(ia % ib)), nil
+}
+
+// mergeObjects adds the members of b to a, replacing existing keys; deep merges nested objects when requested
+func mergeObjects(a, b []byte, deep bool) ([]byte, error) {
+	ma, err := members(a)
+	if err != nil {
+		return nil, err
+	}
+	mb, err := members(b)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := objectBuilder{members: ma}
+	for _, m := range mb {
+		value := m.value
+		if deep {
+			if existing, ok := builder.get(m.key); ok {
+				ke, _ := kindOf(existing)
+				kv, _ := kindOf(value)
+				if ke == kindObject && kv == kindObject {
+					value, err = mergeObjects(existing, value, true)
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+		builder.set(m.key, m.raw, value)
+	}
+	return builder.bytes(), nil
+}
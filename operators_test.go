@@ -0,0 +1,85 @@
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkOperators(t *testing.B) {
+	op := jq.Must(jq.Parse(`.[0] + .[1]`))
+	data := []byte(`[{"a":1,"b":[1,2]},{"c":"x"}]`)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestOperators(t *testing.T) {
+	testCases := map[string]struct {
+		A        string
+		Operator string
+		B        string
+		Expected string
+		HasError bool
+	}{
+		"add numbers":         {A: `1`, Operator: "+", B: `2.5`, Expected: `3.5`},
+		"add null":            {A: `null`, Operator: "+", B: `1`, Expected: `1`},
+		"add to null":         {A: `"a"`, Operator: "+", B: `null`, Expected: `"a"`},
+		"add strings":         {A: `"a\n"`, Operator: "+", B: `"é"`, Expected: `"a\né"`},
+		"add arrays":          {A: `[1]`, Operator: "+", B: `[2,[3]]`, Expected: `[1,2,[3]]`},
+		"add objects":         {A: `{"a":1,"b":{"c":1}}`, Operator: "+", B: `{"b":{"d":2}}`, Expected: `{"a":1,"b":{"d":2}}`},
+		"add mixed":           {A: `"a"`, Operator: "+", B: `1`, HasError: true},
+		"add booleans":        {A: `true`, Operator: "+", B: `false`, HasError: true},
+		"subtract numbers":    {A: `5`, Operator: "-", B: `7`, Expected: `-2`},
+		"subtract arrays":     {A: `[1,2,1,{"a":1}]`, Operator: "-", B: `[1,{"a":1.0}]`, Expected: `[2]`},
+		"subtract objects":    {A: `{}`, Operator: "-", B: `{}`, HasError: true},
+		"subtract from null":  {A: `null`, Operator: "-", B: `1`, HasError: true},
+		"multiply numbers":    {A: `3`, Operator: "*", B: `-0.5`, Expected: `-1.5`},
+		"repeat string":       {A: `"ab"`, Operator: "*", B: `3`, Expected: `"ababab"`},
+		"repeat reversed":     {A: `2`, Operator: "*", B: `"ab"`, Expected: `"abab"`},
+		"repeat fraction":     {A: `"ab"`, Operator: "*", B: `1.5`, Expected: `"abab"`},
+		"repeat zero":         {A: `"ab"`, Operator: "*", B: `0`, Expected: `null`},
+		"merge objects":       {A: `{"a":{"b":1},"c":1}`, Operator: "*", B: `{"a":{"d":2},"c":{"e":3}}`, Expected: `{"a":{"b":1,"d":2},"c":{"e":3}}`},
+		"multiply object":     {A: `{"a":1}`, Operator: "*", B: `2`, HasError: true},
+		"divide numbers":      {A: `10`, Operator: "/", B: `4`, Expected: `2.5`},
+		"divide by zero":      {A: `1`, Operator: "/", B: `0`, HasError: true},
+		"split string":        {A: `"a, b, c"`, Operator: "/", B: `", "`, Expected: `["a","b","c"]`},
+		"split characters":    {A: `"aé"`, Operator: "/", B: `""`, Expected: `["a","é"]`},
+		"split empty":         {A: `""`, Operator: "/", B: `","`, Expected: `[]`},
+		"divide string":       {A: `"a"`, Operator: "/", B: `1`, HasError: true},
+		"modulo":              {A: `5`, Operator: "%", B: `3`, Expected: `2`},
+		"modulo negative":     {A: `-5`, Operator: "%", B: `3`, Expected: `-2`},
+		"modulo negative rhs": {A: `5`, Operator: "%", B: `-3`, Expected: `2`},
+		"modulo truncates":    {A: `5.5`, Operator: "%", B: `2.9`, Expected: `1`},
+		"modulo by zero":      {A: `5`, Operator: "%", B: `0.5`, HasError: true},
+		"modulo string":       {A: `"a"`, Operator: "%", B: `2`, HasError: true},
+		"equal numbers":       {A: `1`, Operator: "==", B: `1.0`, Expected: `true`},
+		"equal objects":       {A: `{"a":1,"b":[2]}`, Operator: "==", B: `{"b":[2],"a":1}`, Expected: `true`},
+		"not equal":           {A: `"a"`, Operator: "!=", B: `"b"`, Expected: `true`},
+		"null before false":   {A: `null`, Operator: "<", B: `false`, Expected: `true`},
+		"number before text":  {A: `99`, Operator: "<", B: `"1"`, Expected: `true`},
+		"array before object": {A: `[]`, Operator: "<", B: `{}`, Expected: `true`},
+		"compare strings":     {A: `"b"`, Operator: ">", B: `"a"`, Expected: `true`},
+		"compare arrays":      {A: `[1,2]`, Operator: "<=", B: `[1,3]`, Expected: `true`},
+		"compare prefix":      {A: `[1]`, Operator: ">=", B: `[1,0]`, Expected: `false`},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(`.[0] ` + tc.Operator + ` .[1]`)
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(`[` + tc.A + `,` + tc.B + `]`))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package jq
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bubunyo/go-jq/scanner"
+)
+
+// optimize rewrites a parsed expression into an equivalent plan that is cheaper to evaluate: chains of keys and
+// indices such as .a.b[0] are fused into a single descent of their input, and expressions that don't depend on their
+// input, such as 60 * 60, are evaluated once rather than for every input. Children are optimized before their
+// parents, so that a fold such as .[1 + 1] can make a chain fusable.
+func optimize(n node) node {
+	switch n := n.(type) {
+	case *pipeNode:
+		n.lhs, n.rhs = optimize(n.lhs), optimize(n.rhs)
+	case *commaNode:
+		n.lhs, n.rhs = optimize(n.lhs), optimize(n.rhs)
+	case *indexNode:
+		n.target, n.index = optimize(n.target), optimize(n.index)
+	case *sliceNode:
+		n.target, n.from, n.to = optimize(n.target), optimizeOptional(n.from), optimizeOptional(n.to)
+	case *iterateNode:
+		n.target = optimize(n.target)
+	case *globNode:
+		n.target = optimize(n.target)
+	case *arrayNode:
+		n.body = optimizeOptional(n.body)
+	case *objectNode:
+		for i := range n.entries {
+			n.entries[i].key, n.entries[i].value = optimize(n.entries[i].key), optimize(n.entries[i].value)
+		}
+	case *stringNode:
+		for i := range n.parts {
+			n.parts[i] = optimize(n.parts[i])
+		}
+	case *negateNode:
+		n.operand = optimize(n.operand)
+	case *binaryNode:
+		n.lhs, n.rhs = optimize(n.lhs), optimize(n.rhs)
+	case *andNode:
+		n.lhs, n.rhs = optimize(n.lhs), optimize(n.rhs)
+	case *orNode:
+		n.lhs, n.rhs = optimize(n.lhs), optimize(n.rhs)
+	case *alternativeNode:
+		n.lhs, n.rhs = optimize(n.lhs), optimize(n.rhs)
+	case *ifNode:
+		n.cond, n.then, n.els = optimize(n.cond), optimize(n.then), optimize(n.els)
+		// a constant condition always takes the same branch
+		if c, ok := n.cond.(literalNode); ok {
+			if truthy(c.value) {
+				return n.then
+			}
+			return n.els
+		}
+	case *tryNode:
+		n.body, n.handler = optimize(n.body), optimizeOptional(n.handler)
+	case *callNode:
+		optimizeAll(n.args)
+	case *funcCallNode:
+		optimizeAll(n.args)
+		optimizeDef(n.def)
+	case *defNode:
+		optimizeDef(n.def)
+		n.rest = optimize(n.rest)
+	case *reduceNode:
+		n.source, n.init, n.update = optimize(n.source), optimize(n.init), optimize(n.update)
+	case *foreachNode:
+		n.source, n.init, n.update = optimize(n.source), optimize(n.init), optimize(n.update)
+		n.extract = optimizeOptional(n.extract)
+	case *labelNode:
+		n.body = optimize(n.body)
+	case *asNode:
+		n.source, n.body = optimize(n.source), optimize(n.body)
+	case *assignNode:
+		n.target, n.value = optimize(n.target), optimize(n.value)
+	case *updateNode:
+		n.target, n.update = optimize(n.target), optimize(n.update)
+	case *walkNode:
+		n.f = optimize(n.f)
+	}
+
+	if s := fuse(n); s != nil {
+		return s
+	}
+	return fold(n)
+}
+
+func optimizeOptional(n node) node {
+	if n == nil {
+		return nil
+	}
+	return optimize(n)
+}
+
+func optimizeAll(ns []node) {
+	for i, n := range ns {
+		ns[i] = optimize(n)
+	}
+}
+
+// optimizeDef optimizes the body of a function once, however many times it is called; the definitions of the prelude
+// are optimized when it is loaded and so are never rewritten by the queries sharing them
+func optimizeDef(d *funcDef) {
+	if d.optimized || d.body == nil {
+		return
+	}
+	d.optimized = true
+	d.body = optimize(d.body)
+}
+
+// fuse replaces a chain of two or more keys and indices with a scanNode
+func fuse(n node) node {
+	switch n.(type) {
+	case *pipeNode, *indexNode:
+	default:
+		return nil
+	}
+
+	steps, ok := staticPath(n)
+	if !ok || len(steps) < 2 {
+		return nil
+	}
+
+	s := &scanNode{chain: n, steps: steps, raw: make([][]byte, len(steps))}
+	for i, step := range steps {
+		if step.index < 0 {
+			key := quote(step.name)
+			s.raw[i] = key[1 : len(key)-1]
+		}
+	}
+	return s
+}
+
+func isLiteral(n node) bool {
+	_, ok := n.(literalNode)
+	return ok
+}
+
+// constant reports whether an expression produces the same values whatever its input and environment
+func constant(n node) bool {
+	switch n := n.(type) {
+	case literalNode:
+		return true
+	case *commaNode:
+		return constant(n.lhs) && constant(n.rhs)
+	case *arrayNode:
+		return n.body == nil || constant(n.body)
+	case *objectNode:
+		for _, entry := range n.entries {
+			if !constant(entry.key) || !constant(entry.value) {
+				return false
+			}
+		}
+		return true
+	case *stringNode:
+		for _, part := range n.parts {
+			if !constant(part) {
+				return false
+			}
+		}
+		return true
+	case *negateNode:
+		return constant(n.operand)
+	case *binaryNode:
+		return constant(n.lhs) && constant(n.rhs)
+	case *andNode:
+		return constant(n.lhs) && constant(n.rhs)
+	case *orNode:
+		return constant(n.lhs) && constant(n.rhs)
+	default:
+		return false
+	}
+}
+
+// fold replaces a constant expression producing a single value with that value. Expressions that fail, such as 1 / 0,
+// are left to fail when evaluated.
+func fold(n node) node {
+	if isLiteral(n) || !constant(n) {
+		return n
+	}
+
+	var values [][]byte
+	err := n.eval(nil, nullValue, func(v []byte) error {
+		values = append(values, v)
+		return nil
+	})
+	if err != nil || len(values) != 1 {
+		return n
+	}
+	return literalNode{value: values[0]}
+}
+
+// scanNode evaluates a chain of keys and indices, such as .a.b[0], in a single descent of its input: each key or index
+// is sought without scanning the values it passes into, and only the value found at the end is scanned in full. The
+// values after those sought aren't validated. Paths are evaluated by the chain fused.
+type scanNode struct {
+	chain node
+	steps []pathStep
+	raw   [][]byte // the key of each step as the content of a JSON string, for steps that aren't indices
+}
+
+func (n *scanNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	v, err := n.scan(e.doc, in)
+	if err != nil {
+		return err
+	}
+	return fn(v)
+}
+
+func (n *scanNode) evalPath(e *env, in []byte, p path, fn func([]byte, path) error) error {
+	return evalPath(e, n.chain, in, p, fn)
+}
+
+// scan returns the value at the end of the chain as the chain would: a missing key or index is null, as is anything
+// within null, while indexing a value of another kind is an error. The chain jumps through the index of d when in is
+// part of the document.
+func (n *scanNode) scan(d *Document, in []byte) ([]byte, error) {
+	if node, ok := d.node(in); ok {
+		return n.jump(d.tape, node)
+	}
+
+	pos := 0
+	for i, s := range n.steps {
+		k, err := kindOf(in[pos:])
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case k == kindNull:
+			return nullValue, nil
+		case k == kindObject && s.index < 0:
+			pos, err = scanner.SeekKey(in, pos, n.raw[i])
+		case k == kindArray && s.index >= 0:
+			pos, err = scanner.SeekIndex(in, pos, s.index)
+		default:
+			return nil, s.mismatch(k)
+		}
+		if absent(err) {
+			return nullValue, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	end, err := scanner.Any(in, pos)
+	if err != nil {
+		return nil, err
+	}
+	return in[pos:end], nil
+}
+
+// jump follows the chain through a tape from the value at node
+func (n *scanNode) jump(t *scanner.Tape, node int) ([]byte, error) {
+	for i, s := range n.steps {
+		v := t.Value(node)
+		k, err := kindOf(v)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case k == kindNull:
+			return nullValue, nil
+		case k == kindObject && s.index < 0:
+			node, err = t.FindKey(node, n.raw[i])
+		case k == kindArray && s.index >= 0:
+			node, err = t.FindIndex(node, s.index)
+		default:
+			return nil, s.mismatch(k)
+		}
+		if absent(err) {
+			return nullValue, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return t.Value(node), nil
+}
+
+func (n *scanNode) String() string {
+	return pathString(n.steps)
+}
+
+// mismatch reports a step applied to a value of a kind it can't index, as the expression the step came from would
+func (s pathStep) mismatch(k kind) error {
+	switch {
+	case s.field:
+		return fmt.Errorf("cannot index %v with %s", k, quote(s.name))
+	case s.index < 0:
+		return fmt.Errorf("cannot index %v with %v", k, kindString)
+	default:
+		return fmt.Errorf("cannot index %v with %v", k, kindNumber)
+	}
+}
+
+// pathString writes a path of keys and indices as jq would, such as .a."b-c"[0]
+func pathString(steps []pathStep) string {
+	var b strings.Builder
+	for _, s := range steps {
+		switch {
+		case s.index >= 0:
+			if b.Len() == 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString("[" + strconv.Itoa(s.index) + "]")
+		case s.field && isIdent(s.name):
+			b.WriteString("." + s.name)
+		case s.field:
+			b.WriteString("." + string(quote(s.name)))
+		default:
+			if b.Len() == 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString("[" + string(quote(s.name)) + "]")
+		}
+	}
+	return b.String()
+}
+
+// isIdent reports whether a key can be written after a dot without quotes
+func isIdent(s string) bool {
+	if s == "" || !isIdentStart(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if c := s[i]; !isIdentStart(c) && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package jq_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deep is a document whose sought values follow a large sibling, which a fused path skips without scanning twice
+var deep = []byte(`{"spec":{"template":{"containers":[{"image":"nginx","ports":[{"port":80}]}]},` +
+	`"history":[` + strings.Repeat(`{"revision":1,"labels":{"app":"web","tier":"frontend"}},`, 100) + `{}]}}`)
+
+func BenchmarkOptimize(t *testing.B) {
+	op := jq.Must(jq.Parse(`.spec.template.containers[0].ports[0].port`))
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(deep)
+		require.NoError(t, err)
+	}
+}
+
+func TestOptimize(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+		HasError bool
+	}{
+		"fused":                {In: string(deep), Op: `.spec.template.containers[0].image`, Expected: `"nginx"`},
+		"quoted keys":          {In: `{"a":{"b-c":[1,{"d":2}]}}`, Op: `.a."b-c"[1]["d"]`, Expected: `2`},
+		"escaped keys":         {In: `{"a\/b":{"c":1}}`, Op: `.["a/b"].c`, Expected: `1`},
+		"spaced":               {In: ` { "a" : [ 1 , { "b" : 2 } ] } `, Op: `.a[1].b`, Expected: `2`},
+		"rest not validated":   {In: `{"a":{"b":1,"c":}}`, Op: `.a.b`, Expected: `1`},
+		"null along the way":   {In: `{"a":null}`, Op: `.a.b[0].c`, Expected: `null`},
+		"null input":           {In: `null`, Op: `.a.b`, Expected: `null`},
+		"missing key":          {In: `{"a":{}}`, Op: `.a.b`, Expected: `null`},
+		"out of bounds":        {In: `{"a":[]}`, Op: `.a[0]`, Expected: `null`},
+		"field of a number":    {In: `{"a":1}`, Op: `try .a.b catch .`, Expected: `"cannot index number with \"b\""`},
+		"key of an array":      {In: `{"a":[1]}`, Op: `try .a["b"] catch .`, Expected: `"cannot index array with string"`},
+		"index of an object":   {In: `{"a":{"b":1}}`, Op: `try .a[0] catch .`, Expected: `"cannot index object with number"`},
+		"optional":             {In: `{"a":1}`, Op: `.a.b?`, Expected: ``},
+		"alternative":          {In: `{"a":{}}`, Op: `.a.b // "none"`, Expected: `"none"`},
+		"paths":                {In: `{"a":{"b":[1]}}`, Op: `path(.a.b[0])`, Expected: `["a","b",0]`},
+		"paths of missing":     {In: `{}`, Op: `path(.a.b)`, Expected: `["a","b"]`},
+		"update":               {In: `{"a":{"b":[1,2]}}`, Op: `.a.b[1] |= . + 1`, Expected: `{"a":{"b":[1,3]}}`},
+		"assign":               {In: `{}`, Op: `.a.b = 1`, Expected: `{"a":{"b":1}}`},
+		"delete":               {In: `{"a":{"b":1,"c":2}}`, Op: `del(.a.b)`, Expected: `{"a":{"c":2}}`},
+		"constant index":       {In: `{"a":[1,2,3]}`, Op: `.a[1 + 1]`, Expected: `3`},
+		"constant arithmetic":  {In: `[1,2]`, Op: `[.[] * (60 * 60)]`, Expected: `[3600,7200]`},
+		"constant string":      {In: `null`, Op: `"a\(1 + 1)b"`, Expected: `"a2b"`},
+		"constant condition":   {In: `{"a":{"b":1}}`, Op: `if true then .a.b else error("x") end`, Expected: `1`},
+		"constant error":       {In: `null`, Op: `1 / 0`, HasError: true},
+		"constant values":      {In: `null`, Op: `[(1, 2) + 10]`, Expected: `[11,12]`},
+		"inside functions":     {In: `{"a":{"b":2}}`, Op: `def f: .a.b * (2 + 1); f`, Expected: `6`},
+		"inside interpolation": {In: `{"a":{"b":2}}`, Op: `"b is \(.a.b)"`, Expected: `"b is 2"`},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op)
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}
+
+func TestExplain(t *testing.T) {
+	testCases := map[string]struct {
+		Op       string
+		Expected string
+	}{
+		"fused":       {Op: `.a."b-c"[0]`, Expected: "scan .a.\"b-c\"[0]\n"},
+		"single step": {Op: `.a`, Expected: "field .a\n"},
+		"folded":      {Op: `.[1 + 1].b`, Expected: "scan .[2].b\n"},
+		"condition":   {Op: `if false then 1 else .a.b end`, Expected: "scan .a.b\n"},
+		"nested": {
+			Op:       `.items[] | select(.price > 60 * 60) | {id, user: .user.name}`,
+			Expected: "pipe\n  iterate\n    field .items\n  pipe\n    call select/1\n      binary >\n        field .price\n        literal 3600\n    object\n      literal \"id\"\n      field .id\n      literal \"user\"\n      scan .user.name\n",
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op)
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, op.(*jq.Query).Explain())
+		})
+	}
+}
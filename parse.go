@@ -2,9 +2,10 @@ package jq
 
 import (
 	"fmt"
+	"io/fs"
+	"math"
 	"regexp"
-	"strconv"
-	"strings"
+	"time"
 )
 
 var (
@@ -20,137 +21,1219 @@ func Must(op Op, err error) Op {
 	return op
 }
 
+// Option configures the Op returned by Parse
+type Option func(*options)
+
+type options struct {
+	maxDecompressedSize int64
+	now                 func() time.Time
+	location            *time.Location
+	slurp               bool
+	nullInput           bool
+	programName         string
+	strict              bool
+	modulePath          []fs.FS
+	moduleHost          bool // WithModulePath opened the host file system to modules, allowing absolute search paths
+	functions           map[string]Op
+	readsInputs         bool // the query calls input or inputs, so Apply splits its input into documents
+}
+
+// WithMaxDecompressedSize limits the number of bytes the decompression builtins (gunzip, zlib_inflate, inflate and
+// unzstd) may produce; defaults to DefaultMaxDecompressedSize
+func WithMaxDecompressedSize(n int64) Option {
+	return func(o *options) {
+		o.maxDecompressedSize = n
+	}
+}
+
+// WithClock sets the function now and the other date builtins use to read the current time; defaults to time.Now
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// WithLocation sets the time zone used by localtime and strflocaltime; defaults to time.Local
+func WithLocation(loc *time.Location) Option {
+	return func(o *options) {
+		o.location = loc
+	}
+}
+
+// WithSlurp evaluates the query once against an array holding every input document, as jq -s does
+func WithSlurp() Option {
+	return func(o *options) {
+		o.slurp = true
+	}
+}
+
+// WithNullInput evaluates the query once against null, leaving the input documents to be read with input and inputs,
+// as jq -n does
+func WithNullInput() Option {
+	return func(o *options) {
+		o.nullInput = true
+	}
+}
+
+// WithProgramName sets the value of $__prog_name; defaults to "jq"
+func WithProgramName(name string) Option {
+	return func(o *options) {
+		o.programName = name
+	}
+}
+
+// WithStrict validates each input document against RFC 8259 with scanner.Validate before evaluating it, rejecting the
+// malformed numbers, literals, whitespace, commas and strings the scanner otherwise accepts
+func WithStrict() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// WithFunction makes op callable from the query as a function without arguments, name, which applies op to its input
+// and produces nothing when op returns nil; functions defined with def take precedence over it, and it over builtins
+func WithFunction(name string, op Op) Option {
+	return func(o *options) {
+		if o.functions == nil {
+			o.functions = map[string]Op{}
+		}
+		o.functions[name] = op
+	}
+}
+
+func defaultOptions() options {
+	return options{
+		maxDecompressedSize: DefaultMaxDecompressedSize,
+		now:                 time.Now,
+		location:            time.Local,
+		programName:         "jq",
+	}
+}
+
 // Parse takes a string representation of a selector and returns the corresponding Op definition
-func Parse(selector string) (Op, error) {
-	// Split selector by pipe operator to get pipeline segments
-	pipeSegments := strings.Split(selector, "|")
+func Parse(selector string, opts ...Option) (Op, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	tokens, err := tokenize(selector, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{tokens: tokens, opts: &o, scope: append([]string(nil), predefined...), modules: newModuleLoader(&o), origin: mainLocation}
+	root, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	root = optimize(root)
+
+	vars := &binding{name: "__prog_name", value: quote(o.programName)}
+	return &Query{root: root, multi: analyse(root), opts: o, vars: vars}, nil
+}
+
+// FindIndices matches the array selector syntax, [index], [from:to], [from:], [:to] or [], returning the from,
+// separator and to submatches
+func FindIndices(key string) [][]string {
+	return reArray.FindAllStringSubmatch(key, -1)
+}
+
+// namedOperations is a registry of operations that can be referenced by name
+var namedOperations = map[string]func(*options) OpFunc{
+	"b64_decode":   func(*options) OpFunc { return B64Decode() },
+	"b64_encode":   func(*options) OpFunc { return B64Encode() },
+	"gunzip":       func(o *options) OpFunc { return Gunzip(o.maxDecompressedSize) },
+	"zlib_inflate": func(o *options) OpFunc { return ZlibInflate(o.maxDecompressedSize) },
+	"inflate":      func(o *options) OpFunc { return Inflate(o.maxDecompressedSize) },
+	"unzstd":       func(o *options) OpFunc { return Unzstd(o.maxDecompressedSize) },
+	"gzip":         func(*options) OpFunc { return Gzip() },
+	"zlib_deflate": func(*options) OpFunc { return ZlibDeflate() },
+	"deflate":      func(*options) OpFunc { return Deflate() },
+	"zstd":         func(*options) OpFunc { return Zstd() },
+	"fromjson":     func(*options) OpFunc { return FromJSON() },
+	"tojson":       func(*options) OpFunc { return ToJSON() },
+	"type":         func(*options) OpFunc { return Type() },
+	"tostring":     func(*options) OpFunc { return ToString() },
+	"tonumber":     func(*options) OpFunc { return ToNumber() },
+	"arrays":       func(*options) OpFunc { return Arrays() },
+	"objects":      func(*options) OpFunc { return Objects() },
+	"iterables":    func(*options) OpFunc { return Iterables() },
+	"scalars":      func(*options) OpFunc { return Scalars() },
+	"strings":      func(*options) OpFunc { return Strings() },
+	"numbers":      func(*options) OpFunc { return Numbers() },
+	"booleans":     func(*options) OpFunc { return Booleans() },
+	"nulls":        func(*options) OpFunc { return Nulls() },
+	"values":       func(*options) OpFunc { return Values() },
+}
+
+// keywords cannot be used as function names
+var keywords = map[string]bool{
+	"if": true, "then": true, "elif": true, "else": true, "end": true, "try": true, "catch": true,
+	"and": true, "or": true, "as": true, "def": true, "reduce": true, "foreach": true, "label": true,
+	"import": true, "include": true, "break": true,
+}
 
-	ops := make([]Op, 0, len(pipeSegments))
-	for _, segment := range pipeSegments {
-		op, err := parseSegment(segment)
+// parser builds the expression tree for a selector by recursive descent; each parse method handles one level of jq's
+// operator precedence, from the pipe operator down to postfix terms such as .a[0]
+type parser struct {
+	tokens []token
+	pos    int
+	opts   *options
+	scope  []string
+	funcs  []scopedFunc
+
+	// data holds the values of data imported with import "path" as $name, keyed by variable name
+	data map[string][]byte
+
+	modules *moduleLoader
+	origin  location // where the selector or module being parsed was loaded from
+}
+
+// scopedFunc is a function in scope while parsing, under the name it is called by, such as mod::f for imports
+type scopedFunc struct {
+	name string
+	def  *funcDef
+}
+
+// predefined holds the variables defined before a query starts
+var predefined = []string{"__prog_name"}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isPunct(text string) bool {
+	t := p.peek()
+	return t.kind == tokPunct && t.text == text
+}
+
+func (p *parser) isKeyword(text string) bool {
+	t := p.peek()
+	return t.kind == tokIdent && t.text == text
+}
+
+func (p *parser) expect(text string) error {
+	t := p.next()
+	if (t.kind == tokPunct || t.kind == tokIdent) && t.text == text {
+		return nil
+	}
+	return p.errorf(t, "expected %v but found %v", text, t)
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	return fmt.Errorf("syntax error at position %v: %v", t.pos, fmt.Sprintf(format, args...))
+}
+
+// parse parses the entire selector
+func (p *parser) parse() (node, error) {
+	if _, err := p.parseModuleDirective(); err != nil {
+		return nil, err
+	}
+	if _, err := p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	n, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, "unexpected %v", t)
+	}
+	return n, nil
+}
+
+func (p *parser) parsePipe() (node, error) {
+	if p.isKeyword("def") {
+		return p.parseDefs()
+	}
+
+	lhs, err := p.parseComma()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.isPunct("|") {
+		return lhs, nil
+	}
+	p.next()
+
+	rhs, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	return &pipeNode{lhs: lhs, rhs: rhs}, nil
+}
+
+func (p *parser) parseComma() (node, error) {
+	lhs, err := p.parseAlternative()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isPunct(",") {
+		p.next()
+		rhs, err := p.parseAlternative()
 		if err != nil {
 			return nil, err
 		}
-		ops = append(ops, op)
+		lhs = &commaNode{lhs: lhs, rhs: rhs}
+	}
+	return lhs, nil
+}
+
+func (p *parser) parseAlternative() (node, error) {
+	lhs, err := p.parseAssignment()
+	if err != nil {
+		return nil, err
 	}
 
-	return Chain(ops...), nil
+	if !p.isPunct("//") {
+		return lhs, nil
+	}
+	p.next()
+
+	rhs, err := p.parseAlternative()
+	if err != nil {
+		return nil, err
+	}
+	return &alternativeNode{lhs: lhs, rhs: rhs}, nil
 }
 
-func parseArray(key string) (Op, bool) {
-	match := FindIndices(key)
+// parseAssignment parses the assignment operators, which bind more tightly than // but less tightly than or
+func (p *parser) parseAssignment() (node, error) {
+	lhs, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
 
-	if len(match) == 0 {
-		return nil, false
+	t := p.peek()
+	if t.kind != tokPunct {
+		return lhs, nil
+	}
+	op, ok := assignOps[t.text]
+	if !ok && t.text != "|=" {
+		return lhs, nil
 	}
+	p.next()
 
-	matches := match[0]
+	rhs, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind == tokPunct && (next.text == "|=" || assignOps[next.text] != nil) {
+		return nil, p.errorf(next, "assignments cannot be chained without parentheses")
+	}
 
-	if matches[1]+matches[2]+matches[3] == "" {
-		return From(0), true
+	if t.text == "|=" {
+		return &updateNode{target: lhs, update: rhs}, nil
+	}
+	return &assignNode{op: t.text, fn: op, target: lhs, value: rhs}, nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	lhs, err := p.parseAnd()
+	if err != nil {
+		return nil, err
 	}
 
-	if matches[2] == "" {
-		idx, err := strconv.Atoi(matches[1])
+	for p.isKeyword("or") {
+		p.next()
+		rhs, err := p.parseAnd()
 		if err != nil {
-			return nil, false
+			return nil, err
 		}
-		return Index(idx), true
+		lhs = &orNode{lhs: lhs, rhs: rhs}
 	}
+	return lhs, nil
+}
 
-	if matches[1] == "" {
-		to, err := strconv.Atoi(matches[3])
+func (p *parser) parseAnd() (node, error) {
+	lhs, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword("and") {
+		p.next()
+		rhs, err := p.parseComparison()
 		if err != nil {
-			return nil, false
+			return nil, err
 		}
-		return To(to), true
+		lhs = &andNode{lhs: lhs, rhs: rhs}
+	}
+	return lhs, nil
+}
+
+func (p *parser) parseComparison() (node, error) {
+	lhs, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	if !isComparison(t) {
+		return lhs, nil
 	}
+	p.next()
 
-	if matches[3] == "" {
-		from, err := strconv.Atoi(matches[1])
+	rhs, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); isComparison(next) {
+		return nil, p.errorf(next, "comparisons cannot be chained without parentheses")
+	}
+	return &binaryNode{op: t.text, fn: binaryOps[t.text], lhs: lhs, rhs: rhs}, nil
+}
+
+func isComparison(t token) bool {
+	if t.kind != tokPunct {
+		return false
+	}
+	switch t.text {
+	case "==", "!=", "<", "<=", ">", ">=":
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	lhs, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isPunct("+") || p.isPunct("-") {
+		op := p.next().text
+		rhs, err := p.parseMultiplicative()
 		if err != nil {
-			return nil, false
+			return nil, err
 		}
-		return From(from), true
+		lhs = &binaryNode{op: op, fn: binaryOps[op], lhs: lhs, rhs: rhs}
 	}
+	return lhs, nil
+}
 
-	from, err := strconv.Atoi(matches[1])
+func (p *parser) parseMultiplicative() (node, error) {
+	lhs, err := p.parseUnary()
 	if err != nil {
-		return nil, false
+		return nil, err
 	}
 
-	to, err := strconv.Atoi(matches[3])
+	for p.isPunct("*") || p.isPunct("/") || p.isPunct("%") {
+		op := p.next().text
+		rhs, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		lhs = &binaryNode{op: op, fn: binaryOps[op], lhs: lhs, rhs: rhs}
+	}
+	return lhs, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if !p.isPunct("-") {
+		return p.parsePostfix()
+	}
+	p.next()
+
+	operand, err := p.parsePostfix()
 	if err != nil {
-		return nil, false
+		return nil, err
+	}
+	return &negateNode{operand: operand}, nil
+}
+
+// parsePostfix parses a term, which may bind variables for the rest of the pipe as in .a as $x | $x + 1
+func (p *parser) parsePostfix() (node, error) {
+	term, err := p.parseTerm()
+	if err != nil || !p.isKeyword("as") {
+		return term, err
 	}
+	p.next()
 
-	return Range(from, to), true
+	pat, err := p.parsePattern()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("|"); err != nil {
+		return nil, err
+	}
+	body, err := p.withVariables(pat.variables(), p.parsePipe)
+	if err != nil {
+		return nil, err
+	}
+	return &asNode{source: term, pattern: pat, body: body}, nil
 }
 
-func FindIndices(key string) [][]string {
-	return reArray.FindAllStringSubmatch(key, -1)
+// withVariables parses with the variables provided in scope
+func (p *parser) withVariables(names []string, parse func() (node, error)) (node, error) {
+	depth := len(p.scope)
+	p.scope = append(p.scope, names...)
+	n, err := parse()
+	p.scope = p.scope[:depth]
+	return n, err
 }
 
-// namedOperations is a registry of operations that can be referenced by name
-var namedOperations = map[string]func() OpFunc{
-	"b64_decode": B64Decode,
+// variable resolves a reference to a variable, or to data imported with import "path" as $name
+func (p *parser) variable(t token) (node, error) {
+	if p.defined(t.text) {
+		return &variableNode{name: t.text}, nil
+	}
+	if v, ok := p.data[t.text]; ok {
+		return literalNode{value: v}, nil
+	}
+	return nil, p.errorf(t, "$%v is not defined", t.text)
 }
 
-// isNamedOperation checks if a segment name corresponds to a registered operation
-func isNamedOperation(name string) bool {
-	_, exists := namedOperations[name]
-	return exists
+func (p *parser) defined(name string) bool {
+	for i := len(p.scope) - 1; i >= 0; i-- {
+		if p.scope[i] == name {
+			return true
+		}
+	}
+	return false
 }
 
-// getNamedOperation retrieves a named operation from the registry
-func getNamedOperation(name string) (Op, error) {
-	fn, exists := namedOperations[name]
-	if !exists {
-		// This should not happen if isNamedOperation is checked first
-		return nil, fmt.Errorf("unknown operation: %s", name)
+// parsePattern parses the destructuring patterns accepted by as: $x, [$a, $b] and {key: $v, $name}
+func (p *parser) parsePattern() (pattern, error) {
+	t := p.next()
+	switch {
+	case t.kind == tokVariable:
+		return &variablePattern{name: t.text}, nil
+
+	case t.kind == tokPunct && t.text == "[":
+		pat := &arrayPattern{}
+		for {
+			element, err := p.parsePattern()
+			if err != nil {
+				return nil, err
+			}
+			pat.elements = append(pat.elements, element)
+
+			if p.isPunct(",") {
+				p.next()
+				continue
+			}
+			return pat, p.expect("]")
+		}
+
+	case t.kind == tokPunct && t.text == "{":
+		pat := &objectPattern{}
+		for {
+			entry, err := p.parseObjectPatternEntry()
+			if err != nil {
+				return nil, err
+			}
+			pat.entries = append(pat.entries, entry)
+
+			if p.isPunct(",") {
+				p.next()
+				continue
+			}
+			return pat, p.expect("}")
+		}
+
+	default:
+		return nil, p.errorf(t, "expected a variable, array or object pattern but found %v", t)
 	}
-	return fn(), nil
 }
 
-// parseDotNotation parses a dot-notation selector (e.g., "a.b.c" or "a.[0]")
-func parseDotNotation(selector string) (Op, error) {
-	segments := strings.Split(selector, ".")
+func (p *parser) parseObjectPatternEntry() (objectPatternEntry, error) {
+	var entry objectPatternEntry
 
-	ops := make([]Op, 0, len(segments))
-	for _, segment := range segments {
-		key := strings.TrimSpace(segment)
-		if key == "" {
-			continue
+	t := p.next()
+	switch {
+	case t.kind == tokVariable:
+		entry.key = literalNode{value: quote(t.text)}
+		entry.variable = t.text
+		if !p.isPunct(":") {
+			return entry, nil
+		}
+	case t.kind == tokIdent:
+		entry.key = literalNode{value: quote(t.text)}
+	case t.kind == tokString:
+		key, err := p.parseString(t)
+		if err != nil {
+			return entry, err
+		}
+		entry.key = key
+	case t.kind == tokPunct && t.text == "(":
+		key, err := p.parsePipe()
+		if err != nil {
+			return entry, err
+		}
+		if err := p.expect(")"); err != nil {
+			return entry, err
 		}
+		entry.key = key
+	default:
+		return entry, p.errorf(t, "unexpected %v in object pattern", t)
+	}
 
-		if op, ok := parseArray(key); ok {
-			ops = append(ops, op)
-			continue
+	if err := p.expect(":"); err != nil {
+		return entry, err
+	}
+	value, err := p.parsePattern()
+	if err != nil {
+		return entry, err
+	}
+	entry.value = value
+	return entry, nil
+}
+
+// parseTerm parses a primary expression followed by any number of field accesses, brackets and ? operators
+func (p *parser) parseTerm() (node, error) {
+	term, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == tokField:
+			p.next()
+			term = pipe(term, newFieldNode(t.text))
+
+		case t.kind == tokPunct && t.text == "." && p.tokens[p.pos+1].kind == tokPunct && p.tokens[p.pos+1].text == "[":
+			p.next()
+
+		case t.kind == tokPunct && t.text == "[":
+			if term, err = p.parseBracket(term); err != nil {
+				return nil, err
+			}
+
+		case t.kind == tokPunct && t.text == "." && p.isWildcard(p.pos):
+			p.next()
+			p.next()
+			term = &globNode{target: term, pattern: anyKey}
+
+		case t.kind == tokPunct && t.text == "?":
+			p.next()
+			term = &tryNode{body: term}
+
+		default:
+			return term, nil
+		}
+	}
+}
+
+// pipe joins two nodes, dropping identities
+func pipe(lhs, rhs node) node {
+	if _, ok := lhs.(identityNode); ok {
+		return rhs
+	}
+	if _, ok := rhs.(identityNode); ok {
+		return lhs
+	}
+	return &pipeNode{lhs: lhs, rhs: rhs}
+}
+
+// parseBracket parses the [], [index], [from:to], [from:] and [:to] suffixes applied to target, along with the key
+// patterns [*"glob"] and [~"key"]
+func (p *parser) parseBracket(target node) (node, error) {
+	p.next()
+
+	if pattern, err := p.parseKeyPattern(); pattern != nil || err != nil {
+		return &globNode{target: target, pattern: pattern}, err
+	}
+
+	if p.isPunct("]") {
+		p.next()
+		return &iterateNode{target: target}, nil
+	}
+
+	if p.isPunct(":") {
+		p.next()
+		to, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return &sliceNode{target: target, to: to}, nil
+	}
+
+	idx, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.isPunct(":") {
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return &indexNode{target: target, index: idx}, nil
+	}
+	p.next()
+
+	if p.isPunct("]") {
+		p.next()
+		return &sliceNode{target: target, from: idx}, nil
+	}
+
+	to, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	return &sliceNode{target: target, from: idx, to: to}, nil
+}
+
+// isWildcard reports whether the dot at tokens[i] and the * following it are the wildcard .*, which they are when
+// they're adjacent and nothing follows that could be multiplied, so that .*2 remains a multiplication
+func (p *parser) isWildcard(i int) bool {
+	dot, star := p.tokens[i], p.tokens[i+1]
+	if star.kind != tokPunct || star.text != "*" || star.pos != dot.pos+1 {
+		return false
+	}
+	return !startsOperand(p.tokens[i+2])
+}
+
+// startsOperand reports whether a token can begin an operand of a binary operator
+func startsOperand(t token) bool {
+	switch t.kind {
+	case tokEOF:
+		return false
+	case tokIdent:
+		switch t.text {
+		case "then", "elif", "else", "end", "catch", "and", "or", "as":
+			return false
+		}
+		return true
+	case tokPunct:
+		switch t.text {
+		case ".", "..", "(", "[", "{", "-":
+			return true
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// parseKeyPattern parses the key pattern of a bracket suffix, if it holds one: a string literal following *, which is
+// matched as a glob, or following ~, which is matched ignoring case, as in .[*"app.*"], .[~"key"] and .[~*"x-*"]. It
+// returns nil for any other suffix, so that a string index such as .["what?"] remains an exact key.
+func (p *parser) parseKeyPattern() (*keyPattern, error) {
+	i := p.pos
+	fold := p.tokens[i].kind == tokPunct && p.tokens[i].text == "~"
+	if fold {
+		i++
+	}
+	glob := p.tokens[i].kind == tokPunct && p.tokens[i].text == "*"
+	if glob {
+		i++
+	}
+	if !fold && !glob {
+		return nil, nil
+	}
+
+	t := p.tokens[i]
+	if t.kind != tokString || len(t.str) > 1 || len(t.str) == 1 && t.str[0].isExpr {
+		return nil, p.errorf(t, "expected a string literal in a key pattern, found %v", t)
+	}
+	key := ""
+	if len(t.str) == 1 {
+		key = t.str[0].lit
+	}
+
+	p.pos = i + 1
+	if err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	if glob {
+		return parseKeyPattern(key, fold), nil
+	}
+	return literalKeyPattern(key, fold), nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokNumber:
+		f, err := toFloat([]byte(t.text))
+		if err != nil {
+			return nil, p.errorf(t, "invalid number %v", t.text)
+		}
+		// literals beyond the range of a double, such as 1e1000, are clamped as computed values are; numbers in the
+		// input are instead returned as written until computed with, like every value selected from it
+		if isNumber([]byte(t.text)) && !math.IsInf(f, 0) {
+			return literalNode{value: []byte(t.text)}, nil
+		}
+		return literalNode{value: number(f)}, nil
+
+	case tokString:
+		return p.parseString(t)
+
+	case tokField:
+		return newFieldNode(t.text), nil
+
+	case tokVariable:
+		return p.variable(t)
+
+	case tokIdent:
+		return p.parseIdent(t)
+
+	case tokPunct:
+		switch t.text {
+		case ".":
+			if p.isWildcard(p.pos - 1) {
+				p.next()
+				return &globNode{target: identityNode{}, pattern: anyKey}, nil
+			}
+			return identityNode{}, nil
+		case "..":
+			return &callNode{name: "recurse", fn: builtins["recurse/0"]}, nil
+		case "(":
+			n, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		case "[":
+			if p.isPunct("]") {
+				p.next()
+				return &arrayNode{}, nil
+			}
+			body, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			return &arrayNode{body: body}, p.expect("]")
+		case "{":
+			return p.parseObject()
 		}
+	}
+
+	return nil, p.errorf(t, "unexpected %v", t)
+}
 
-		ops = append(ops, Dot(key))
+func (p *parser) parseIdent(t token) (node, error) {
+	switch t.text {
+	case "true":
+		return literalNode{value: trueValue}, nil
+	case "false":
+		return literalNode{value: falseValue}, nil
+	case "null":
+		return literalNode{value: nullValue}, nil
+	case "if":
+		return p.parseIf()
+	case "try":
+		return p.parseTry()
+	case "label":
+		return p.parseLabel()
+	case "reduce", "foreach":
+		return p.parseReduce(t.text)
+	case "break":
+		v := p.next()
+		if v.kind != tokVariable {
+			return nil, p.errorf(v, "expected a label after break but found %v", v)
+		}
+		if !p.defined("*label-" + v.text) {
+			return nil, p.errorf(v, "$*label-%v is not defined", v.text)
+		}
+		return &breakNode{name: v.text}, nil
+	}
+
+	if keywords[t.text] {
+		return nil, p.errorf(t, "unexpected %v", t.text)
 	}
 
-	return Chain(ops...), nil
+	var args []node
+	if p.isPunct("(") {
+		p.next()
+		for {
+			arg, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+
+			if p.isPunct(";") {
+				p.next()
+				continue
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	return p.resolve(t, args)
 }
 
-// parseSegment parses a single pipe segment, which can be either a named operation or a dot-notation selector
-func parseSegment(segment string) (Op, error) {
-	segment = strings.TrimSpace(segment)
+// resolve binds a function call to its definition; functions defined with def take precedence over builtins
+func (p *parser) resolve(t token, args []node) (node, error) {
+	if d := p.lookup(t.text, len(args)); d != nil {
+		return &funcCallNode{def: d, args: args}, nil
+	}
+	if op, ok := p.opts.functions[t.text]; ok && len(args) == 0 {
+		return opNode{op: op}, nil
+	}
+	if fn, ok := builtins[fmt.Sprintf("%v/%v", t.text, len(args))]; ok {
+		if t.text == "input" || t.text == "inputs" {
+			p.opts.readsInputs = true
+		}
+		return &callNode{name: t.text, args: args, fn: fn}, nil
+	}
+	if fn, ok := namedOperations[t.text]; ok && len(args) == 0 {
+		return opNode{op: fn(p.opts)}, nil
+	}
+	if d, ok := prelude[fmt.Sprintf("%v/%v", t.text, len(args))]; ok {
+		return &funcCallNode{def: d, args: args}, nil
+	}
+	return nil, p.errorf(t, "%v/%v is not defined", t.text, len(args))
+}
 
-	// Check for empty segments
-	if segment == "" {
-		return nil, fmt.Errorf("empty pipe segment")
+// parseIf parses the remainder of if cond then a elif cond then b else c end; a missing else returns the input
+func (p *parser) parseIf() (node, error) {
+	cond, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("then"); err != nil {
+		return nil, err
+	}
+	then, err := p.parsePipe()
+	if err != nil {
+		return nil, err
 	}
 
-	// Check if it's a named operation (no dots or brackets)
-	if !strings.Contains(segment, ".") && !strings.Contains(segment, "[") {
-		// Try to get as named operation
-		if isNamedOperation(segment) {
-			return getNamedOperation(segment)
+	switch t := p.next(); {
+	case t.kind == tokIdent && t.text == "elif":
+		els, err := p.parseIf()
+		if err != nil {
+			return nil, err
+		}
+		return &ifNode{cond: cond, then: then, els: els}, nil
+	case t.kind == tokIdent && t.text == "else":
+		els, err := p.parsePipe()
+		if err != nil {
+			return nil, err
 		}
+		return &ifNode{cond: cond, then: then, els: els}, p.expect("end")
+	case t.kind == tokIdent && t.text == "end":
+		return &ifNode{cond: cond, then: then, els: identityNode{}}, nil
+	default:
+		return nil, p.errorf(t, "expected elif, else or end but found %v", t)
+	}
+}
+
+// parseTry parses the remainder of try body catch handler; both bind as tightly as postfix terms
+func (p *parser) parseTry() (node, error) {
+	body, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isKeyword("catch") {
+		return &tryNode{body: body}, nil
+	}
+	p.next()
+
+	handler, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	return &tryNode{body: body, handler: handler}, nil
+}
+
+// parseLabel parses the remainder of label $name | body; the body extends as far right as possible
+func (p *parser) parseLabel() (node, error) {
+	v := p.next()
+	if v.kind != tokVariable {
+		return nil, p.errorf(v, "expected a label name but found %v", v)
+	}
+	if err := p.expect("|"); err != nil {
+		return nil, err
 	}
+	body, err := p.withVariables([]string{"*label-" + v.text}, p.parsePipe)
+	if err != nil {
+		return nil, err
+	}
+	return &labelNode{name: v.text, body: body}, nil
+}
 
-	// Otherwise, parse as dot notation selector
-	return parseDotNotation(segment)
+// parseReduce parses the remainder of reduce source as $x (init; update) and foreach source as $x (init; update) or
+// foreach source as $x (init; update; extract); the variables are in scope in update and extract only
+func (p *parser) parseReduce(keyword string) (node, error) {
+	source, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("as"); err != nil {
+		return nil, err
+	}
+	pat, err := p.parsePattern()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	init, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(";"); err != nil {
+		return nil, err
+	}
+	update, err := p.withVariables(pat.variables(), p.parsePipe)
+	if err != nil {
+		return nil, err
+	}
+
+	if keyword == "reduce" {
+		return &reduceNode{source: source, pattern: pat, init: init, update: update}, p.expect(")")
+	}
+
+	n := &foreachNode{source: source, pattern: pat, init: init, update: update}
+	if p.isPunct(";") {
+		p.next()
+		if n.extract, err = p.withVariables(pat.variables(), p.parsePipe); err != nil {
+			return nil, err
+		}
+	}
+	return n, p.expect(")")
+}
+
+// parseDefs parses function definitions followed by the expression they are in scope for; the expression may be
+// omitted at the end of a selector, in which case it is the identity
+func (p *parser) parseDefs() (node, error) {
+	d, err := p.parseDef()
+	if err != nil {
+		return nil, err
+	}
+
+	depth := len(p.funcs)
+	p.funcs = append(p.funcs, scopedFunc{name: d.name, def: d})
+	var rest node = identityNode{}
+	if p.peek().kind != tokEOF {
+		rest, err = p.parsePipe()
+	}
+	p.funcs = p.funcs[:depth]
+	if err != nil {
+		return nil, err
+	}
+
+	if d.closed {
+		return rest, nil
+	}
+	return &defNode{def: d, rest: rest}, nil
+}
+
+// parseDef parses def name: body; or def name(f; $v): body; the function is in scope within its own body, along with
+// its parameters
+func (p *parser) parseDef() (*funcDef, error) {
+	if err := p.expect("def"); err != nil {
+		return nil, err
+	}
+	t := p.next()
+	if t.kind != tokIdent || keywords[t.text] {
+		return nil, p.errorf(t, "expected a function name but found %v", t)
+	}
+	d := &funcDef{name: t.text, closed: p.closed()}
+
+	if p.isPunct("(") {
+		p.next()
+		for {
+			t := p.next()
+			switch {
+			case t.kind == tokIdent && !keywords[t.text]:
+				d.params = append(d.params, &funcDef{name: t.text, param: true})
+			case t.kind == tokVariable:
+				d.params = append(d.params, &funcDef{name: t.text, param: true, variable: true})
+			default:
+				return nil, p.errorf(t, "expected a parameter name but found %v", t)
+			}
+			if p.isPunct(";") {
+				p.next()
+				continue
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+
+	depth, vars := len(p.funcs), len(p.scope)
+	p.funcs = append(p.funcs, scopedFunc{name: d.name, def: d})
+	for _, param := range d.params {
+		p.funcs = append(p.funcs, scopedFunc{name: param.name, def: param})
+		if param.variable {
+			p.scope = append(p.scope, param.name)
+		}
+	}
+	body, err := p.parsePipe()
+	p.funcs, p.scope = p.funcs[:depth], p.scope[:vars]
+	if err != nil {
+		return nil, err
+	}
+	d.body = body
+	return d, p.expect(";")
+}
+
+// closed reports whether a function defined at the current position would capture nothing from its surroundings
+func (p *parser) closed() bool {
+	if len(p.scope) > len(predefined) {
+		return false
+	}
+	for _, f := range p.funcs {
+		if f.def.param || !f.def.closed {
+			return false
+		}
+	}
+	return true
+}
+
+// lookup finds the function in scope with the name and number of parameters provided, innermost first
+func (p *parser) lookup(name string, arity int) *funcDef {
+	for i := len(p.funcs) - 1; i >= 0; i-- {
+		if f := p.funcs[i]; f.name == name && len(f.def.params) == arity {
+			return f.def
+		}
+	}
+	return nil
+}
+
+// parseObject parses the entries of an object construction such as {a, "b": .c, (.d): 1}
+func (p *parser) parseObject() (node, error) {
+	n := &objectNode{}
+	if p.isPunct("}") {
+		p.next()
+		return n, nil
+	}
+
+	for {
+		entry, err := p.parseObjectEntry()
+		if err != nil {
+			return nil, err
+		}
+		n.entries = append(n.entries, entry)
+
+		if p.isPunct(",") {
+			p.next()
+			continue
+		}
+		return n, p.expect("}")
+	}
+}
+
+func (p *parser) parseObjectEntry() (objectEntry, error) {
+	var entry objectEntry
+
+	t := p.next()
+	switch {
+	case t.kind == tokVariable:
+		value, err := p.variable(t)
+		if err != nil {
+			return entry, err
+		}
+		entry.key = literalNode{value: quote(t.text)}
+		entry.value = value
+		return entry, nil
+	case t.kind == tokIdent:
+		entry.key = literalNode{value: quote(t.text)}
+		entry.value = newFieldNode(t.text)
+	case t.kind == tokString:
+		key, err := p.parseString(t)
+		if err != nil {
+			return entry, err
+		}
+		entry.key = key
+		entry.value = &indexNode{target: identityNode{}, index: key}
+	case t.kind == tokPunct && t.text == "(":
+		key, err := p.parsePipe()
+		if err != nil {
+			return entry, err
+		}
+		if err := p.expect(")"); err != nil {
+			return entry, err
+		}
+		entry.key = key
+		if !p.isPunct(":") {
+			return entry, p.errorf(p.peek(), "expected : after computed object key")
+		}
+	default:
+		return entry, p.errorf(t, "unexpected %v in object construction", t)
+	}
+
+	if !p.isPunct(":") {
+		return entry, nil
+	}
+	p.next()
+
+	value, err := p.parseObjectValue()
+	if err != nil {
+		return entry, err
+	}
+	entry.value = value
+	return entry, nil
+}
+
+// parseObjectValue parses an object value, which may contain pipes but not commas
+func (p *parser) parseObjectValue() (node, error) {
+	lhs, err := p.parseAlternative()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.isPunct("|") {
+		return lhs, nil
+	}
+	p.next()
+
+	rhs, err := p.parseObjectValue()
+	if err != nil {
+		return nil, err
+	}
+	return &pipeNode{lhs: lhs, rhs: rhs}, nil
+}
+
+// parseString builds a string literal, parsing any interpolated expressions
+func (p *parser) parseString(t token) (node, error) {
+	if len(t.str) == 1 && !t.str[0].isExpr {
+		return literalNode{value: quote(t.str[0].lit)}, nil
+	}
+
+	n := &stringNode{}
+	for _, part := range t.str {
+		if !part.isExpr {
+			n.parts = append(n.parts, literalNode{value: quote(part.lit)})
+			continue
+		}
+
+		tokens, err := tokenize(part.expr, part.pos)
+		if err != nil {
+			return nil, err
+		}
+		sub := *p
+		sub.tokens, sub.pos = tokens, 0
+		expr, err := sub.parse()
+		if err != nil {
+			return nil, err
+		}
+		n.parts = append(n.parts, expr)
+	}
+	return n, nil
 }
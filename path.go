@@ -0,0 +1,645 @@
+package jq
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/bubunyo/go-jq/scanner"
+)
+
+func pathBuiltins() {
+	define("path", 1, &builtin{
+		eval: func(e *env, in []byte, args []node, fn func([]byte) error) error {
+			return evalPath(e, args[0], in, nil, func(_ []byte, p path) error {
+				return fn(p.bytes())
+			})
+		},
+	})
+
+	define("getpath", 1, &builtin{
+		eval: func(e *env, in []byte, args []node, fn func([]byte) error) error {
+			return args[0].eval(e, in, func(p []byte) error {
+				elems, err := pathElements(p)
+				if err != nil {
+					return err
+				}
+				v, err := getPath(in, elems)
+				if err != nil {
+					return err
+				}
+				return fn(v)
+			})
+		},
+		path: func(e *env, in []byte, p path, args []node, fn func([]byte, path) error) error {
+			return args[0].eval(e, in, func(rel []byte) error {
+				elems, err := pathElements(rel)
+				if err != nil {
+					return err
+				}
+				v, err := getPath(in, elems)
+				if err != nil {
+					return err
+				}
+				return fn(v, p.append(elems...))
+			})
+		},
+	})
+
+	define("setpath", 2, function(func(in []byte, args [][]byte) ([]byte, error) {
+		p, err := pathElements(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return setPath(in, p, args[1])
+	}))
+
+	define("delpaths", 1, function(func(in []byte, args [][]byte) ([]byte, error) {
+		return delPaths(in, args[0])
+	}))
+}
+
+// pathElements validates that p is a JSON array and returns its elements
+func pathElements(p []byte) (path, error) {
+	if k, err := kindOf(p); err != nil || k != kindArray {
+		return nil, fmt.Errorf("path must be specified as an array; got %s", truncate(p))
+	}
+	return elements(p)
+}
+
+// getPath returns the value located at p, or null if the path doesn't exist
+func getPath(in []byte, p path) ([]byte, error) {
+	v := in
+	for _, elem := range p {
+		if v[0] == 'n' {
+			return nullValue, nil
+		}
+		var err error
+		if v, err = index(v, elem); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// entry is the location of an object member or array element within its parent; positions are -1 when absent
+type entry struct {
+	found      bool
+	start      int // start of the key of a member, or of the value of an element
+	valueStart int
+	valueEnd   int
+	prevEnd    int // end of the value preceding the entry
+	nextStart  int // start of the entry following the entry
+	lastEnd    int // end of the last value of the parent
+	size       int // number of entries in the parent
+	close      int // position of the closing bracket of the parent
+}
+
+// locate finds the member with the key given by the JSON string elem, or the element at the index given by the JSON
+// number elem, within the object or array in
+func locate(in, elem []byte) (entry, error) {
+	loc := entry{start: -1, valueStart: -1, valueEnd: -1, prevEnd: -1, nextStart: -1, lastEnd: -1}
+
+	var end int
+	var err error
+	switch elem[0] {
+	case '"':
+		end, err = scanner.Members(in, 0, func(keyStart, keyEnd, valueStart, valueEnd int) error {
+			loc.visit(keyStart, valueStart, valueEnd, sameKey(in[keyStart:keyEnd], elem))
+			return nil
+		})
+	default:
+		i, ierr := toInt(elem)
+		if ierr != nil {
+			return loc, ierr
+		}
+		end, err = scanner.Elements(in, 0, func(start, end int) error {
+			loc.visit(start, start, end, loc.size == i)
+			return nil
+		})
+	}
+	if err != nil {
+		return loc, err
+	}
+
+	loc.close = end - 1
+	return loc, nil
+}
+
+func (loc *entry) visit(start, valueStart, valueEnd int, match bool) {
+	if loc.found && loc.nextStart < 0 {
+		loc.nextStart = start
+	}
+	if match && !loc.found {
+		loc.found = true
+		loc.start, loc.valueStart, loc.valueEnd = start, valueStart, valueEnd
+		loc.prevEnd = loc.lastEnd
+	}
+	loc.lastEnd = valueEnd
+	loc.size++
+}
+
+// sameKey reports whether two JSON string tokens hold the same string
+func sameKey(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	if bytes.IndexByte(a, '\\') < 0 && bytes.IndexByte(b, '\\') < 0 {
+		return false
+	}
+	sa, err := unquote(a)
+	if err != nil {
+		return false
+	}
+	sb, err := unquote(b)
+	return err == nil && bytes.Equal(sa, sb)
+}
+
+// splice replaces in[from:to] with the parts provided
+func splice(in []byte, from, to int, parts ...[]byte) []byte {
+	size := len(in) - (to - from)
+	for _, part := range parts {
+		size += len(part)
+	}
+
+	out := make([]byte, 0, size)
+	out = append(out, in[:from]...)
+	for _, part := range parts {
+		out = append(out, part...)
+	}
+	return append(out, in[to:]...)
+}
+
+// setPath returns a copy of in with the value at p replaced by v. Only the bytes of the value being replaced are
+// rewritten; everything else, including formatting and key order, is copied from in. Missing keys are appended to
+// their object and arrays are padded with nulls as required.
+func setPath(in []byte, p path, v []byte) ([]byte, error) {
+	if len(p) == 0 {
+		return v, nil
+	}
+
+	elem, rest := p[0], p[1:]
+	ke, err := kindOf(elem)
+	if err != nil {
+		return nil, err
+	}
+	kin, err := kindOf(in)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case ke == kindString && kin == kindNull:
+		child, err := setPath(nullValue, rest, v)
+		if err != nil {
+			return nil, err
+		}
+		return splice([]byte("{}"), 1, 1, elem, []byte{':'}, child), nil
+
+	case ke == kindNumber && kin == kindNull:
+		return setIndex([]byte("[]"), elem, rest, v)
+
+	case ke == kindObject && (kin == kindNull || kin == kindArray):
+		return setSlice(in, elem, rest, v)
+
+	case ke == kindString && kin == kindObject:
+		loc, err := locate(in, elem)
+		if err != nil {
+			return nil, err
+		}
+		if loc.found {
+			child, err := setPath(in[loc.valueStart:loc.valueEnd], rest, v)
+			if err != nil {
+				return nil, err
+			}
+			return splice(in, loc.valueStart, loc.valueEnd, child), nil
+		}
+
+		child, err := setPath(nullValue, rest, v)
+		if err != nil {
+			return nil, err
+		}
+		if loc.lastEnd < 0 {
+			return splice(in, loc.close, loc.close, elem, []byte{':'}, child), nil
+		}
+		return splice(in, loc.lastEnd, loc.lastEnd, []byte{','}, elem, []byte{':'}, child), nil
+
+	case ke == kindNumber && kin == kindArray:
+		return setIndex(in, elem, rest, v)
+
+	default:
+		return nil, fmt.Errorf("cannot index %v with %v", kin, ke)
+	}
+}
+
+// setIndex sets the element at the index elem within the array in, padding the array with nulls when the index lies
+// beyond its end
+func setIndex(in, elem []byte, rest path, v []byte) ([]byte, error) {
+	i, err := toInt(elem)
+	if err != nil {
+		return nil, err
+	}
+
+	if i < 0 {
+		n, err := count(in)
+		if err != nil {
+			return nil, err
+		}
+		if i += n; i < 0 {
+			return nil, fmt.Errorf("out of bounds negative array index")
+		}
+		elem = number(float64(i))
+	}
+
+	loc, err := locate(in, elem)
+	if err != nil {
+		return nil, err
+	}
+	if loc.found {
+		child, err := setPath(in[loc.valueStart:loc.valueEnd], rest, v)
+		if err != nil {
+			return nil, err
+		}
+		return splice(in, loc.valueStart, loc.valueEnd, child), nil
+	}
+
+	child, err := setPath(nullValue, rest, v)
+	if err != nil {
+		return nil, err
+	}
+
+	padding := make([]byte, 0, (i-loc.size)*5+len(child)+1)
+	for n := loc.size; n < i; n++ {
+		if n > 0 {
+			padding = append(padding, ',')
+		}
+		padding = append(padding, nullValue...)
+	}
+	if i > 0 {
+		padding = append(padding, ',')
+	}
+	padding = append(padding, child...)
+
+	if loc.lastEnd < 0 {
+		return splice(in, loc.close, loc.close, padding), nil
+	}
+	return splice(in, loc.lastEnd, loc.lastEnd, padding), nil
+}
+
+// setSlice replaces the elements selected by the {"start":..., "end":...} path element elem with the elements of
+// the array produced by setting rest within the current slice
+func setSlice(in, elem []byte, rest path, v []byte) ([]byte, error) {
+	if in[0] == 'n' {
+		in = []byte("[]")
+	}
+
+	start, end, err := sliceBounds(elem)
+	if err != nil {
+		return nil, err
+	}
+	current, err := sliceRange(in, start, end, false)
+	if err != nil {
+		return nil, err
+	}
+	replacement, err := setPath(current, rest, v)
+	if err != nil {
+		return nil, err
+	}
+	if k, err := kindOf(replacement); err != nil || k != kindArray {
+		return nil, fmt.Errorf("a slice of an array can only be assigned another array")
+	}
+
+	from, to, values, err := sliceIndices(in, start, end)
+	if err != nil {
+		return nil, err
+	}
+	inserted, err := elements(replacement)
+	if err != nil {
+		return nil, err
+	}
+
+	result := append(append(values[:from:from], inserted...), values[to:]...)
+	return appendArray(nil, result), nil
+}
+
+// sliceIndices resolves the bounds of a path slice against the array in, returning them with the array's elements
+func sliceIndices(in, start, end []byte) (int, int, [][]byte, error) {
+	values, err := elements(in)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	n := len(values)
+	from, to := 0, n
+	if start[0] != 'n' {
+		if from, err = toInt(start); err != nil {
+			return 0, 0, nil, err
+		}
+	}
+	if end[0] != 'n' {
+		if to, err = toInt(end); err != nil {
+			return 0, 0, nil, err
+		}
+	}
+	if from < 0 {
+		from += n
+	}
+	if to < 0 {
+		to += n
+	}
+	from, to = clamp(from, 0, n), clamp(to, 0, n)
+	if to < from {
+		to = from
+	}
+	return from, to, values, nil
+}
+
+// delPaths returns a copy of in with the values at each of the paths in the JSON array ps removed; paths are
+// deleted longest and last first so that earlier deletions don't shift the paths that remain
+func delPaths(in, ps []byte) ([]byte, error) {
+	if k, err := kindOf(ps); err != nil || k != kindArray {
+		return nil, fmt.Errorf("paths must be specified as an array; got %s", truncate(ps))
+	}
+	paths, err := elements(ps)
+	if err != nil {
+		return nil, err
+	}
+
+	var sortErr error
+	sort.SliceStable(paths, func(i, j int) bool {
+		c, err := compareValues(paths[i], paths[j])
+		if err != nil && sortErr == nil {
+			sortErr = err
+		}
+		return c > 0
+	})
+	if sortErr != nil {
+		return nil, sortErr
+	}
+
+	parsed := make([]path, len(paths))
+	for i, p := range paths {
+		if parsed[i], err = pathElements(p); err != nil {
+			return nil, err
+		}
+	}
+
+	if out, ok := removeEntries(in, parsed); ok {
+		return out, nil
+	}
+	for _, p := range parsed {
+		if in, err = delPath(in, p); err != nil {
+			return nil, err
+		}
+	}
+	return in, nil
+}
+
+// delPath returns a copy of in with the value at p removed along with its key and separating comma
+func delPath(in []byte, p path) ([]byte, error) {
+	if len(p) == 0 {
+		return nullValue, nil
+	}
+	if in[0] == 'n' {
+		return in, nil
+	}
+
+	elem, rest := p[0], p[1:]
+	ke, err := kindOf(elem)
+	if err != nil {
+		return nil, err
+	}
+	kin, err := kindOf(in)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case ke == kindObject && kin == kindArray:
+		return delSlice(in, elem, rest)
+	case ke == kindNumber && kin == kindArray:
+		i, err := toInt(elem)
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 {
+			n, err := count(in)
+			if err != nil {
+				return nil, err
+			}
+			if i += n; i < 0 {
+				return nil, fmt.Errorf("out of bounds negative array index")
+			}
+			elem = number(float64(i))
+		}
+	case ke == kindString && kin == kindObject:
+	default:
+		return nil, fmt.Errorf("cannot delete field at %v index of %v", ke, kin)
+	}
+
+	loc, err := locate(in, elem)
+	if err != nil || !loc.found {
+		return in, err
+	}
+
+	if len(rest) > 0 {
+		child, err := delPath(in[loc.valueStart:loc.valueEnd], rest)
+		if err != nil {
+			return nil, err
+		}
+		return splice(in, loc.valueStart, loc.valueEnd, child), nil
+	}
+
+	switch {
+	case loc.nextStart >= 0:
+		return splice(in, loc.start, loc.nextStart), nil
+	case loc.prevEnd >= 0:
+		return splice(in, loc.prevEnd, loc.valueEnd), nil
+	default:
+		return splice(in, loc.start, loc.valueEnd), nil
+	}
+}
+
+// delSlice removes the elements selected by a path slice, or deletes rest within each of them
+func delSlice(in, elem []byte, rest path) ([]byte, error) {
+	start, end, err := sliceBounds(elem)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) > 0 {
+		current, err := sliceRange(in, start, end, false)
+		if err != nil {
+			return nil, err
+		}
+		replacement, err := delPath(current, rest)
+		if err != nil {
+			return nil, err
+		}
+		return setSlice(in, elem, nil, replacement)
+	}
+
+	from, to, values, err := sliceIndices(in, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return appendArray(nil, append(values[:from:from], values[to:]...)), nil
+}
+
+// removeEntries deletes the object members and array elements located by paths in a single pass over in. It reports
+// false when the paths must be deleted one at a time instead, such as when a path holds a slice or the entries of
+// two paths overlap.
+func removeEntries(in []byte, paths []path) ([]byte, bool) {
+	type parent struct {
+		value []byte
+		elems [][]byte
+	}
+	parents := map[int]*parent{}
+	var order []int
+
+	for _, p := range paths {
+		if len(p) == 0 {
+			return nil, false
+		}
+		for _, elem := range p {
+			if elem[0] == '{' {
+				return nil, false
+			}
+		}
+
+		v := in
+		for _, elem := range p[:len(p)-1] {
+			if v[0] == 'n' {
+				break
+			}
+			var err error
+			if v, err = index(v, elem); err != nil {
+				return nil, false
+			}
+		}
+
+		last := p[len(p)-1]
+		switch {
+		case v[0] == 'n':
+			continue
+		case v[0] == '{' && last[0] == '"':
+		case v[0] == '[' && last[0] != '"':
+		default:
+			return nil, false
+		}
+
+		start := offsetOf(in, v)
+		if start < 0 {
+			return nil, false
+		}
+		pa, ok := parents[start]
+		if !ok {
+			pa = &parent{value: v}
+			parents[start] = pa
+			order = append(order, start)
+		}
+		pa.elems = append(pa.elems, last)
+	}
+
+	var removals [][2]int
+	for _, start := range order {
+		pa := parents[start]
+		ranges, err := entryRemovals(pa.value, pa.elems)
+		if err != nil {
+			return nil, false
+		}
+		for _, r := range ranges {
+			removals = append(removals, [2]int{start + r[0], start + r[1]})
+		}
+	}
+
+	sort.Slice(removals, func(i, j int) bool { return removals[i][0] < removals[j][0] })
+	size := len(in)
+	for i, r := range removals {
+		if i > 0 && r[0] < removals[i-1][1] {
+			return nil, false
+		}
+		size -= r[1] - r[0]
+	}
+
+	out := make([]byte, 0, size)
+	pos := 0
+	for _, r := range removals {
+		out = append(out, in[pos:r[0]]...)
+		pos = r[1]
+	}
+	return append(out, in[pos:]...), true
+}
+
+// entryRemovals returns the byte ranges to cut from the object or array in to remove the entries keyed by elems,
+// including the commas separating them from the entries that remain
+func entryRemovals(in []byte, elems [][]byte) ([][2]int, error) {
+	type span struct {
+		start, end int
+		deleted    bool
+	}
+	var spans []span
+
+	if in[0] == '{' {
+		_, err := scanner.Members(in, 0, func(keyStart, keyEnd, _, valueEnd int) error {
+			deleted := false
+			for _, elem := range elems {
+				if sameKey(in[keyStart:keyEnd], elem) {
+					deleted = true
+					break
+				}
+			}
+			spans = append(spans, span{start: keyStart, end: valueEnd, deleted: deleted})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		_, err := scanner.Elements(in, 0, func(start, end int) error {
+			spans = append(spans, span{start: start, end: end})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, elem := range elems {
+			i, err := toInt(elem)
+			if err != nil {
+				return nil, err
+			}
+			if i < 0 {
+				if i += len(spans); i < 0 {
+					return nil, fmt.Errorf("out of bounds negative array index")
+				}
+			}
+			if i < len(spans) {
+				spans[i].deleted = true
+			}
+		}
+	}
+
+	lastKept := -1
+	for i, s := range spans {
+		if !s.deleted {
+			lastKept = i
+		}
+	}
+
+	// entries followed by one that remains are removed up to the start of the next entry, while the trailing run of
+	// deleted entries is removed from the end of the last entry that remains
+	var ranges [][2]int
+	for i := 0; i < lastKept; i++ {
+		if spans[i].deleted {
+			ranges = append(ranges, [2]int{spans[i].start, spans[i+1].start})
+		}
+	}
+	if lastKept < len(spans)-1 {
+		from := spans[0].start
+		if lastKept >= 0 {
+			from = spans[lastKept].end
+		}
+		ranges = append(ranges, [2]int{from, spans[len(spans)-1].end})
+	}
+	return ranges, nil
+}
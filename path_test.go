@@ -0,0 +1,79 @@
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkPaths(t *testing.B) {
+	op := jq.Must(jq.Parse("paths"))
+	data := []byte(`{"spec":{"containers":[{"image":"a"},{"image":"b"}]}}`)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestPaths(t *testing.T) {
+	doc := `{"spec": {"containers": [{"image": "a"}, {"image": "b", "port": 80}]}, "name": "x"}`
+
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+		HasError bool
+	}{
+		"path dot":          {In: doc, Op: "path(.spec.containers)", Expected: `["spec","containers"]`},
+		"path index":        {In: doc, Op: "path(.spec.containers[1].image)", Expected: `["spec","containers",1,"image"]`},
+		"path missing":      {In: `{}`, Op: "path(.a[0].b)", Expected: `["a",0,"b"]`},
+		"path iterate":      {In: `{"a":[1,2]}`, Op: "[path(.a[])]", Expected: `[["a",0],["a",1]]`},
+		"path slice":        {In: `[1,2,3]`, Op: "path(.[1:])", Expected: `[{"start":1,"end":null}]`},
+		"path select":       {In: `[1,5,2]`, Op: "[path(.[] | select(. > 1))]", Expected: `[[1],[2]]`},
+		"path recurse":      {In: `{"a":[1]}`, Op: "[path(..)]", Expected: `[[],["a"],["a",0]]`},
+		"path alternative":  {In: `{"a":null,"b":1}`, Op: "path(.a // .b)", Expected: `["b"]`},
+		"path type filter":  {In: `{"a":1,"b":"x"}`, Op: "[path(.[] | numbers)]", Expected: `[["a"]]`},
+		"path identity":     {In: `1`, Op: "path(.)", Expected: `[]`},
+		"path not a path":   {In: `{"a":1}`, Op: "path(.a + 1)", HasError: true},
+		"path of value":     {In: `{"a":1}`, Op: "path(1)", HasError: true},
+		"paths":             {In: `{"a":[1],"b":2}`, Op: "[paths]", Expected: `[["a"],["a",0],["b"]]`},
+		"paths filter":      {In: doc, Op: `[paths(type == "number")]`, Expected: `[["spec","containers",1,"port"]]`},
+		"leaf paths":        {In: `{"a":[1,{"b":null}],"c":"d"}`, Op: "[leaf_paths]", Expected: `[["a",0],["c"]]`},
+		"getpath":           {In: doc, Op: `getpath(["spec","containers",1,"image"])`, Expected: `"b"`},
+		"getpath missing":   {In: doc, Op: `getpath(["spec","volumes",0])`, Expected: `null`},
+		"getpath invalid":   {In: doc, Op: `getpath(["name","x"])`, HasError: true},
+		"getpath not array": {In: doc, Op: `getpath("name")`, HasError: true},
+		"setpath":           {In: doc, Op: `setpath(["spec","containers",0,"image"]; "c")`, Expected: `{"spec": {"containers": [{"image": "c"}, {"image": "b", "port": 80}]}, "name": "x"}`},
+		"setpath new key":   {In: `{"a": 1}`, Op: `setpath(["b","c"]; 2)`, Expected: `{"a": 1,"b":{"c":2}}`},
+		"setpath pad":       {In: `null`, Op: `setpath([2]; 1)`, Expected: `[null,null,1]`},
+		"setpath root":      {In: `{"a":1}`, Op: `setpath([]; 2)`, Expected: `2`},
+		"setpath slice":     {In: `[1,2,3]`, Op: `setpath([{"start":1,"end":2}]; ["x","y"])`, Expected: `[1,"x","y",3]`},
+		"setpath invalid":   {In: `{"a":1}`, Op: `setpath([0]; 1)`, HasError: true},
+		"delpaths":          {In: doc, Op: `delpaths([["spec","containers",0],["name"]])`, Expected: `{"spec": {"containers": [{"image": "b", "port": 80}]}}`},
+		"delpaths indices":  {In: `[1,2,3,4]`, Op: `delpaths([[0],[2]])`, Expected: `[2,4]`},
+		"delpaths missing":  {In: `{"a":1}`, Op: `delpaths([["b","c"]])`, Expected: `{"a":1}`},
+		"delpaths root":     {In: `{"a":1}`, Op: `delpaths([[]])`, Expected: `null`},
+		"delpaths slice":    {In: `[1,2,3,4]`, Op: `delpaths([[{"start":1,"end":3}]])`, Expected: `[1,4]`},
+		"pick":              {In: doc, Op: `pick(.spec.containers[1].image)`, Expected: `{"spec":{"containers":[null,{"image":"b"}]}}`},
+		"pick several":      {In: `{"a":1,"b":{"c":2,"d":3},"e":4}`, Op: `pick(.a, .b.c)`, Expected: `{"a":1,"b":{"c":2}}`},
+		"pick missing":      {In: `{"a":1}`, Op: `pick(.b)`, Expected: `{"b":null}`},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op)
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}
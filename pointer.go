@@ -0,0 +1,190 @@
+package jq
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bubunyo/go-jq/scanner"
+)
+
+// pointerToken is a reference token of a JSON pointer, held both as the raw content of the key it matches and as the
+// array index it denotes, or -1 when it isn't one
+type pointerToken struct {
+	name  string
+	raw   []byte
+	index int
+}
+
+var (
+	escapePointer   = strings.NewReplacer("~", "~0", "/", "~1")
+	unescapePointer = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+// parsePointer splits an RFC 6901 JSON pointer into its reference tokens, unescaping ~1 and ~0
+func parsePointer(pointer string) ([]pointerToken, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON pointer %q; it must be empty or begin with /", pointer)
+	}
+
+	var tokens []pointerToken
+	for _, s := range strings.Split(pointer[1:], "/") {
+		for i := 0; i < len(s); i++ {
+			if s[i] == '~' && (i+1 == len(s) || s[i+1] != '0' && s[i+1] != '1') {
+				return nil, fmt.Errorf("invalid JSON pointer %q; ~ must be followed by 0 or 1", pointer)
+			}
+		}
+		name := unescapePointer.Replace(s)
+
+		key := quote(name)
+		tokens = append(tokens, pointerToken{name: name, raw: key[1 : len(key)-1], index: arrayIndex(name)})
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses a reference token as an array index, which is 0 or digits without a leading zero; it returns -1
+// for any other token, including -, which refers past the last element
+func arrayIndex(s string) int {
+	if s == "" || len(s) > 1 && s[0] == '0' {
+		return -1
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return -1
+		}
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return -1
+	}
+	return i
+}
+
+// Pointer extracts the value an RFC 6901 JSON pointer such as /spec/containers/0/image refers to; the empty pointer
+// refers to the whole document. The value is located in a single descent of the document and returned without being
+// copied, and a member or element that doesn't exist is an error.
+func Pointer(pointer string) OpFunc {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return func([]byte) ([]byte, error) { return nil, err }
+	}
+
+	return func(in []byte) ([]byte, error) {
+		if len(tokens) == 0 {
+			return in, nil
+		}
+
+		pos := 0
+		for i, t := range tokens {
+			k, err := kindOf(in[pos:])
+			if err != nil {
+				return nil, err
+			}
+
+			switch {
+			case k == kindObject:
+				pos, err = scanner.SeekKey(in, pos, t.raw)
+			case k == kindArray && t.index >= 0:
+				pos, err = scanner.SeekIndex(in, pos, t.index)
+			case k == kindArray:
+				return nil, fmt.Errorf("cannot index array with %q at %v", t.name, pointerPrefix(pointer, i))
+			default:
+				return nil, fmt.Errorf("cannot index %v with %q at %v", k, t.name, pointerPrefix(pointer, i))
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w at %v", err, pointerPrefix(pointer, i+1))
+			}
+		}
+
+		end, err := scanner.Any(in, pos)
+		if err != nil {
+			return nil, err
+		}
+		return in[pos:end], nil
+	}
+}
+
+// pointerPrefix returns the part of pointer made of its first n reference tokens
+func pointerPrefix(pointer string, n int) string {
+	end := 0
+	for ; n > 0; n-- {
+		next := strings.IndexByte(pointer[end+1:], '/')
+		if next < 0 {
+			return pointer
+		}
+		end += next + 1
+	}
+	if end == 0 {
+		return `""`
+	}
+	return pointer[:end]
+}
+
+// ToPointer converts a jq path, a JSON array of keys and indices such as path(expr) produces, into a JSON pointer
+func ToPointer(path []byte) (string, error) {
+	k, err := kindOf(path)
+	if err != nil {
+		return "", err
+	}
+	if k != kindArray {
+		return "", fmt.Errorf("path must be an array, not %v (%s)", k, truncate(path))
+	}
+
+	var b strings.Builder
+	err = eachElement(path, func(_ int, v []byte) error {
+		k, err := kindOf(v)
+		if err != nil {
+			return err
+		}
+
+		switch k {
+		case kindString:
+			s, err := decodeString(v)
+			if err != nil {
+				return err
+			}
+			b.WriteByte('/')
+			escapePointer.WriteString(&b, s)
+		case kindNumber:
+			f, err := toFloat(v)
+			if err != nil {
+				return err
+			}
+			if f < 0 || f != float64(int64(f)) {
+				return fmt.Errorf("%s cannot be represented in a JSON pointer", v)
+			}
+			b.WriteByte('/')
+			b.WriteString(strconv.FormatInt(int64(f), 10))
+		default:
+			return fmt.Errorf("%v (%s) cannot be represented in a JSON pointer", k, truncate(v))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// FromPointer converts a JSON pointer into a jq path for use with getpath, setpath and delpaths. Tokens that are
+// array indices become numbers and every other token a string; a pointer alone can't tell whether 0 names an element
+// or a member, so the path assumes an element.
+func FromPointer(pointer string) ([]byte, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	path := make([][]byte, len(tokens))
+	for i, t := range tokens {
+		if t.index >= 0 {
+			path[i] = strconv.AppendInt(nil, int64(t.index), 10)
+		} else {
+			path[i] = quote(t.name)
+		}
+	}
+	return appendArray(nil, path), nil
+}
@@ -0,0 +1,146 @@
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/bubunyo/go-jq/scanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkPointer(t *testing.B) {
+	op := jq.Pointer("/spec/containers/1/image")
+	data := []byte(`{"kind":"Pod","spec":{"containers":[{"name":"a","image":"a:1"},{"name":"b","image":"b:2"}]}}`)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestPointer(t *testing.T) {
+	// the example document of RFC 6901
+	doc := `{"foo": ["bar", "baz"], "": 0, "a/b": 1, "c%d": 2, "e^f": 3, "g|h": 4, "i\\j": 5, "k\"l": 6, " ": 7, "m~n": 8}`
+
+	testCases := map[string]struct {
+		In       string
+		Pointer  string
+		Expected string
+		HasError bool
+	}{
+		"whole document":   {In: `{"a": 1}`, Pointer: ``, Expected: `{"a": 1}`},
+		"member":           {In: doc, Pointer: `/foo`, Expected: `["bar", "baz"]`},
+		"element":          {In: doc, Pointer: `/foo/0`, Expected: `"bar"`},
+		"empty key":        {In: doc, Pointer: `/`, Expected: `0`},
+		"escaped slash":    {In: doc, Pointer: `/a~1b`, Expected: `1`},
+		"percent":          {In: doc, Pointer: `/c%d`, Expected: `2`},
+		"caret":            {In: doc, Pointer: `/e^f`, Expected: `3`},
+		"bar":              {In: doc, Pointer: `/g|h`, Expected: `4`},
+		"backslash":        {In: doc, Pointer: `/i\j`, Expected: `5`},
+		"quote":            {In: doc, Pointer: `/k"l`, Expected: `6`},
+		"space":            {In: doc, Pointer: `/ `, Expected: `7`},
+		"escaped tilde":    {In: doc, Pointer: `/m~0n`, Expected: `8`},
+		"nested":           {In: `{"spec": {"containers": [{"image": "a:1"}, {"image": "b:2"}]}}`, Pointer: `/spec/containers/1/image`, Expected: `"b:2"`},
+		"numeric key":      {In: `{"0": "zero"}`, Pointer: `/0`, Expected: `"zero"`},
+		"missing key":      {In: doc, Pointer: `/junk`, HasError: true},
+		"out of bounds":    {In: doc, Pointer: `/foo/2`, HasError: true},
+		"past the end":     {In: doc, Pointer: `/foo/-`, HasError: true},
+		"leading zero":     {In: doc, Pointer: `/foo/01`, HasError: true},
+		"scalar":           {In: doc, Pointer: `/foo/0/x`, HasError: true},
+		"no leading slash": {In: doc, Pointer: `foo`, HasError: true},
+		"invalid escape":   {In: doc, Pointer: `/m~2n`, HasError: true},
+		"trailing tilde":   {In: doc, Pointer: `/m~`, HasError: true},
+		"invalid document": {In: `{"a": `, Pointer: `/a`, HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			data, err := jq.Pointer(tc.Pointer).Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+
+	t.Run("key not found", func(t *testing.T) {
+		_, err := jq.Pointer("/a/b").Apply([]byte(`{"a": {}}`))
+		assert.ErrorIs(t, err, scanner.ErrKeyNotFound)
+		assert.EqualError(t, err, "key not found at /a/b")
+	})
+}
+
+func TestToPointer(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Expected string
+		HasError bool
+	}{
+		"empty":     {In: `[]`, Expected: ``},
+		"keys":      {In: `["spec", "containers", 0, "image"]`, Expected: `/spec/containers/0/image`},
+		"escapes":   {In: `["a/b", "m~n", "~1"]`, Expected: `/a~1b/m~0n/~01`},
+		"empty key": {In: `[""]`, Expected: `/`},
+		"unicode":   {In: `["café"]`, Expected: `/café`},
+		"negative":  {In: `[-1]`, HasError: true},
+		"fraction":  {In: `[1.5]`, HasError: true},
+		"slice":     {In: `[{"start": 1, "end": null}]`, HasError: true},
+		"null":      {In: `[null]`, HasError: true},
+		"object":    {In: `{"a": 1}`, HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			pointer, err := jq.ToPointer([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, pointer)
+		})
+	}
+}
+
+func TestFromPointer(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Expected string
+		HasError bool
+	}{
+		"empty":        {In: ``, Expected: `[]`},
+		"keys":         {In: `/spec/containers/0/image`, Expected: `["spec","containers",0,"image"]`},
+		"escapes":      {In: `/a~1b/m~0n/~01`, Expected: `["a/b","m~n","~1"]`},
+		"empty key":    {In: `/`, Expected: `[""]`},
+		"leading zero": {In: `/01/-`, Expected: `["01","-"]`},
+		"invalid":      {In: `a`, HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			path, err := jq.FromPointer(tc.In)
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(path))
+
+			pointer, err := jq.ToPointer(path)
+			require.NoError(t, err)
+			assert.Equal(t, tc.In, pointer)
+		})
+	}
+
+	t.Run("setpath", func(t *testing.T) {
+		path, err := jq.FromPointer("/spec/containers/0/image")
+		require.NoError(t, err)
+		op, err := jq.Parse(`setpath(` + string(path) + `; "b:2")`)
+		require.NoError(t, err)
+		data, err := op.Apply([]byte(`{"spec": {"containers": [{"image": "a:1"}]}}`))
+		require.NoError(t, err)
+		assert.Equal(t, `{"spec": {"containers": [{"image": "b:2"}]}}`, string(data))
+	})
+}
@@ -0,0 +1,38 @@
+package jq
+
+import (
+	_ "embed"
+	"fmt"
+)
+
+// preludeSource holds the builtins defined in jq rather than Go
+//
+//go:embed prelude.jq
+var preludeSource string
+
+// prelude holds the definitions of preludeSource keyed by name and arity, such as "range/3"
+var prelude map[string]*funcDef
+
+// loadPrelude parses the prelude at package init, once every Go builtin has been defined, so that the prelude is linked
+// against them
+func loadPrelude() {
+	prelude = map[string]*funcDef{}
+
+	tokens, err := tokenize(preludeSource, 0)
+	if err != nil {
+		panic(fmt.Errorf("invalid prelude; %v", err))
+	}
+	o := defaultOptions()
+	p := parser{tokens: tokens, opts: &o, scope: append([]string(nil), predefined...)}
+	for p.isKeyword("def") {
+		d, err := p.parseDef()
+		if err != nil {
+			panic(fmt.Errorf("invalid prelude; %v", err))
+		}
+		optimizeDef(d)
+		prelude[d.String()] = d
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		panic(fmt.Errorf("invalid prelude; %v", p.errorf(t, "unexpected %v", t)))
+	}
+}
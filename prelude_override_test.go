@@ -0,0 +1,163 @@
+package jq
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// referenceDefs parses testdata/reference.jq, which defines in jq the builtins implemented in Go
+func referenceDefs(t *testing.T) []*funcDef {
+	src, err := os.ReadFile("testdata/reference.jq")
+	require.NoError(t, err)
+	tokens, err := tokenize(string(src), 0)
+	require.NoError(t, err)
+
+	o := defaultOptions()
+	p := parser{tokens: tokens, opts: &o, scope: append([]string(nil), predefined...)}
+	var defs []*funcDef
+	for p.isKeyword("def") {
+		d, err := p.parseDef()
+		require.NoError(t, err)
+		defs = append(defs, d)
+	}
+	require.Equal(t, tokEOF, p.peek().kind)
+	return defs
+}
+
+// parseReference parses selector with the reference definitions taking precedence over Go builtins
+func parseReference(t *testing.T, defs []*funcDef, selector string) node {
+	tokens, err := tokenize(selector, 0)
+	require.NoError(t, err)
+	o := defaultOptions()
+	p := parser{tokens: tokens, opts: &o, scope: append([]string(nil), predefined...), modules: newModuleLoader(&o), origin: mainLocation}
+	for _, d := range defs {
+		p.funcs = append(p.funcs, scopedFunc{name: d.name, def: d})
+	}
+	root, err := p.parse()
+	require.NoError(t, err)
+	return root
+}
+
+// native reports whether a definition is shadowed by a builtin implemented in Go
+func native(d *funcDef) bool {
+	if _, ok := builtins[d.String()]; ok {
+		return true
+	}
+	_, ok := namedOperations[d.name]
+	return ok && len(d.params) == 0
+}
+
+func evalAll(t *testing.T, root node, in string) ([]string, error) {
+	q := &Query{root: root, opts: defaultOptions()}
+	e := q.env(&inputState{docs: InputsFromSlice()})
+
+	var values []string
+	err := root.eval(e, []byte(in), func(v []byte) error {
+		var buf bytes.Buffer
+		require.NoError(t, json.Compact(&buf, v))
+		values = append(values, buf.String())
+		return nil
+	})
+	return values, err
+}
+
+// TestPreludeOverrides checks that every builtin implemented in Go behaves as its reference definition does
+func TestPreludeOverrides(t *testing.T) {
+	defs := referenceDefs(t)
+	doc := `{"a": [1, {"b": null, "c": false}], "d": "x", "e": {}}`
+	testCases := []struct {
+		Op string
+		In string
+	}{
+		{`map(. * 2)`, `[1, 2]`},
+		{`map(.a)`, `{"x": {"a": 1}}`},
+		{`select(.a)`, `{"a": false}`},
+		{`[.[] | select(. > 1)]`, `[1, 2, 3]`},
+		{`[recurse]`, doc},
+		{`[recurse(.[]?)]`, doc},
+		{`[recurse(if . < 3 then . + 1 else empty end)]`, `0`},
+		{`[recurse(. * .; . < 100)]`, `2`},
+		{`[.[] | values]`, `[1, null, "a"]`},
+		{`[.[] | nulls]`, `[1, null, "a"]`},
+		{`[.[] | booleans]`, `[1, true, "a"]`},
+		{`[.[] | numbers]`, `[1, true, "a"]`},
+		{`[.[] | strings]`, `[1, true, "a"]`},
+		{`[.[] | arrays]`, `[[], {}, 1]`},
+		{`[.[] | objects]`, `[[], {}, 1]`},
+		{`[.[] | iterables]`, `[[], {}, 1]`},
+		{`[.[] | scalars]`, `[[], {}, 1, null]`},
+		{`add`, `[1, 2, 3]`},
+		{`add`, `["a", "b"]`},
+		{`add`, `[]`},
+		{`add`, `[{"a": 1}, {"b": 2}]`},
+		{`map(abs)`, `[-1, 2, -0.5, -0, 1.50]`},
+		{`abs`, `"abc"`},
+		{`abs`, `null`},
+		{`to_entries`, `{"a": 1, "b": [2]}`},
+		{`from_entries`, `[{"key": "a", "value": 1}, {"k": "b", "v": 2}, {"name": 3, "value": null}]`},
+		{`walk(if type == "number" then . + 1 else . end)`, `[1, {"a": 2, "b": [3]}]`},
+		{`walk(if type == "array" then sort else . end)`, `{"a": [3, 1, 2]}`},
+		{`first(.[])`, `[3, 4]`},
+		{`[first(empty)]`, `null`},
+		{`all`, `[true, 1]`},
+		{`any`, `[false, null]`},
+		{`all(. > 1)`, `[1, 2]`},
+		{`any(. > 1)`, `[1, 2]`},
+		{`all(.[]; . > 0)`, `[1, 2]`},
+		{`any(.[]; . > 5)`, `[1, 2]`},
+		{`[range(1; 4)]`, `null`},
+		{`[range(0, 1; 3, 4)]`, `null`},
+		{`INDEX(.id)`, `[{"id": 1}, {"id": "x"}]`},
+		{`INDEX(.[]; .id)`, `[{"id": 1}, {"id": 1, "v": 2}]`},
+		{`[tostream]`, doc},
+		{`[tostream]`, `[]`},
+		{`[tostream]`, `3`},
+		{`fromstream(tostream)`, doc},
+		{`[1 | truncate_stream([[0], 1], [[1, 0], 2], [[1, 0]], [[1]])]`, `null`},
+	}
+
+	covered := map[string]bool{}
+	for _, tc := range testCases {
+		t.Run(tc.Op, func(t *testing.T) {
+			q, err := Parse(tc.Op)
+			require.NoError(t, err)
+			native := q.(*Query).root
+
+			want, werr := evalAll(t, parseReference(t, defs, tc.Op), tc.In)
+			got, gerr := evalAll(t, native, tc.In)
+			if werr != nil || gerr != nil {
+				assert.Equal(t, werr != nil, gerr != nil, "reference %v, native %v", werr, gerr)
+				return
+			}
+			assert.Equal(t, want, got)
+		})
+
+		tokens, err := tokenize(tc.Op, 0)
+		require.NoError(t, err)
+		for _, tok := range tokens {
+			if tok.kind == tokIdent {
+				covered[tok.text] = true
+			}
+		}
+	}
+
+	for _, d := range defs {
+		if !native(d) {
+			t.Errorf("%v isn't implemented in Go; its definition belongs in the prelude", d)
+		}
+		if !covered[d.name] && !strings.HasPrefix(d.name, "_") {
+			t.Errorf("%v is implemented in Go but its reference definition is not tested", d)
+		}
+	}
+	for _, d := range prelude {
+		if native(d) {
+			t.Errorf("%v is implemented in Go; its definition belongs in testdata/reference.jq", d)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkPrelude(t *testing.B) {
+	op := jq.Must(jq.Parse(`[limit(5; .[] | select(. % 2 == 0))] | sort_by(-.) | join(",")`))
+	data := []byte(`[1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16,17,18,19,20]`)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestPrelude(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+		HasError bool
+	}{
+		"range":              {In: `null`, Op: `[range(4)]`, Expected: `[0,1,2,3]`},
+		"range bounds":       {In: `null`, Op: `[range(2; 5)]`, Expected: `[2,3,4]`},
+		"range multi":        {In: `null`, Op: `[range(0, 1; 3, 4)]`, Expected: `[0,1,2,0,1,2,3,1,2,1,2,3]`},
+		"range by":           {In: `null`, Op: `[range(0; 10; 3)]`, Expected: `[0,3,6,9]`},
+		"range by negative":  {In: `null`, Op: `[range(5; 0; -2)]`, Expected: `[5,3,1]`},
+		"range by zero":      {In: `null`, Op: `[range(0; 10; 0)]`, Expected: `[]`},
+		"range not a number": {In: `null`, Op: `[range("a"; 2)]`, HasError: true},
+		"limit":              {In: `[1, 2, 3, 4]`, Op: `[limit(2; .[])]`, Expected: `[1,2]`},
+		"limit zero":         {In: `[1, 2, 3, 4]`, Op: `[limit(0; .[])]`, Expected: `[]`},
+		"limit infinite":     {In: `1`, Op: `[limit(3; repeat(. * 2))]`, Expected: `[1,2,4]`},
+		"first":              {In: `[3, 4]`, Op: `first`, Expected: `3`},
+		"first stream":       {In: `[3, 4]`, Op: `first(.[])`, Expected: `3`},
+		"first empty":        {In: `[]`, Op: `[first(.[])]`, Expected: `[]`},
+		"first path":         {In: `{"a": [1, 2]}`, Op: `path(first(.a[]))`, Expected: `["a",0]`},
+		"last":               {In: `[3, 4]`, Op: `last`, Expected: `4`},
+		"last stream":        {In: `[3, 4]`, Op: `last(.[])`, Expected: `4`},
+		"nth":                {In: `[3, 4, 5]`, Op: `nth(1)`, Expected: `4`},
+		"nth stream":         {In: `[3, 4, 5]`, Op: `nth(2; .[])`, Expected: `5`},
+		"nth negative":       {In: `[3, 4, 5]`, Op: `nth(-1; .[])`, HasError: true},
+		"isempty":            {In: `[]`, Op: `isempty(.[])`, Expected: `true`},
+		"isempty not":        {In: `[1]`, Op: `isempty(.[], error("x"))`, Expected: `false`},
+		"until":              {In: `1`, Op: `until(. > 100; . * 2)`, Expected: `128`},
+		"while":              {In: `1`, Op: `[while(. < 20; . * 3)]`, Expected: `[1,3,9]`},
+		"repeat":             {In: `[1]`, Op: `[limit(3; repeat(. + [1]))]`, Expected: `[[1],[1,1],[1,1,1]]`},
+		"join":               {In: `["a", 1, null, true]`, Op: `join("-")`, Expected: `"a-1--true"`},
+		"join empty":         {In: `[]`, Op: `join(",")`, Expected: `""`},
+		"join object":        {In: `[{}, [1]]`, Op: `join(",")`, Expected: `"{},[1]"`},
+		"flatten":            {In: `[1,[2,[3,[4]]]]`, Op: `flatten`, Expected: `[1,2,3,4]`},
+		"flatten depth":      {In: `[1,[2,[3,[4]]]]`, Op: `flatten(1)`, Expected: `[1,2,[3,[4]]]`},
+		"flatten negative":   {In: `[1]`, Op: `flatten(-1)`, HasError: true},
+		"sort":               {In: `[3,"a",null,[1],{"a":1},true,false,1]`, Op: `sort`, Expected: `[null,false,true,1,3,"a",[1],{"a":1}]`},
+		"sort objects":       {In: `[{"b":1},{"a":2},{"a":1}]`, Op: `sort`, Expected: `[{"a":1},{"a":2},{"b":1}]`},
+		"sort not an array":  {In: `{"a": 1}`, Op: `sort`, HasError: true},
+		"sort_by":            {In: `[{"a":2,"b":1},{"a":1,"b":2},{"a":1,"b":1}]`, Op: `sort_by(.a)`, Expected: `[{"a":1,"b":2},{"a":1,"b":1},{"a":2,"b":1}]`},
+		"sort_by keys":       {In: `[{"a":2,"b":1},{"a":1,"b":2},{"a":1,"b":1}]`, Op: `sort_by(.a, .b)`, Expected: `[{"a":1,"b":1},{"a":1,"b":2},{"a":2,"b":1}]`},
+		"group_by":           {In: `[1, 2, 3, 4, 5]`, Op: `group_by(. % 2)`, Expected: `[[2,4],[1,3,5]]`},
+		"unique":             {In: `[2, 1, 2, "a", 1]`, Op: `unique`, Expected: `[1,2,"a"]`},
+		"unique_by":          {In: `["ab", "c", "de", "f"]`, Op: `unique_by(length)`, Expected: `["c","ab"]`},
+		"min":                {In: `[3, 1, 2]`, Op: `min`, Expected: `1`},
+		"max":                {In: `[3, 1, 2]`, Op: `max`, Expected: `3`},
+		"min empty":          {In: `[]`, Op: `min`, Expected: `null`},
+		"min_by":             {In: `[{"a": 1, "i": 0}, {"a": 0, "i": 1}, {"a": 0, "i": 2}]`, Op: `min_by(.a) | .i`, Expected: `1`},
+		"max_by":             {In: `[{"a": 1, "i": 0}, {"a": 1, "i": 1}, {"a": 0, "i": 2}]`, Op: `max_by(.a) | .i`, Expected: `1`},
+		"combinations":       {In: `[[1, 2], [3, 4]]`, Op: `[combinations]`, Expected: `[[1,3],[1,4],[2,3],[2,4]]`},
+		"combinations n":     {In: `[0, 1]`, Op: `[combinations(2)]`, Expected: `[[0,0],[0,1],[1,0],[1,1]]`},
+		"toarray":            {In: `[1]`, Op: `[.[], . | toarray]`, Expected: `[[1],[1]]`},
+		"add stream":         {In: `{"a": [1, 2, 3]}`, Op: `add(.a[])`, Expected: `6`},
+		"add stream empty":   {In: `null`, Op: `add(empty)`, Expected: `null`},
+		"finites":            {In: `[1, "a"]`, Op: `[.[] | numbers | finites]`, Expected: `[1]`},
+		"normals":            {In: `[1, 0]`, Op: `[.[] | normals]`, Expected: `[1]`},
+		"builtin in def":     {In: `[3, 1, 2]`, Op: `def sort: map(-.); sort`, Expected: `[-3,-1,-2]`},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op)
+			if tc.HasError && err != nil {
+				return
+			}
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}
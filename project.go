@@ -0,0 +1,223 @@
+package jq
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/bubunyo/go-jq/scanner"
+)
+
+// shape is a selection set of a projection: the members to keep, keyed by name, each with the selection set to apply
+// to its value, or nil to keep the value whole
+type shape map[string]shape
+
+// Project keeps only the members a GraphQL-style shape such as { user { name email address { city } } items { id } }
+// selects, preserving their nesting. A selection set applies to every element of an array, members the input doesn't
+// have are left out, and scalars are kept as they are. The input is walked once and the selected keys and values are
+// copied from it verbatim.
+//
+// Fields are names or, for keys that aren't names, JSON strings such as "app.kubernetes.io/name"; commas are optional
+// and # begins a comment, as in GraphQL. The outer braces may be left out. An error is returned if the shape can't be
+// parsed.
+func Project(shape string) (OpFunc, error) {
+	s, err := parseShape(shape)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(in []byte) ([]byte, error) {
+		in = bytes.TrimSpace(in)
+		if len(in) == 0 {
+			return nil, fmt.Errorf("unexpected EOF")
+		}
+		return s.project(make([]byte, 0, len(in)), in)
+	}, nil
+}
+
+// project appends the parts of v the shape selects to out
+func (s shape) project(out, v []byte) ([]byte, error) {
+	var err error
+	switch v[0] {
+	case '{':
+		out = append(out, '{')
+		n := 0
+		_, err = scanner.Members(v, 0, func(keyStart, keyEnd, valueStart, valueEnd int) error {
+			key := v[keyStart:keyEnd]
+			sub, ok, err := s.lookup(key)
+			if err != nil || !ok {
+				return err
+			}
+
+			if n > 0 {
+				out = append(out, ',')
+			}
+			n++
+			out = append(append(out, key...), ':')
+			if sub == nil {
+				out = append(out, v[valueStart:valueEnd]...)
+				return nil
+			}
+			out, err = sub.project(out, v[valueStart:valueEnd])
+			return err
+		})
+		out = append(out, '}')
+	case '[':
+		out = append(out, '[')
+		n := 0
+		_, err = scanner.Elements(v, 0, func(start, end int) error {
+			if n > 0 {
+				out = append(out, ',')
+			}
+			n++
+			out, err = s.project(out, v[start:end])
+			return err
+		})
+		out = append(out, ']')
+	default:
+		out = append(out, v...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// lookup finds the selection of the member with the quoted key given
+func (s shape) lookup(key []byte) (shape, bool, error) {
+	name, err := unquote(key)
+	if err != nil {
+		return nil, false, err
+	}
+	sub, ok := s[string(name)]
+	return sub, ok, nil
+}
+
+// shapeParser parses the selection sets of a shape
+type shapeParser struct {
+	in  string
+	pos int
+}
+
+func parseShape(in string) (shape, error) {
+	p := shapeParser{in: in}
+	p.skip()
+
+	braced := p.peek() == '{'
+	if braced {
+		p.pos++
+	}
+	s, err := p.parseSelections(braced)
+	if err != nil {
+		return nil, err
+	}
+	if p.skip(); p.pos < len(p.in) {
+		return nil, p.errorf("unexpected %q", p.in[p.pos])
+	}
+	return s, nil
+}
+
+func (p *shapeParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid shape %q at position %v: %v", p.in, p.pos, fmt.Sprintf(format, args...))
+}
+
+// peek returns the next byte, or 0 at the end of the shape
+func (p *shapeParser) peek() byte {
+	if p.pos == len(p.in) {
+		return 0
+	}
+	return p.in[p.pos]
+}
+
+// skip skips whitespace, commas and comments, none of which are significant
+func (p *shapeParser) skip() {
+	for p.pos < len(p.in) {
+		switch p.in[p.pos] {
+		case ' ', '\t', '\n', '\r', ',':
+			p.pos++
+		case '#':
+			for p.pos < len(p.in) && p.in[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// parseSelections parses the fields of a selection set, up to its closing brace or, when it isn't braced, the end of
+// the shape. A field selected twice keeps the union of its selections.
+func (p *shapeParser) parseSelections(braced bool) (shape, error) {
+	s := shape{}
+	for {
+		p.skip()
+		switch c := p.peek(); {
+		case c == 0 && braced:
+			return nil, p.errorf("missing }")
+		case c == 0 || c == '}' && braced:
+			if len(s) == 0 {
+				return nil, p.errorf("empty selection set")
+			}
+			if braced {
+				p.pos++
+			}
+			return s, nil
+		}
+
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+
+		var sub shape
+		if p.skip(); p.peek() == '{' {
+			p.pos++
+			if sub, err = p.parseSelections(true); err != nil {
+				return nil, err
+			}
+		}
+
+		if prev, ok := s[name]; ok {
+			sub = prev.merge(sub)
+		}
+		s[name] = sub
+	}
+}
+
+// parseName parses a field, either a GraphQL name or a JSON string
+func (p *shapeParser) parseName() (string, error) {
+	start := p.pos
+	switch c := p.peek(); {
+	case c == '"':
+		end, err := scanner.String([]byte(p.in), p.pos)
+		if err != nil {
+			return "", p.errorf("%v", err)
+		}
+		p.pos = end
+		name, err := unquote([]byte(p.in[start:end]))
+		if err != nil {
+			return "", p.errorf("invalid field %v", p.in[start:end])
+		}
+		return string(name), nil
+	case isIdentStart(c):
+		for p.pos < len(p.in) && (isIdentStart(p.in[p.pos]) || p.in[p.pos] >= '0' && p.in[p.pos] <= '9') {
+			p.pos++
+		}
+		return p.in[start:p.pos], nil
+	default:
+		return "", p.errorf("expected a field, found %q", c)
+	}
+}
+
+// merge returns the union of two selections of the same field; keeping a value whole selects everything
+func (s shape) merge(other shape) shape {
+	if s == nil || other == nil {
+		return nil
+	}
+	for name, sub := range other {
+		if prev, ok := s[name]; ok {
+			sub = prev.merge(sub)
+		}
+		s[name] = sub
+	}
+	return s
+}
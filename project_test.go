@@ -0,0 +1,74 @@
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkProject(t *testing.B) {
+	op := jq.Must(jq.Project(`{ user { name email address { city } } items { id } }`))
+	data := []byte(`{"user":{"id":7,"name":"Ama","email":"ama@example.com","address":{"city":"Accra","street":"Oxford St"},"roles":["admin"]},` +
+		`"items":[{"id":1,"price":10,"tags":["a"]},{"id":2,"price":20,"tags":[]}],"meta":{"page":1}}`)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestProject(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Shape    string
+		Expected string
+		BadShape bool
+		HasError bool
+	}{
+		"fields":          {In: `{"a":1,"b":2,"c":3}`, Shape: `{ a c }`, Expected: `{"a":1,"c":3}`},
+		"input order":     {In: `{"a":1,"b":2}`, Shape: `{ b a }`, Expected: `{"a":1,"b":2}`},
+		"nested":          {In: `{"user":{"name":"Ama","email":"a@b","age":30},"x":1}`, Shape: `{ user { name email } }`, Expected: `{"user":{"name":"Ama","email":"a@b"}}`},
+		"whole value":     {In: `{"user":{"name":"Ama", "tags": [1, 2]}}`, Shape: `{ user }`, Expected: `{"user":{"name":"Ama", "tags": [1, 2]}}`},
+		"array":           {In: `{"items":[{"id":1,"p":2},{"id":2}]}`, Shape: `{ items { id } }`, Expected: `{"items":[{"id":1},{"id":2}]}`},
+		"top level array": {In: `[{"id":1,"p":2},[{"id":2,"p":3}]]`, Shape: `{ id }`, Expected: `[{"id":1},[{"id":2}]]`},
+		"missing members": {In: `{"user":{"name":"Ama"}}`, Shape: `{ user { name email } items { id } }`, Expected: `{"user":{"name":"Ama"}}`},
+		"scalars kept":    {In: `{"user":null,"items":[1,{"id":2}]}`, Shape: `{ user { name } items { id } }`, Expected: `{"user":null,"items":[1,{"id":2}]}`},
+		"no braces":       {In: `{"a":1,"b":{"c":2,"d":3}}`, Shape: `a b { c }`, Expected: `{"a":1,"b":{"c":2}}`},
+		"commas":          {In: `{"a":1,"b":2,"c":3}`, Shape: `{ a, b, }`, Expected: `{"a":1,"b":2}`},
+		"comments":        {In: `{"a":1,"b":2}`, Shape: "{\n  a # the a\n}", Expected: `{"a":1}`},
+		"quoted field":    {In: `{"app.kubernetes.io/name":"web","x":1}`, Shape: `{ "app.kubernetes.io/name" }`, Expected: `{"app.kubernetes.io/name":"web"}`},
+		"escaped key":     {In: `{"a\/b":1,"c":2}`, Shape: `{ "a/b" }`, Expected: `{"a\/b":1}`},
+		"merged fields":   {In: `{"a":{"b":1,"c":2,"d":3}}`, Shape: `{ a { b } a { c } }`, Expected: `{"a":{"b":1,"c":2}}`},
+		"merged whole":    {In: `{"a":{"b":1,"c":2}}`, Shape: `{ a { b } a }`, Expected: `{"a":{"b":1,"c":2}}`},
+		"spaced input":    {In: ` { "a" : 1 , "b" : 2 } `, Shape: `{ a }`, Expected: `{"a":1}`},
+		"scalar input":    {In: `"x"`, Shape: `{ a }`, Expected: `"x"`},
+		"empty shape":     {In: `{}`, Shape: ``, BadShape: true},
+		"empty selection": {In: `{}`, Shape: `{ a { } }`, BadShape: true},
+		"unclosed":        {In: `{}`, Shape: `{ a { b }`, BadShape: true},
+		"unbalanced":      {In: `{}`, Shape: `{ a } }`, BadShape: true},
+		"invalid field":   {In: `{}`, Shape: `{ 1a }`, BadShape: true},
+		"invalid input":   {In: `{"a":}`, Shape: `{ a }`, HasError: true},
+		"empty input":     {In: ``, Shape: `{ a }`, HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Project(tc.Shape)
+			if tc.BadShape {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}
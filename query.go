@@ -0,0 +1,194 @@
+package jq
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+)
+
+// Query is a compiled selector; it is the Op returned by Parse
+type Query struct {
+	root  node
+	multi bool
+	opts  options
+	vars  *binding // predefined variables
+}
+
+// Apply evaluates the query against in. A query that can produce several values, such as .[] or .a, .b, returns the
+// values it produces collected into a JSON array; any other query returns its value, or nil if it produces none. A
+// value selected from in is returned without being copied, and any other value is the caller's to modify. When the
+// query slurps or reads input or inputs, in holds a sequence of documents separated by optional whitespace, as in
+// NDJSON; otherwise it is a single document.
+func (q *Query) Apply(in []byte) ([]byte, error) {
+	return q.apply(in, nil)
+}
+
+// apply evaluates the query against in, which is the document d indexes when d isn't nil
+func (q *Query) apply(in []byte, d *Document) ([]byte, error) {
+	in = bytes.TrimSpace(in)
+	if len(in) == 0 {
+		return nil, errors.New("unexpected EOF")
+	}
+
+	docs := InputsFromSlice(in)
+	if q.opts.slurp || q.opts.readsInputs {
+		docs = inputsFromBytes(in)
+	}
+	s := &inputState{docs: docs, strict: q.opts.strict}
+	e := q.env(s)
+	e.doc = d
+	if q.multi {
+		var values [][]byte
+		err := q.each(s, func(doc []byte) error {
+			return q.root.eval(e, doc, func(v []byte) error {
+				values = append(values, v)
+				return nil
+			})
+		})
+		if err != nil && err != errHalt {
+			return nil, err
+		}
+		return appendArray(nil, values), nil
+	}
+
+	var result []byte
+	err := q.each(s, func(doc []byte) error {
+		v, _, err := first(e, q.root, doc)
+		result = v
+		return err
+	})
+	if err == errHalt {
+		return nil, nil
+	}
+	return owned(result, in), err
+}
+
+// Run evaluates the query against each of the documents provided in turn, calling fn with every value produced, and
+// stops at the first error. Documents read by input and inputs are not evaluated again, so a query such as
+// reduce inputs as $x (0; . + $x) run with WithNullInput aggregates a stream without holding it in memory. As with
+// Apply, only the values selected from the document evaluated share its memory.
+func (q *Query) Run(docs Inputs, fn func([]byte) error) error {
+	s := &inputState{docs: docs, strict: q.opts.strict}
+	e := q.env(s)
+	err := q.each(s, func(doc []byte) error {
+		return q.root.eval(e, doc, func(v []byte) error {
+			return fn(owned(v, doc))
+		})
+	})
+	if err == errHalt {
+		return nil
+	}
+	return err
+}
+
+// each calls fn with each input the query is to be evaluated against: every document, an array of all the documents
+// when slurping, or a single null when documents are left to input and inputs
+func (q *Query) each(s *inputState, fn func(doc []byte) error) error {
+	if q.opts.slurp {
+		all, err := s.slurp()
+		if err != nil {
+			return err
+		}
+		s.docs = InputsFromSlice(all)
+	}
+	if q.opts.nullInput {
+		// a null of its own, as the values taken from the input are returned without being copied
+		return fn([]byte("null"))
+	}
+
+	for {
+		doc, err := s.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+}
+
+// env returns the environment in which the query is evaluated, holding the predefined variables
+func (q *Query) env(s *inputState) *env {
+	e := &env{opts: &q.opts, vars: q.vars, inputs: s}
+	e.top = e
+	return e
+}
+
+// analysis serializes the static analysis of queries, which caches results within definitions shared between queries,
+// such as those of the prelude
+var analysis sync.Mutex
+
+// analyse reports whether the query rooted at n may produce more than one value
+func analyse(n node) bool {
+	analysis.Lock()
+	defer analysis.Unlock()
+	return isMulti(n)
+}
+
+// isMulti reports whether a node may produce more than one value
+func isMulti(n node) bool {
+	switch n := n.(type) {
+	case *commaNode, *iterateNode, *globNode:
+		return true
+	case *pipeNode:
+		return isMulti(n.lhs) || isMulti(n.rhs)
+	case *indexNode:
+		return isMulti(n.target) || isMulti(n.index)
+	case *sliceNode:
+		return isMulti(n.target) || (n.from != nil && isMulti(n.from)) || (n.to != nil && isMulti(n.to))
+	case *objectNode:
+		for _, entry := range n.entries {
+			if isMulti(entry.key) || isMulti(entry.value) {
+				return true
+			}
+		}
+		return false
+	case *stringNode:
+		return anyMulti(n.parts)
+	case *negateNode:
+		return isMulti(n.operand)
+	case *binaryNode:
+		return isMulti(n.lhs) || isMulti(n.rhs)
+	case *defNode:
+		return isMulti(n.rest)
+	case *funcCallNode:
+		return n.isMulti()
+	case *reduceNode:
+		return isMulti(n.init)
+	case *foreachNode:
+		return true
+	case *labelNode:
+		return isMulti(n.body)
+	case *asNode:
+		return isMulti(n.source) || isMulti(n.body)
+	case *assignNode:
+		return isMulti(n.value)
+	case *andNode:
+		return isMulti(n.lhs) || isMulti(n.rhs)
+	case *orNode:
+		return isMulti(n.lhs) || isMulti(n.rhs)
+	case *alternativeNode:
+		return isMulti(n.lhs) || isMulti(n.rhs)
+	case *ifNode:
+		return isMulti(n.cond) || isMulti(n.then) || isMulti(n.els)
+	case *tryNode:
+		return isMulti(n.body) || (n.handler != nil && isMulti(n.handler))
+	case *callNode:
+		return n.fn.stream || (!n.fn.aggregate && anyMulti(n.args))
+	default:
+		return false
+	}
+}
+
+func anyMulti(nodes []node) bool {
+	for _, n := range nodes {
+		if isMulti(n) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/bubunyo/go-jq/scanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkQuery(t *testing.B) {
+	op := jq.Must(jq.Parse(`.items[] | select(.price > 10) | {name, total: (.price * .qty)}`))
+	data := []byte(`{"items":[{"name":"a","price":5,"qty":2},{"name":"b","price":20,"qty":3}]}`)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+		Empty    bool
+		HasError bool
+	}{
+		"comma":            {In: `{"a":1,"b":2}`, Op: ".a, .b", Expected: `[1,2]`},
+		"quoted field":     {In: `{"a-b":1}`, Op: `."a-b"`, Expected: `1`},
+		"string index":     {In: `{"a-b":1}`, Op: `.["a-b"]`, Expected: `1`},
+		"negative index":   {In: `[1,2,3]`, Op: `.[-1]`, Expected: `3`},
+		"iterate object":   {In: `{"a":1,"b":2}`, Op: `[.[]]`, Expected: `[1,2]`},
+		"optional":         {In: `1`, Op: `.a?`, Empty: true},
+		"optional iterate": {In: `[[1],2]`, Op: `[.[] | .[]?]`, Expected: `[1]`},
+		"array":            {In: `{"a":1,"b":2}`, Op: `[.a, .b]`, Expected: `[1,2]`},
+		"object":           {In: `{"a":1,"b":2}`, Op: `{a, "c": .b, (.a | tostring): 3}`, Expected: `{"a":1,"c":2,"1":3}`},
+		"object multi":     {In: `{"a":[1,2]}`, Op: `{x: .a[]}`, Expected: `[{"x":1},{"x":2}]`},
+		"arithmetic":       {In: `{"a":3,"b":4}`, Op: `.a * 2 + .b / 2 - 1 % 1`, Expected: `8`},
+		"negate":           {In: `{"a":3}`, Op: `-.a`, Expected: `-3`},
+		"string concat":    {In: `{"a":"x"}`, Op: `.a + "y"`, Expected: `"xy"`},
+		"interpolation":    {In: `{"a":"x","b":[1]}`, Op: `"\(.a)-\(.b)"`, Expected: `"x-[1]"`},
+		"interp number":    {In: `null`, Op: `"\(1)"`, Expected: `"1"`},
+		"interp between":   {In: `null`, Op: `"a\(1)b"`, Expected: `"a1b"`},
+		"interp adjacent":  {In: `{"a":"x"}`, Op: `"\(1)\(2)\(.a)"`, Expected: `"12x"`},
+		"interp literals":  {In: `null`, Op: `"\("x") \(null) \([1, 2]) \({"a": true})"`, Expected: `"x null [1,2] {\"a\":true}"`},
+		"interp key":       {In: `null`, Op: `{"a\(1)": 1}`, Expected: `{"a1":1}`},
+		"compare":          {In: `{"a":3}`, Op: `.a >= 3 and .a != 4`, Expected: `true`},
+		"compare chained":  {In: `1`, Op: `1 < 2 < 3`, HasError: true},
+		"or":               {In: `null`, Op: `false or . == null`, Expected: `true`},
+		"alternative":      {In: `{"a":null}`, Op: `.a // "default"`, Expected: `"default"`},
+		"alternative err":  {In: `1`, Op: `.a // 2`, Expected: `2`},
+		"if":               {In: `2`, Op: `if . > 1 then "big" elif . > 0 then "small" else "none" end`, Expected: `"big"`},
+		"if without else":  {In: `0`, Op: `if . > 1 then "big" end`, Expected: `0`},
+		"try":              {In: `1`, Op: `try .a catch "failed"`, Expected: `"failed"`},
+		"try no catch":     {In: `1`, Op: `try .a`, Empty: true},
+		"recurse":          {In: `{"a":[1]}`, Op: `[..]`, Expected: `[{"a":[1]},[1],1]`},
+		"map":              {In: `[1,2]`, Op: `map(. * 10)`, Expected: `[10,20]`},
+		"select":           {In: `[1,5,2]`, Op: `map(select(. > 1))`, Expected: `[5,2]`},
+		"keys":             {In: `{"b":1,"a":2}`, Op: `keys`, Expected: `["a","b"]`},
+		"keys unsorted":    {In: `{"b":1,"a":2}`, Op: `keys_unsorted`, Expected: `["b","a"]`},
+		"length":           {In: `"héllo"`, Op: `length`, Expected: `5`},
+		"add":              {In: `[1,2,3]`, Op: `add`, Expected: `6`},
+		"empty":            {In: `1`, Op: `empty`, Empty: true},
+		"comment":          {In: `{"a":1}`, Op: ".a # the a field", Expected: `1`},
+		"undefined":        {In: `1`, Op: `nope`, HasError: true},
+		"unterminated":     {In: `1`, Op: `(.a`, HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op)
+			if tc.HasError && err != nil {
+				return
+			}
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			switch {
+			case tc.HasError:
+				assert.Error(t, err)
+			case tc.Empty:
+				require.NoError(t, err)
+				assert.Nil(t, data)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, tc.Expected, string(data))
+			}
+		})
+	}
+}
+
+func TestQueryResultOwned(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+	}{
+		"comparison":     {In: `1`, Op: `. == 1`, Expected: `true`},
+		"false":          {In: `{}`, Op: `has("a")`, Expected: `false`},
+		"missing key":    {In: `null`, Op: `.a`, Expected: `null`},
+		"string literal": {In: `1`, Op: `"literal"`, Expected: `"literal"`},
+		"number literal": {In: `1`, Op: `42`, Expected: `42`},
+		"type":           {In: `1`, Op: `type`, Expected: `"number"`},
+		"infinite":       {In: `1`, Op: `infinite`, Expected: `1.7976931348623157e+308`},
+	}
+
+	// queries whose results share constants with the cases above
+	probes := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+	}{
+		"has":  {In: `{"a":1}`, Op: `has("a")`, Expected: `true`},
+		"null": {In: `null`, Op: `.x`, Expected: `null`},
+		"type": {In: `2`, Op: `type`, Expected: `"number"`},
+	}
+
+	overwrite := func(v []byte) {
+		for i := range v {
+			v[i] = 'x'
+		}
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op)
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			require.NoError(t, err)
+			overwrite(data)
+
+			err = op.(*jq.Query).Run(jq.InputsFromSlice([]byte(tc.In)), func(v []byte) error {
+				overwrite(v)
+				return nil
+			})
+			require.NoError(t, err)
+
+			data, err = op.Apply([]byte(tc.In))
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+
+			for name, p := range probes {
+				data, err := jq.Must(jq.Parse(p.Op)).Apply([]byte(p.In))
+				require.NoError(t, err)
+				assert.Equal(t, p.Expected, string(data), name)
+			}
+		})
+	}
+}
+
+func TestQueryResultShared(t *testing.T) {
+	in := []byte(`{"a":{"b":[1,2]}}`)
+
+	data, err := jq.Must(jq.Parse(`.a.b`)).Apply(in)
+	require.NoError(t, err)
+	assert.Equal(t, `[1,2]`, string(data))
+	assert.Same(t, &in[10], &data[0], "values selected from the input aren't copied")
+}
+
+func TestMissingValues(t *testing.T) {
+	people := `[{"name":"a","x":1,"k":"p"},{"x":2},{"name":"c","k":"q"}]`
+
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+	}{
+		"missing key":      {In: `{"a":1}`, Op: `.b`, Expected: `null`},
+		"missing index":    {In: `[1,2]`, Op: `.[5]`, Expected: `null`},
+		"negative index":   {In: `[1,2]`, Op: `.[-5]`, Expected: `null`},
+		"computed key":     {In: `{"a":1}`, Op: `.["b"]`, Expected: `null`},
+		"chain":            {In: `{"a":{}}`, Op: `.a.b.c[0]`, Expected: `null`},
+		"slice beyond end": {In: `[1,2,3]`, Op: `.[1:10]`, Expected: `[2,3]`},
+		"slice past end":   {In: `[1,2,3]`, Op: `.[5:7]`, Expected: `[]`},
+		"select":           {In: people, Op: `[.[] | select(.x == 1) | .k]`, Expected: `["p"]`},
+		"map":              {In: people, Op: `map(.name)`, Expected: `["a",null,"c"]`},
+		"optional":         {In: people, Op: `[.[] | .name?]`, Expected: `["a",null,"c"]`},
+		"sort_by":          {In: people, Op: `sort_by(.x) | map(.x)`, Expected: `[null,1,2]`},
+		"group_by":         {In: people, Op: `group_by(.k) | map(length)`, Expected: `[1,1,1]`},
+		"first of empty":   {In: `[]`, Op: `first`, Expected: `null`},
+		"last of empty":    {In: `[]`, Op: `last`, Expected: `null`},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op)
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+
+			doc, err := jq.NewDocument([]byte(tc.In))
+			require.NoError(t, err)
+			data, err = doc.Apply(op)
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+
+	// the Dot and Index Ops keep reporting what is missing
+	_, err := jq.Dot("b").Apply([]byte(`{"a":1}`))
+	assert.ErrorIs(t, err, scanner.ErrKeyNotFound)
+	_, err = jq.Index(5).Apply([]byte(`[1,2]`))
+	assert.ErrorIs(t, err, scanner.ErrIndexOutOfBounds)
+}
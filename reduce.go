@@ -0,0 +1,93 @@
+package jq
+
+// reduceNode implements reduce source as $x (init; update): update is applied to the state for each value of
+// source in turn, starting from each value of init, and the final state is produced. The state becomes the last value
+// update produces, or null when it produces none.
+type reduceNode struct {
+	source  node
+	pattern pattern
+	init    node
+	update  node
+}
+
+func (n *reduceNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	return n.init.eval(e, in, func(state []byte) error {
+		err := n.source.eval(e, in, func(v []byte) error {
+			return n.pattern.bind(e, in, v, func(inner *env) error {
+				next := nullValue
+				err := n.update.eval(inner, state, func(u []byte) error {
+					next = u
+					return nil
+				})
+				state = next
+				return err
+			})
+		})
+		if err != nil {
+			return err
+		}
+		return fn(state)
+	})
+}
+
+func (n *reduceNode) evalPath(e *env, in []byte, p path, fn func([]byte, path) error) error {
+	return evalPath(e, n.init, in, p, func(state []byte, sp path) error {
+		err := n.source.eval(e, in, func(v []byte) error {
+			return n.pattern.bind(e, in, v, func(inner *env) error {
+				next, np := nullValue, path(nil)
+				err := evalPath(inner, n.update, state, sp, func(u []byte, up path) error {
+					next, np = u, up
+					return nil
+				})
+				state, sp = next, np
+				return err
+			})
+		})
+		if err != nil {
+			return err
+		}
+		return fn(state, sp)
+	})
+}
+
+// foreachNode implements foreach source as $x (init; update; extract): like reduce, but extract is applied to every
+// state update produces, each of which becomes the state in turn
+type foreachNode struct {
+	source  node
+	pattern pattern
+	init    node
+	update  node
+	extract node
+}
+
+func (n *foreachNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	return n.init.eval(e, in, func(state []byte) error {
+		return n.source.eval(e, in, func(v []byte) error {
+			return n.pattern.bind(e, in, v, func(inner *env) error {
+				return n.update.eval(inner, state, func(u []byte) error {
+					state = u
+					if n.extract == nil {
+						return fn(u)
+					}
+					return n.extract.eval(inner, u, fn)
+				})
+			})
+		})
+	})
+}
+
+func (n *foreachNode) evalPath(e *env, in []byte, p path, fn func([]byte, path) error) error {
+	return evalPath(e, n.init, in, p, func(state []byte, sp path) error {
+		return n.source.eval(e, in, func(v []byte) error {
+			return n.pattern.bind(e, in, v, func(inner *env) error {
+				return evalPath(inner, n.update, state, sp, func(u []byte, up path) error {
+					state, sp = u, up
+					if n.extract == nil {
+						return fn(u, up)
+					}
+					return evalPath(inner, n.extract, u, up, fn)
+				})
+			})
+		})
+	})
+}
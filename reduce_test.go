@@ -0,0 +1,62 @@
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkReduce(t *testing.B) {
+	op := jq.Must(jq.Parse(`reduce .[] as $x (0; . + $x)`))
+	data := []byte(`[1,2,3,4,5,6,7,8,9,10]`)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestReduce(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+		HasError bool
+	}{
+		"reduce":              {In: `[1, 2, 3]`, Op: `reduce .[] as $x (0; . + $x)`, Expected: `6`},
+		"reduce empty":        {In: `[]`, Op: `reduce .[] as $x (0; . + $x)`, Expected: `0`},
+		"reduce object":       {In: `[{"k": "a", "v": 1}, {"k": "b", "v": 2}]`, Op: `reduce .[] as {k: $k, v: $v} ({}; .[$k] = $v)`, Expected: `{"a":1,"b":2}`},
+		"reduce input":        {In: `{"n": 10, "l": [1, 2]}`, Op: `reduce .l[] as $x (.n; . + $x)`, Expected: `13`},
+		"reduce update empty": {In: `[1, 2]`, Op: `reduce .[] as $x (0; empty)`, Expected: `null`},
+		"reduce update last":  {In: `[1, 2]`, Op: `reduce .[] as $x (0; ., 10)`, Expected: `10`},
+		"reduce inits":        {In: `[1, 2]`, Op: `reduce .[] as $x (0, 10; . + $x)`, Expected: `[3,13]`},
+		"reduce precedence":   {In: `[[1, 2], [3]]`, Op: `[.[] | reduce .[] as $x (0; . + $x)]`, Expected: `[3,3]`},
+		"reduce path":         {In: `{"a": {"b": 1}}`, Op: `path(reduce ("a", "b") as $k (.; .[$k]))`, Expected: `["a","b"]`},
+		"reduce scope":        {In: `[1]`, Op: `reduce .[] as $x ($x; .)`, HasError: true},
+		"reduce syntax":       {In: `[1]`, Op: `reduce .[] as $x (0)`, HasError: true},
+		"foreach":             {In: `[1, 2, 3]`, Op: `foreach .[] as $x (0; . + $x)`, Expected: `[1,3,6]`},
+		"foreach extract":     {In: `[1, 2, 3]`, Op: `[foreach .[] as $x (0; . + $x; [$x, .])]`, Expected: `[[1,1],[2,3],[3,6]]`},
+		"foreach empty":       {In: `[1, 2, 3]`, Op: `[foreach .[] as $x (0; if $x == 2 then empty else . + $x end)]`, Expected: `[1,4]`},
+		"foreach path":        {In: `{"a": {"b": 1}}`, Op: `[path(foreach ("a", "b") as $k (.; .[$k]))]`, Expected: `[["a"],["a","b"]]`},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op)
+			if tc.HasError && err != nil {
+				return
+			}
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}
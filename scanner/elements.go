@@ -0,0 +1,57 @@
+package scanner
+
+// Elements calls fn with the start and end positions of each element of the array that begins at pos and returns the
+// position of the end of the array; iteration stops at the first error returned by fn
+func Elements(in []byte, pos int, fn func(start, end int) error) (int, error) {
+	pos, err := skipSpace(in, pos)
+	if err != nil {
+		return 0, err
+	}
+
+	if v := in[pos]; v != '[' {
+		return 0, newError(pos, v)
+	}
+	pos++
+
+	// clean initial spaces
+	pos, err = skipSpace(in, pos)
+	if err != nil {
+		return 0, err
+	}
+
+	if in[pos] == ']' {
+		return pos + 1, nil
+	}
+
+	for {
+		pos, err = skipSpace(in, pos)
+		if err != nil {
+			return 0, err
+		}
+
+		start := pos
+		// data
+		pos, err = Any(in, pos)
+		if err != nil {
+			return 0, err
+		}
+
+		if err := fn(start, pos); err != nil {
+			return 0, err
+		}
+
+		pos, err = skipSpace(in, pos)
+		if err != nil {
+			return 0, err
+		}
+
+		switch v := in[pos]; v {
+		case ',':
+			pos++
+		case ']':
+			return pos + 1, nil
+		default:
+			return 0, newError(pos, v)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package scanner_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bubunyo/go-jq/scanner"
+)
+
+func BenchmarkElements(t *testing.B) {
+	data := []byte(`["hello","world"]`)
+	fn := func(start, end int) error { return nil }
+
+	for i := 0; i < t.N; i++ {
+		end, err := scanner.Elements(data, 0, fn)
+		if err != nil {
+			t.FailNow()
+			return
+		}
+
+		if end != len(data) {
+			t.FailNow()
+			return
+		}
+	}
+}
+
+func TestElements(t *testing.T) {
+	testCases := map[string]struct {
+		In     string
+		Out    []string
+		End    int
+		HasErr bool
+	}{
+		"simple": {
+			In:  `["hello","world"]`,
+			Out: []string{`"hello"`, `"world"`},
+			End: 17,
+		},
+		"empty": {
+			In:  ` [ ] `,
+			Out: []string{},
+			End: 4,
+		},
+		"spaced": {
+			In:  ` [ "hello" , {"a":1} ] `,
+			Out: []string{`"hello"`, `{"a":1}`},
+			End: 22,
+		},
+		"missing comma": {
+			In:     `[1 2]`,
+			HasErr: true,
+		},
+		"unclosed": {
+			In:     `[1,`,
+			HasErr: true,
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			var out []string
+			end, err := scanner.Elements([]byte(tc.In), 0, func(start, end int) error {
+				out = append(out, tc.In[start:end])
+				return nil
+			})
+			if tc.HasErr {
+				if err == nil {
+					t.FailNow()
+				}
+				return
+			}
+
+			if err != nil || end != tc.End || len(out) != len(tc.Out) {
+				t.Fatalf("got %v, %v, %v", out, end, err)
+			}
+			for i := range out {
+				if out[i] != tc.Out[i] {
+					t.Fatalf("want %v, got %v", tc.Out[i], out[i])
+				}
+			}
+		})
+	}
+}
+
+func TestElementsStop(t *testing.T) {
+	errStop := errors.New("stop")
+
+	count := 0
+	_, err := scanner.Elements([]byte(`[1,2,3]`), 0, func(start, end int) error {
+		count++
+		return errStop
+	})
+	if err != errStop || count != 1 {
+		t.FailNow()
+	}
+}
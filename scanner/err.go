@@ -9,5 +9,8 @@ type opErr struct {
 }
 
 func (err opErr) Error() string {
+	if err.content == "" {
+		return fmt.Sprintf("%v at position %v", err.msg, err.pos)
+	}
 	return fmt.Sprintf("%v at position %v; %v", err.msg, err.pos, err.content)
 }
@@ -12,6 +12,15 @@ func FindFrom(in []byte, pos, from int) ([]byte, error) {
 	}
 	pos++
 
+	// an empty array contains no index
+	pos, err = skipSpace(in, pos)
+	if err != nil {
+		return nil, err
+	}
+	if in[pos] == ']' {
+		return nil, errFromOutOfBounds
+	}
+
 	idx := 0
 	itemStart := pos
 
@@ -38,6 +38,11 @@ func TestFindFrom(t *testing.T) {
 			From:   20,
 			HasErr: true,
 		},
+		"empty": {
+			In:     `[]`,
+			From:   0,
+			HasErr: true,
+		},
 	}
 
 	for label, tc := range testCases {
@@ -2,43 +2,67 @@ package scanner
 
 // FindIndex accepts a JSON array and return the value of the element at the specified index
 func FindIndex(in []byte, pos, index int) ([]byte, error) {
-	pos, err := skipSpace(in, pos)
+	start, err := SeekIndex(in, pos, index)
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := Any(in, start)
 	if err != nil {
 		return nil, err
 	}
+	return in[start:end], nil
+}
+
+// SeekIndex accepts a JSON array and returns the position at which the element at the specified index begins, without
+// scanning the element itself
+func SeekIndex(in []byte, pos, index int) (int, error) {
+	pos, err := skipSpace(in, pos)
+	if err != nil {
+		return 0, err
+	}
 
 	if v := in[pos]; v != '[' {
-		return nil, newError(pos, v)
+		return 0, newError(pos, v)
 	}
 	pos++
 
+	// an empty array contains no index
+	pos, err = skipSpace(in, pos)
+	if err != nil {
+		return 0, err
+	}
+	if in[pos] == ']' {
+		return 0, ErrIndexOutOfBounds
+	}
+
 	idx := 0
 	for {
 		pos, err = skipSpace(in, pos)
 		if err != nil {
-			return nil, err
+			return 0, err
+		}
+
+		if index == idx {
+			return pos, nil
 		}
 
-		itemStart := pos
 		// data
 		pos, err = Any(in, pos)
 		if err != nil {
-			return nil, err
-		}
-		if index == idx {
-			return in[itemStart:pos], nil
+			return 0, err
 		}
 
 		pos, err = skipSpace(in, pos)
 		if err != nil {
-			return nil, err
+			return 0, err
 		}
 
 		switch in[pos] {
 		case ',':
 			pos++
 		case ']':
-			return nil, errIndexOutOfBounds
+			return 0, ErrIndexOutOfBounds
 		}
 
 		idx++
@@ -45,6 +45,10 @@ func TestFindIndex(t *testing.T) {
 			Index:    2,
 			Expected: `{"hello":"world"}`,
 		},
+		"empty": {
+			In:     ` [ ] `,
+			HasErr: true,
+		},
 	}
 
 	for label, tc := range testCases {
@@ -65,3 +69,35 @@ func TestFindIndex(t *testing.T) {
 		})
 	}
 }
+
+func TestSeekIndex(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Index    int
+		Expected int
+		HasErr   bool
+	}{
+		"first":             {In: `[1,2]`, Index: 0, Expected: 1},
+		"spaced":            {In: ` [ 1 , 2 ] `, Index: 1, Expected: 7},
+		"value not scanned": {In: `[1,[}]`, Index: 1, Expected: 3},
+		"earlier value bad": {In: `[[},1]`, Index: 1, HasErr: true},
+		"out of bounds":     {In: `[1]`, Index: 1, HasErr: true},
+		"empty":             {In: `[]`, Index: 0, HasErr: true},
+		"not an array":      {In: `{}`, Index: 0, HasErr: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			pos, err := scanner.SeekIndex([]byte(tc.In), 0, tc.Index)
+			if tc.HasErr {
+				if err == nil {
+					t.FailNow()
+				}
+				return
+			}
+			if err != nil || pos != tc.Expected {
+				t.Fatalf("got %v, %v", pos, err)
+			}
+		})
+	}
+}
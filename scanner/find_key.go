@@ -2,71 +2,113 @@ package scanner
 
 import "bytes"
 
-// FindKey accepts a JSON object and returns the value associated with the key specified
+// FindKey accepts a JSON object and returns the value associated with the key specified. The key is given as the
+// content of a JSON string, without its quotes, and matches keys written with different escapes, so that "\/" is found
+// by "/".
 func FindKey(in []byte, pos int, k []byte) ([]byte, error) {
-	pos, err := skipSpace(in, pos)
+	start, err := SeekKey(in, pos, k)
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := Any(in, start)
 	if err != nil {
 		return nil, err
 	}
+	return in[start:end], nil
+}
+
+// SeekKey accepts a JSON object and returns the position at which the value associated with the key specified begins,
+// without scanning the value itself, so that a path can be followed into it in a single descent. Keys are matched as
+// they are by FindKey.
+func SeekKey(in []byte, pos int, k []byte) (int, error) {
+	pos, err := skipSpace(in, pos)
+	if err != nil {
+		return 0, err
+	}
 
 	if v := in[pos]; v != '{' {
-		return nil, newError(pos, v)
+		return 0, newError(pos, v)
 	}
 	pos++
 
+	pos, err = skipSpace(in, pos)
+	if err != nil {
+		return 0, err
+	}
+	if in[pos] == '}' {
+		return 0, ErrKeyNotFound
+	}
+
 	for {
 		pos, err = skipSpace(in, pos)
 		if err != nil {
-			return nil, err
+			return 0, err
 		}
 
 		keyStart := pos
 		// key
 		pos, err = String(in, pos)
 		if err != nil {
-			return nil, err
+			return 0, err
 		}
 		key := in[keyStart+1 : pos-1]
-		match := bytes.Equal(k, key)
+		match := sameKey(k, key)
 
 		// leading spaces
 		pos, err = skipSpace(in, pos)
 		if err != nil {
-			return nil, err
+			return 0, err
 		}
 
 		// colon
 		pos, err = expect(in, pos, ':')
 		if err != nil {
-			return nil, err
+			return 0, err
 		}
 
 		pos, err = skipSpace(in, pos)
 		if err != nil {
-			return nil, err
+			return 0, err
+		}
+
+		if match {
+			return pos, nil
 		}
 
-		valueStart := pos
 		// data
 		pos, err = Any(in, pos)
 		if err != nil {
-			return nil, err
-		}
-
-		if match {
-			return in[valueStart:pos], nil
+			return 0, err
 		}
 
 		pos, err = skipSpace(in, pos)
 		if err != nil {
-			return nil, err
+			return 0, err
 		}
 
 		switch in[pos] {
 		case ',':
 			pos++
 		case '}':
-			return nil, errKeyNotFound
+			return 0, ErrKeyNotFound
 		}
 	}
 }
+
+// FindKeys calls fn with the key and value of each member of the JSON object whose key match accepts, in the order
+// they appear, stopping at the first error fn returns. Keys are given to match as the content of their JSON strings
+// with escapes decoded, and to fn as they appear in the input, quotes included.
+func FindKeys(in []byte, pos int, match func(key []byte) bool, fn func(key, value []byte) error) error {
+	_, err := Members(in, pos, func(keyStart, keyEnd, valueStart, valueEnd int) error {
+		key := in[keyStart+1 : keyEnd-1]
+		if bytes.IndexByte(key, '\\') >= 0 {
+			key = unescape(key)
+		}
+		if !match(key) {
+			return nil
+		}
+		return fn(in[keyStart:keyEnd], in[valueStart:valueEnd])
+	})
+	return err
+}
@@ -1,6 +1,9 @@
 package scanner_test
 
 import (
+	"bytes"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/bubunyo/go-jq/scanner"
@@ -40,6 +43,36 @@ func TestFindKey(t *testing.T) {
 			Key:      "hello",
 			Expected: `"world"`,
 		},
+		"empty": {
+			In:     `{ }`,
+			Key:    "hello",
+			HasErr: true,
+		},
+		"missing": {
+			In:     `{"a":1}`,
+			Key:    "hello",
+			HasErr: true,
+		},
+		"escaped in object": {
+			In:       `{"a\/b":1,"\u00e9\t":2}`,
+			Key:      "é\\t",
+			Expected: `2`,
+		},
+		"escaped in key": {
+			In:       `{"a/b":1}`,
+			Key:      `a\/b`,
+			Expected: `1`,
+		},
+		"surrogate pair": {
+			In:       `{"\ud834\udd1e":1}`,
+			Key:      "𝄞",
+			Expected: `1`,
+		},
+		"escape mismatch": {
+			In:     `{"a\\nb":1}`,
+			Key:    `a\nb`,
+			HasErr: true,
+		},
 	}
 
 	for label, tc := range testCases {
@@ -60,3 +93,116 @@ func TestFindKey(t *testing.T) {
 		})
 	}
 }
+
+func BenchmarkFindKeys(t *testing.B) {
+	data := []byte(`{"app.kubernetes.io/name":"web","app.kubernetes.io/part-of":"shop","tier":"frontend"}`)
+	prefix := []byte("app.kubernetes.io/")
+
+	for i := 0; i < t.N; i++ {
+		n := 0
+		err := scanner.FindKeys(data, 0, func(key []byte) bool {
+			return bytes.HasPrefix(key, prefix)
+		}, func(_, _ []byte) error {
+			n++
+			return nil
+		})
+		if err != nil || n != 2 {
+			t.FailNow()
+		}
+	}
+}
+
+func TestFindKeys(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Prefix   string
+		Expected string
+		HasErr   bool
+	}{
+		"matches": {
+			In:       `{"ab":1,"b":2,"ac":3}`,
+			Prefix:   "a",
+			Expected: `"ab"=1 "ac"=3 `,
+		},
+		"none": {
+			In:     `{"b":2}`,
+			Prefix: "a",
+		},
+		"spaced": {
+			In:       ` { "ab" : [1, 2] } `,
+			Prefix:   "a",
+			Expected: `"ab"=[1, 2] `,
+		},
+		"escaped keys decoded": {
+			In:       `{"\u0061b":1}`,
+			Prefix:   "a",
+			Expected: `"\u0061b"=1 `,
+		},
+		"not an object": {
+			In:     `[1]`,
+			Prefix: "a",
+			HasErr: true,
+		},
+		"invalid": {
+			In:     `{"a":}`,
+			Prefix: "a",
+			HasErr: true,
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			var out strings.Builder
+			err := scanner.FindKeys([]byte(tc.In), 0, func(key []byte) bool {
+				return bytes.HasPrefix(key, []byte(tc.Prefix))
+			}, func(key, value []byte) error {
+				fmt.Fprintf(&out, "%s=%s ", key, value)
+				return nil
+			})
+			if tc.HasErr {
+				if err == nil {
+					t.FailNow()
+				}
+				return
+			}
+			if err != nil || out.String() != tc.Expected {
+				t.Fatalf("got %q, %v", out.String(), err)
+			}
+		})
+	}
+}
+
+func TestSeekKey(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Key      string
+		Expected int
+		HasErr   bool
+	}{
+		"first":              {In: `{"a":1,"b":2}`, Key: "a", Expected: 5},
+		"spaced":             {In: ` { "a" : 1 , "b" : 2 } `, Key: "b", Expected: 19},
+		"value not scanned":  {In: `{"a":{"b":}}`, Key: "a", Expected: 5},
+		"rest not scanned":   {In: `{"a":1,"b":}`, Key: "a", Expected: 5},
+		"earlier value bad":  {In: `{"a":},"b":1}`, Key: "b", HasErr: true},
+		"missing":            {In: `{"a":1}`, Key: "b", HasErr: true},
+		"empty":              {In: `{}`, Key: "a", HasErr: true},
+		"not an object":      {In: `[1]`, Key: "a", HasErr: true},
+		"escaped key":        {In: `{"a\/b":1}`, Key: "a/b", Expected: 8},
+		"nested key skipped": {In: `{"x":{"a":1},"a":2}`, Key: "a", Expected: 17},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			pos, err := scanner.SeekKey([]byte(tc.In), 0, []byte(tc.Key))
+			if tc.HasErr {
+				if err == nil {
+					t.FailNow()
+				}
+				return
+			}
+			if err != nil || pos != tc.Expected {
+				t.Fatalf("got %v, %v", pos, err)
+			}
+		})
+	}
+}
@@ -16,6 +16,15 @@ func FindRange(in []byte, pos, from, to int) ([]byte, error) {
 	}
 	pos++
 
+	// an empty array contains no index
+	pos, err = skipSpace(in, pos)
+	if err != nil {
+		return nil, err
+	}
+	if in[pos] == ']' {
+		return nil, ErrIndexOutOfBounds
+	}
+
 	idx := 0
 	itemStart := pos
 
@@ -53,7 +62,7 @@ func FindRange(in []byte, pos, from, to int) ([]byte, error) {
 		case ',':
 			pos++
 		case ']':
-			return nil, errIndexOutOfBounds
+			return nil, ErrIndexOutOfBounds
 		}
 
 		idx++
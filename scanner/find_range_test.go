@@ -61,6 +61,12 @@ func TestFindRange(t *testing.T) {
 			To:     20,
 			HasErr: true,
 		},
+		"empty": {
+			In:     `[]`,
+			From:   0,
+			To:     0,
+			HasErr: true,
+		},
 	}
 
 	for label, tc := range testCases {
@@ -12,6 +12,15 @@ func FindTo(in []byte, pos, to int) ([]byte, error) {
 	}
 	pos++
 
+	// an empty array contains no index
+	pos, err = skipSpace(in, pos)
+	if err != nil {
+		return nil, err
+	}
+	if in[pos] == ']' {
+		return nil, ErrIndexOutOfBounds
+	}
+
 	idx := 0
 	itemStart := pos
 
@@ -45,7 +54,7 @@ func FindTo(in []byte, pos, to int) ([]byte, error) {
 		case ',':
 			pos++
 		case ']':
-			return nil, errIndexOutOfBounds
+			return nil, ErrIndexOutOfBounds
 		}
 
 		idx++
@@ -38,6 +38,11 @@ func TestFindTo(t *testing.T) {
 			To:     20,
 			HasErr: true,
 		},
+		"empty": {
+			In:     `[]`,
+			To:     0,
+			HasErr: true,
+		},
 	}
 
 	for label, tc := range testCases {
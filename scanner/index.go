@@ -0,0 +1,221 @@
+package scanner
+
+import "sort"
+
+// Tape is a structural index of a JSON document: the position of every key and value, recorded in a single pass in
+// the order they appear. Each value records where the values within it end, so that keys, elements and members are
+// found by jumping over values rather than scanning them again. Values are identified by their position in the tape,
+// the document itself being 0.
+type Tape struct {
+	in    []byte
+	nodes []tapeNode
+}
+
+// tapeNode is a key or value of a tape: its bytes, and the node that follows it and everything within it. The members
+// of an object are recorded as a key node followed by a value node.
+type tapeNode struct {
+	start, end int
+	next       int
+}
+
+// Index validates the JSON document provided and records its structure in a Tape
+func Index(in []byte) (*Tape, error) {
+	t := &Tape{in: in, nodes: make([]tapeNode, 0, len(in)/8)}
+	pos, err := t.value(0)
+	if err != nil {
+		return nil, err
+	}
+
+	// only whitespace may follow the document
+	if pos, err = skipSpace(in, pos); err == nil {
+		return nil, newError(pos, in[pos])
+	}
+	return t, nil
+}
+
+// value records the value that begins at pos and the values within it, returning the position of its end
+func (t *Tape) value(pos int) (int, error) {
+	pos, err := skipSpace(t.in, pos)
+	if err != nil {
+		return 0, err
+	}
+
+	n := len(t.nodes)
+	t.nodes = append(t.nodes, tapeNode{start: pos})
+	switch t.in[pos] {
+	case '{':
+		pos, err = t.object(pos + 1)
+	case '[':
+		pos, err = t.array(pos + 1)
+	default:
+		pos, err = Any(t.in, pos)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	t.nodes[n].end = pos
+	t.nodes[n].next = len(t.nodes)
+	return pos, nil
+}
+
+// object records the members of the object whose content begins at pos
+func (t *Tape) object(pos int) (int, error) {
+	pos, err := skipSpace(t.in, pos)
+	if err != nil {
+		return 0, err
+	}
+	if t.in[pos] == '}' {
+		return pos + 1, nil
+	}
+
+	for {
+		pos, err = skipSpace(t.in, pos)
+		if err != nil {
+			return 0, err
+		}
+
+		keyStart := pos
+		pos, err = String(t.in, pos)
+		if err != nil {
+			return 0, err
+		}
+		t.nodes = append(t.nodes, tapeNode{start: keyStart, end: pos, next: len(t.nodes) + 1})
+
+		pos, err = skipSpace(t.in, pos)
+		if err != nil {
+			return 0, err
+		}
+		pos, err = expect(t.in, pos, ':')
+		if err != nil {
+			return 0, err
+		}
+
+		pos, err = t.value(pos)
+		if err != nil {
+			return 0, err
+		}
+
+		pos, err = skipSpace(t.in, pos)
+		if err != nil {
+			return 0, err
+		}
+		switch v := t.in[pos]; v {
+		case ',':
+			pos++
+		case '}':
+			return pos + 1, nil
+		default:
+			return 0, newError(pos, v)
+		}
+	}
+}
+
+// array records the elements of the array whose content begins at pos
+func (t *Tape) array(pos int) (int, error) {
+	pos, err := skipSpace(t.in, pos)
+	if err != nil {
+		return 0, err
+	}
+	if t.in[pos] == ']' {
+		return pos + 1, nil
+	}
+
+	for {
+		pos, err = t.value(pos)
+		if err != nil {
+			return 0, err
+		}
+
+		pos, err = skipSpace(t.in, pos)
+		if err != nil {
+			return 0, err
+		}
+		switch v := t.in[pos]; v {
+		case ',':
+			pos++
+		case ']':
+			return pos + 1, nil
+		default:
+			return 0, newError(pos, v)
+		}
+	}
+}
+
+// Value returns the bytes of the value at n
+func (t *Tape) Value(n int) []byte {
+	return t.in[t.nodes[n].start:t.nodes[n].end]
+}
+
+// Lookup returns the value that begins at pos in the document, so that values found by other means can be located
+// within the tape; it reports false when no value begins there
+func (t *Tape) Lookup(pos int) (int, bool) {
+	n := sort.Search(len(t.nodes), func(i int) bool { return t.nodes[i].start >= pos })
+	if n == len(t.nodes) || t.nodes[n].start != pos {
+		return 0, false
+	}
+	return n, true
+}
+
+// FindKey returns the value associated with the key specified in the object at n, matching keys as FindKey does
+func (t *Tape) FindKey(n int, k []byte) (int, error) {
+	v := t.nodes[n]
+	if c := t.in[v.start]; c != '{' {
+		return 0, newError(v.start, c)
+	}
+
+	for key := n + 1; key < v.next; key = t.nodes[key+1].next {
+		if sameKey(k, t.in[t.nodes[key].start+1:t.nodes[key].end-1]) {
+			return key + 1, nil
+		}
+	}
+	return 0, ErrKeyNotFound
+}
+
+// FindIndex returns the element at the specified index of the array at n
+func (t *Tape) FindIndex(n, index int) (int, error) {
+	v := t.nodes[n]
+	if c := t.in[v.start]; c != '[' {
+		return 0, newError(v.start, c)
+	}
+
+	i := 0
+	for e := n + 1; e < v.next; e = t.nodes[e].next {
+		if i == index {
+			return e, nil
+		}
+		i++
+	}
+	return 0, ErrIndexOutOfBounds
+}
+
+// Elements calls fn with each element of the array at n, stopping at the first error fn returns
+func (t *Tape) Elements(n int, fn func(e int) error) error {
+	v := t.nodes[n]
+	if c := t.in[v.start]; c != '[' {
+		return newError(v.start, c)
+	}
+
+	for e := n + 1; e < v.next; e = t.nodes[e].next {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Members calls fn with the key, quotes included, and value of each member of the object at n, stopping at the first
+// error fn returns
+func (t *Tape) Members(n int, fn func(key []byte, value int) error) error {
+	v := t.nodes[n]
+	if c := t.in[v.start]; c != '{' {
+		return newError(v.start, c)
+	}
+
+	for key := n + 1; key < v.next; key = t.nodes[key+1].next {
+		if err := fn(t.in[t.nodes[key].start:t.nodes[key].end], key+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
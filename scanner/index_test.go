@@ -0,0 +1,149 @@
+package scanner_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bubunyo/go-jq/scanner"
+)
+
+func BenchmarkIndex(t *testing.B) {
+	data := []byte(`{"user":{"name":"Ama","roles":["admin","dev"]},"items":[{"id":1,"price":10},{"id":2,"price":20}],"ok":true}`)
+
+	for i := 0; i < t.N; i++ {
+		if _, err := scanner.Index(data); err != nil {
+			t.FailNow()
+		}
+	}
+}
+
+func BenchmarkTapeFindKey(t *testing.B) {
+	data := []byte(`{"a":[` + strings.Repeat(`{"b":"c"},`, 100) + `1],"hello":"world"}`)
+	tape, err := scanner.Index(data)
+	if err != nil {
+		t.FailNow()
+	}
+
+	for i := 0; i < t.N; i++ {
+		n, err := tape.FindKey(0, []byte("hello"))
+		if err != nil || string(tape.Value(n)) != `"world"` {
+			t.FailNow()
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	testCases := map[string]struct {
+		In     string
+		HasErr bool
+	}{
+		"object":            {In: `{"a":1,"b":[true,null,"x"],"c":{}}`},
+		"array":             {In: `[[],[1,[2]],{"a":{"b":-1.5e3}}]`},
+		"scalar":            {In: `"hello"`},
+		"spaced":            {In: " { \"a\" : [ 1 , 2 ] } \n"},
+		"empty":             {In: ``, HasErr: true},
+		"unclosed object":   {In: `{"a":1`, HasErr: true},
+		"unclosed array":    {In: `[1,2`, HasErr: true},
+		"missing colon":     {In: `{"a" 1}`, HasErr: true},
+		"missing comma":     {In: `[1 2]`, HasErr: true},
+		"invalid member":    {In: `{"a":1,}`, HasErr: true},
+		"invalid value":     {In: `{"a":x}`, HasErr: true},
+		"trailing content":  {In: `{} {}`, HasErr: true},
+		"mismatched braces": {In: `{"a":[1}}`, HasErr: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			_, err := scanner.Index([]byte(tc.In))
+			if tc.HasErr != (err != nil) {
+				t.Fatalf("got %v", err)
+			}
+		})
+	}
+}
+
+func TestTape(t *testing.T) {
+	data := []byte(` {"a":{"b":[10,{"c":"d"},30]},"e\/f":1,"g":[]} `)
+	tape, err := scanner.Index(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	find := func(path ...any) (string, error) {
+		n := 0
+		for _, step := range path {
+			var err error
+			switch s := step.(type) {
+			case string:
+				n, err = tape.FindKey(n, []byte(s))
+			case int:
+				n, err = tape.FindIndex(n, s)
+			}
+			if err != nil {
+				return "", err
+			}
+		}
+		return string(tape.Value(n)), nil
+	}
+
+	testCases := map[string]struct {
+		Path     []any
+		Expected string
+		HasErr   bool
+	}{
+		"document":      {Expected: `{"a":{"b":[10,{"c":"d"},30]},"e\/f":1,"g":[]}`},
+		"key":           {Path: []any{"a"}, Expected: `{"b":[10,{"c":"d"},30]}`},
+		"nested":        {Path: []any{"a", "b", 1, "c"}, Expected: `"d"`},
+		"last element":  {Path: []any{"a", "b", 2}, Expected: `30`},
+		"escaped key":   {Path: []any{"e/f"}, Expected: `1`},
+		"missing key":   {Path: []any{"x"}, HasErr: true},
+		"out of bounds": {Path: []any{"a", "b", 3}, HasErr: true},
+		"empty array":   {Path: []any{"g", 0}, HasErr: true},
+		"not an object": {Path: []any{"g", "x"}, HasErr: true},
+		"not an array":  {Path: []any{"a", 0}, HasErr: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			v, err := find(tc.Path...)
+			if tc.HasErr {
+				if err == nil {
+					t.FailNow()
+				}
+				return
+			}
+			if err != nil || v != tc.Expected {
+				t.Fatalf("got %q, %v", v, err)
+			}
+		})
+	}
+
+	t.Run("iteration", func(t *testing.T) {
+		var out strings.Builder
+		err := tape.Members(0, func(key []byte, value int) error {
+			fmt.Fprintf(&out, "%s=%s ", key, tape.Value(value))
+			return nil
+		})
+		b, _ := tape.FindKey(0, []byte("a"))
+		b, _ = tape.FindKey(b, []byte("b"))
+		err2 := tape.Elements(b, func(e int) error {
+			fmt.Fprintf(&out, "%s ", tape.Value(e))
+			return nil
+		})
+		expected := `"a"={"b":[10,{"c":"d"},30]} "e\/f"=1 "g"=[] 10 {"c":"d"} 30 `
+		if err != nil || err2 != nil || out.String() != expected {
+			t.Fatalf("got %q, %v, %v", out.String(), err, err2)
+		}
+	})
+
+	t.Run("lookup", func(t *testing.T) {
+		n, ok := tape.Lookup(strings.Index(string(data), `{"c"`))
+		if !ok || string(tape.Value(n)) != `{"c":"d"}` {
+			t.FailNow()
+		}
+		if _, ok := tape.Lookup(strings.Index(string(data), `"d"`) + 1); ok {
+			t.FailNow()
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package scanner
+
+// Members calls fn with the positions of the key and value of each member of the object that begins at pos and
+// returns the position of the end of the object; keys include their surrounding quotes and iteration stops at the
+// first error returned by fn
+func Members(in []byte, pos int, fn func(keyStart, keyEnd, valueStart, valueEnd int) error) (int, error) {
+	pos, err := skipSpace(in, pos)
+	if err != nil {
+		return 0, err
+	}
+
+	if v := in[pos]; v != '{' {
+		return 0, newError(pos, v)
+	}
+	pos++
+
+	// clean initial spaces
+	pos, err = skipSpace(in, pos)
+	if err != nil {
+		return 0, err
+	}
+
+	if in[pos] == '}' {
+		return pos + 1, nil
+	}
+
+	for {
+		pos, err = skipSpace(in, pos)
+		if err != nil {
+			return 0, err
+		}
+
+		keyStart := pos
+		// key
+		pos, err = String(in, pos)
+		if err != nil {
+			return 0, err
+		}
+		keyEnd := pos
+
+		// leading spaces
+		pos, err = skipSpace(in, pos)
+		if err != nil {
+			return 0, err
+		}
+
+		// colon
+		pos, err = expect(in, pos, ':')
+		if err != nil {
+			return 0, err
+		}
+
+		pos, err = skipSpace(in, pos)
+		if err != nil {
+			return 0, err
+		}
+
+		valueStart := pos
+		// data
+		pos, err = Any(in, pos)
+		if err != nil {
+			return 0, err
+		}
+
+		if err := fn(keyStart, keyEnd, valueStart, pos); err != nil {
+			return 0, err
+		}
+
+		pos, err = skipSpace(in, pos)
+		if err != nil {
+			return 0, err
+		}
+
+		switch v := in[pos]; v {
+		case ',':
+			pos++
+		case '}':
+			return pos + 1, nil
+		default:
+			return 0, newError(pos, v)
+		}
+	}
+}
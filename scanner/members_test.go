@@ -0,0 +1,83 @@
+package scanner_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq/scanner"
+)
+
+func BenchmarkMembers(t *testing.B) {
+	data := []byte(`{"hello":"world"}`)
+	fn := func(keyStart, keyEnd, valueStart, valueEnd int) error { return nil }
+
+	for i := 0; i < t.N; i++ {
+		end, err := scanner.Members(data, 0, fn)
+		if err != nil {
+			t.FailNow()
+			return
+		}
+
+		if end != len(data) {
+			t.FailNow()
+			return
+		}
+	}
+}
+
+func TestMembers(t *testing.T) {
+	testCases := map[string]struct {
+		In     string
+		Out    []string
+		End    int
+		HasErr bool
+	}{
+		"simple": {
+			In:  `{"hello":"world"}`,
+			Out: []string{`"hello"`, `"world"`},
+			End: 17,
+		},
+		"empty": {
+			In:  ` { } `,
+			Out: []string{},
+			End: 4,
+		},
+		"spaced": {
+			In:  ` { "a" : 1 , "b\"" : [2] } `,
+			Out: []string{`"a"`, `1`, `"b\""`, `[2]`},
+			End: 26,
+		},
+		"missing comma": {
+			In:     `{"a":1 "b":2}`,
+			HasErr: true,
+		},
+		"missing colon": {
+			In:     `{"a" 1}`,
+			HasErr: true,
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			var out []string
+			end, err := scanner.Members([]byte(tc.In), 0, func(keyStart, keyEnd, valueStart, valueEnd int) error {
+				out = append(out, tc.In[keyStart:keyEnd], tc.In[valueStart:valueEnd])
+				return nil
+			})
+			if tc.HasErr {
+				if err == nil {
+					t.FailNow()
+				}
+				return
+			}
+
+			if err != nil || end != tc.End || len(out) != len(tc.Out) {
+				t.Fatalf("got %v, %v, %v", out, end, err)
+			}
+			for i := range out {
+				if out[i] != tc.Out[i] {
+					t.Fatalf("want %v, got %v", tc.Out[i], out[i])
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package scanner_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/bubunyo/go-jq/scanner"
@@ -23,6 +24,21 @@ func BenchmarkObject(t *testing.B) {
 	}
 }
 
+// indented is a pretty-printed object with large string values, as in logged request bodies
+var indented = []byte("{\n" + strings.Repeat("    \"message\": \""+strings.Repeat("x", 512)+"\",\n    \"count\": 12345,\n", 16) +
+	"    \"nested\": {\n        \"tags\": [\"a\", \"b\"]\n    }\n}")
+
+func BenchmarkObjectIndented(t *testing.B) {
+	t.SetBytes(int64(len(indented)))
+
+	for i := 0; i < t.N; i++ {
+		end, err := scanner.Object(indented, 0)
+		if err != nil || end != len(indented) {
+			t.FailNow()
+		}
+	}
+}
+
 func TestObject(t *testing.T) {
 	testCases := map[string]struct {
 		In     string
@@ -1,6 +1,12 @@
 package scanner
 
-import "errors"
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
 
 // String returns the position of the string that begins at the specified pos
 func String(in []byte, pos int) (int, error) {
@@ -9,28 +15,110 @@ func String(in []byte, pos int) (int, error) {
 		return 0, err
 	}
 
-	max := len(in)
-
 	if v := in[pos]; v != '"' {
 		return 0, newError(pos, v)
 	}
 	pos++
 
+	// most keys and short values end within their first word, which is searched without a call
+	if pos+8 <= len(in) {
+		x := word(in, pos)
+		if q := equal(x, '"'); q != 0 && equal(x, '\\') == 0 {
+			return pos + first(q) + 1, nil
+		}
+	}
+
 	for {
-		switch in[pos] {
-		case '\\':
-			if in[pos+1] == '"' {
-				pos++
-			}
-		case '"':
-			return pos + 1, nil
+		i := bytes.IndexByte(in[pos:], '"')
+		if i < 0 {
+			return 0, errors.New("unclosed string")
+		}
+		pos += i + 1
+		if !escaped(in, pos-1) {
+			return pos, nil
 		}
-		pos++
+	}
+}
+
+// escaped reports whether the quote at pos is escaped, which it is when an odd number of backslashes precede it: the
+// backslashes of a run escape each other in pairs, so that \\" ends a string while \" doesn't
+func escaped(in []byte, pos int) bool {
+	n := 0
+	for pos > 0 && in[pos-1] == '\\' {
+		n++
+		pos--
+	}
+	return n%2 == 1
+}
 
-		if pos >= max {
-			break
+// sameKey reports whether the contents of two JSON strings are equal once their escapes are decoded; contents without
+// escapes are compared as they are
+func sameKey(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	if bytes.IndexByte(a, '\\') < 0 && bytes.IndexByte(b, '\\') < 0 {
+		return false
+	}
+	return bytes.Equal(unescape(a), unescape(b))
+}
+
+// unescape decodes the escapes of the content of a JSON string; invalid escapes are kept as they are
+func unescape(s []byte) []byte {
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			out = append(out, s[i])
+			continue
+		}
+
+		i++
+		switch c := s[i]; c {
+		case '"', '\\', '/':
+			out = append(out, c)
+		case 'b':
+			out = append(out, '\b')
+		case 'f':
+			out = append(out, '\f')
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case 'u':
+			r, n := hexRune(s[i+1:])
+			if n == 0 {
+				out = append(out, '\\', c)
+				continue
+			}
+			i += n
+			// a surrogate pair is written as two escapes
+			if utf16.IsSurrogate(r) && i+2 < len(s) && s[i+1] == '\\' && s[i+2] == 'u' {
+				if low, n := hexRune(s[i+3:]); n > 0 {
+					if d := utf16.DecodeRune(r, low); d != utf8.RuneError {
+						r = d
+						i += 2 + n
+					}
+				}
+			}
+			out = utf8.AppendRune(out, r)
+		default:
+			out = append(out, '\\', c)
 		}
 	}
+	return out
+}
 
-	return 0, errors.New("unclosed string")
+// hexRune decodes the four hexadecimal digits of a \u escape, returning the number of bytes read, or 0 when s doesn't
+// begin with four hexadecimal digits
+func hexRune(s []byte) (rune, int) {
+	if len(s) < 4 {
+		return 0, 0
+	}
+	v, err := strconv.ParseUint(string(s[:4]), 16, 16)
+	if err != nil {
+		return 0, 0
+	}
+	return rune(v), 4
 }
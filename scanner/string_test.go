@@ -1,6 +1,7 @@
 package scanner_test
 
 import (
+	"strings"
 	"testing"
 	"unicode/utf8"
 
@@ -24,6 +25,30 @@ func BenchmarkString(t *testing.B) {
 	}
 }
 
+func BenchmarkStringLong(t *testing.B) {
+	data := []byte(`"` + strings.Repeat("lorem ipsum dolor sit amet ", 160) + `"`)
+	t.SetBytes(int64(len(data)))
+
+	for i := 0; i < t.N; i++ {
+		end, err := scanner.String(data, 0)
+		if err != nil || end != len(data) {
+			t.FailNow()
+		}
+	}
+}
+
+func BenchmarkStringEscaped(t *testing.B) {
+	data := []byte(`"` + strings.Repeat(`say \"hello\" to \u00e9 \\ `, 100) + `"`)
+	t.SetBytes(int64(len(data)))
+
+	for i := 0; i < t.N; i++ {
+		end, err := scanner.String(data, 0)
+		if err != nil || end != len(data) {
+			t.FailNow()
+		}
+	}
+}
+
 func TestString(t *testing.T) {
 	testCases := map[string]struct {
 		In     string
@@ -50,10 +75,46 @@ func TestString(t *testing.T) {
 			In:     `"hello\"`,
 			HasErr: true,
 		},
+		"escaped backslash": {
+			In:  `"hello\\", "world"`,
+			Out: `"hello\\"`,
+		},
+		"trailing backslash": {
+			In:     `"hello\`,
+			HasErr: true,
+		},
+		"lone quote": {
+			In:     `"`,
+			HasErr: true,
+		},
 		"utf8": {
 			In:  `"生日快乐"`,
 			Out: `"生日快乐"`,
 		},
+		"short in word": {
+			In:  `"ab", "cdefgh"`,
+			Out: `"ab"`,
+		},
+		"escape in first word": {
+			In:  `"a\"b", "cdefgh"`,
+			Out: `"a\"b"`,
+		},
+		"long": {
+			In:  `"hello wonderful world", "again"`,
+			Out: `"hello wonderful world"`,
+		},
+		"long escaped": {
+			In:  `"hello wonderful \"world\"", "again"`,
+			Out: `"hello wonderful \"world\""`,
+		},
+		"backslash runs": {
+			In:  `"hello world \\\\\\\"\\\\", "again"`,
+			Out: `"hello world \\\\\\\"\\\\"`,
+		},
+		"long unclosed": {
+			In:     `"hello wonderful world\"`,
+			HasErr: true,
+		},
 	}
 
 	for label, tc := range testCases {
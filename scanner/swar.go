@@ -0,0 +1,40 @@
+package scanner
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// The scanner examines 8 bytes at a time where it can, treating them as a single word (SWAR, SIMD within a register)
+
+const (
+	ones  = 0x0101010101010101 // the lowest bit of each byte
+	highs = 0x8080808080808080 // the highest bit of each byte
+	lows  = 0x7f7f7f7f7f7f7f7f // all but the highest bit of each byte
+)
+
+// word returns the 8 bytes of in beginning at pos, the first in the lowest byte
+func word(in []byte, pos int) uint64 {
+	return binary.LittleEndian.Uint64(in[pos:])
+}
+
+// zeros sets the highest bit of each byte of x that is zero, and clears every other bit; unlike the classic
+// (x - ones) & ^x & highs, it reports no false positives, so every byte can be tested
+func zeros(x uint64) uint64 {
+	return ^((x&lows + lows) | x | lows)
+}
+
+// equal sets the highest bit of each byte of x that is c, and clears every other bit
+func equal(x uint64, c byte) uint64 {
+	return zeros(x ^ ones*uint64(c))
+}
+
+// first returns the position within its word of the first byte a mask returned by equal or zeros marks
+func first(mask uint64) int {
+	return bits.TrailingZeros64(mask) >> 3
+}
+
+// spaces reports whether all 8 bytes of x are JSON whitespace
+func spaces(x uint64) bool {
+	return equal(x, ' ')|equal(x, '\n')|equal(x, '\t')|equal(x, '\r') == highs
+}
@@ -0,0 +1,59 @@
+package scanner
+
+import "testing"
+
+func TestEqual(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		C        byte
+		Expected int // the position of the first match, or -1
+	}{
+		"first":      {In: `"abcdefg`, C: '"', Expected: 0},
+		"last":       {In: `abcdefg"`, C: '"', Expected: 7},
+		"several":    {In: `ab\c\de"`, C: '\\', Expected: 2},
+		"none":       {In: `abcdefgh`, C: '"', Expected: -1},
+		"high bytes": {In: "\xa2\xa2\xa2\xa2\xa2\xa2\xa2\x22", C: '"', Expected: 7},
+		"zero":       {In: "abc\x00defg", C: 0, Expected: 3},
+		"adjacent":   {In: "\x01\x00\x00\x00\x00\x00\x00\x00", C: 1, Expected: 0},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			mask := equal(word([]byte(tc.In), 0), tc.C)
+			if tc.Expected < 0 {
+				if mask != 0 {
+					t.Fatalf("expected no match, got %x", mask)
+				}
+				return
+			}
+			if got := first(mask); got != tc.Expected {
+				t.Fatalf("expected %v, got %v", tc.Expected, got)
+			}
+
+			// every byte matching is marked, and no other
+			for i := 0; i < 8; i++ {
+				if marked := mask>>(8*i)&0xff == 0x80; marked != (tc.In[i] == tc.C) {
+					t.Fatalf("byte %v marked %v", i, marked)
+				}
+			}
+		})
+	}
+}
+
+func TestSpaces(t *testing.T) {
+	testCases := map[string]bool{
+		"        ":         true,
+		" \t\r\n \t\r\n":   true,
+		"       !":         false,
+		"!       ":         false,
+		"   \v    ":        false,
+		"\xa0\xa0\xa0\xa0": false,
+	}
+
+	for in, expected := range testCases {
+		in := in + "        "
+		if spaces(word([]byte(in), 0)) != expected {
+			t.Fatalf("%q: expected %v", in, expected)
+		}
+	}
+}
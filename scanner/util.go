@@ -8,37 +8,53 @@ import (
 )
 
 var (
-	errUnexpectedEOF    = errors.New("unexpected EOF")
-	errKeyNotFound      = errors.New("key not found")
-	errIndexOutOfBounds = errors.New("index out of bounds")
-	errToLessThanFrom   = errors.New("to index less than from index")
-	errFromOutOfBounds  = errors.New("from index out of bounds")
-	errUnexpectedValue  = errors.New("unexpected value")
+	// ErrKeyNotFound is returned by FindKey when the object doesn't contain the key requested
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrIndexOutOfBounds is returned when an array doesn't contain the index requested
+	ErrIndexOutOfBounds = errors.New("index out of bounds")
+
+	errToLessThanFrom  = errors.New("to index less than from index")
+	errFromOutOfBounds = errors.New("from index out of bounds")
+	errUnexpectedValue = errors.New("unexpected value")
 )
 
+// asciiSpace holds the ASCII characters unicode.IsSpace accepts
+var asciiSpace = [utf8.RuneSelf]bool{'\t': true, '\n': true, '\v': true, '\f': true, '\r': true, ' ': true}
+
+// skipSpace returns the position of the first character from pos that isn't whitespace. ASCII is checked without
+// decoding it, and runs of whitespace such as indentation are skipped a word at a time.
 func skipSpace(in []byte, pos int) (int, error) {
-	for {
-		r, size := utf8.DecodeRune(in[pos:])
-		if size == 0 {
-			return 0, errUnexpectedEOF
+	for pos < len(in) {
+		c := in[pos]
+		if c < utf8.RuneSelf {
+			if !asciiSpace[c] {
+				return pos, nil
+			}
+			pos++
+			for pos+8 <= len(in) && spaces(word(in, pos)) {
+				pos += 8
+			}
+			continue
 		}
+
+		r, size := utf8.DecodeRune(in[pos:])
 		if !unicode.IsSpace(r) {
-			break
+			return pos, nil
 		}
 		pos += size
 	}
 
-	return pos, nil
+	return 0, opErr{pos: pos, msg: "unexpected EOF"}
 }
 
 func expect(in []byte, pos int, content ...byte) (int, error) {
 	if pos+len(content) > len(in) {
-		return 0, errUnexpectedEOF
+		return 0, opErr{pos: len(in), msg: "unexpected EOF"}
 	}
 
 	for _, b := range content {
 		if v := in[pos]; v != b {
-			return 0, errUnexpectedValue
+			return 0, opErr{pos: pos, msg: "unexpected value", content: string([]byte{v})}
 		}
 		pos++
 	}
@@ -2,17 +2,63 @@ package scanner
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 )
 
 func TestSkipSpace(t *testing.T) {
-	content := []byte(" \t\n\r!")
-	end, err := skipSpace(content, 0)
-	if err != nil {
-		t.FailNow()
+	testCases := map[string]struct {
+		In       string
+		Expected int
+		HasError bool
+	}{
+		"ascii": {
+			In:       " \t\n\r!",
+			Expected: 4,
+		},
+		"none": {
+			In:       "!",
+			Expected: 0,
+		},
+		"indented": {
+			In:       "\n" + strings.Repeat(" ", 37) + "!",
+			Expected: 38,
+		},
+		"mixed run": {
+			In:       "\n\t\t \r\n  \t \v\f  !",
+			Expected: 14,
+		},
+		"unicode": {
+			In:       "  \u00a0\u2003!",
+			Expected: 7,
+		},
+		"not space": {
+			In:       "  生日",
+			Expected: 2,
+		},
+		"unexpected EOF": {
+			In:       strings.Repeat(" ", 20),
+			HasError: true,
+		},
+		"empty": {
+			In:       "",
+			HasError: true,
+		},
 	}
-	if end+1 != len(content) {
-		t.FailNow()
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			pos, err := skipSpace([]byte(tc.In), 0)
+			if tc.HasError {
+				if err == nil {
+					t.FailNow()
+				}
+				return
+			}
+			if err != nil || pos != tc.Expected {
+				t.Fatalf("expected %v, got %v (%v)", tc.Expected, pos, err)
+			}
+		})
 	}
 }
 
@@ -0,0 +1,281 @@
+package scanner
+
+import "unicode/utf8"
+
+// The functions of this file enforce the grammar of RFC 8259 exactly, where the rest of the scanner is lenient in
+// favour of speed: numbers must be well formed, literals and numbers must end where a value may, only space, tab,
+// line feed and carriage return are whitespace, commas must separate the elements and members of containers and
+// mustn't follow the last of them, and strings must be valid UTF-8 without unescaped control characters or unknown
+// escapes.
+
+// Validate reports whether in is a single JSON value, optionally surrounded by whitespace, as RFC 8259 defines it
+func Validate(in []byte) error {
+	pos, err := StrictAny(in, 0)
+	if err != nil {
+		return err
+	}
+	if pos = strictSpace(in, pos); pos < len(in) {
+		return newError(pos, in[pos])
+	}
+	return nil
+}
+
+// StrictAny returns the position of the end of the value that begins at pos, as Any does, enforcing RFC 8259
+func StrictAny(in []byte, pos int) (int, error) {
+	pos = strictSpace(in, pos)
+	if pos >= len(in) {
+		return 0, opErr{pos: pos, msg: "unexpected EOF"}
+	}
+
+	switch c := in[pos]; c {
+	case '{':
+		return strictObject(in, pos+1)
+	case '[':
+		return strictArray(in, pos+1)
+	case '"':
+		return strictString(in, pos+1)
+	case 't':
+		return strictLiteral(in, pos, t)
+	case 'f':
+		return strictLiteral(in, pos, f)
+	case 'n':
+		return strictLiteral(in, pos, n)
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return strictNumber(in, pos)
+	default:
+		return 0, newError(pos, c)
+	}
+}
+
+// strictSpace returns the position of the first character from pos that isn't whitespace, or len(in)
+func strictSpace(in []byte, pos int) int {
+	for pos < len(in) {
+		switch in[pos] {
+		case ' ', '\t', '\n', '\r':
+			pos++
+			for pos+8 <= len(in) && spaces(word(in, pos)) {
+				pos += 8
+			}
+		default:
+			return pos
+		}
+	}
+	return pos
+}
+
+// delimited checks that the literal or number ending at pos is followed by something that may follow a value, so that
+// trueX and 1.2.3 aren't taken for true and 1.2
+func delimited(in []byte, pos int) (int, error) {
+	if pos < len(in) {
+		switch c := in[pos]; c {
+		case ' ', '\t', '\n', '\r', ',', ']', '}':
+		default:
+			return 0, newError(pos, c)
+		}
+	}
+	return pos, nil
+}
+
+func strictLiteral(in []byte, pos int, literal []byte) (int, error) {
+	pos, err := expect(in, pos, literal...)
+	if err != nil {
+		return 0, err
+	}
+	return delimited(in, pos)
+}
+
+// strictNumber matches -? (0 | [1-9][0-9]*) (. [0-9]+)? ([eE] [+-]? [0-9]+)?
+func strictNumber(in []byte, pos int) (int, error) {
+	if in[pos] == '-' {
+		pos++
+	}
+
+	switch {
+	case pos < len(in) && in[pos] == '0':
+		pos++
+	case pos < len(in) && isDigit(in[pos]):
+		pos = digits(in, pos)
+	default:
+		return 0, numberError(in, pos)
+	}
+
+	if pos < len(in) && in[pos] == '.' {
+		pos++
+		if pos >= len(in) || !isDigit(in[pos]) {
+			return 0, numberError(in, pos)
+		}
+		pos = digits(in, pos)
+	}
+
+	if pos < len(in) && (in[pos] == 'e' || in[pos] == 'E') {
+		pos++
+		if pos < len(in) && (in[pos] == '+' || in[pos] == '-') {
+			pos++
+		}
+		if pos >= len(in) || !isDigit(in[pos]) {
+			return 0, numberError(in, pos)
+		}
+		pos = digits(in, pos)
+	}
+
+	return delimited(in, pos)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// digits returns the position of the first character from pos that isn't a digit
+func digits(in []byte, pos int) int {
+	for pos < len(in) && isDigit(in[pos]) {
+		pos++
+	}
+	return pos
+}
+
+func numberError(in []byte, pos int) error {
+	if pos >= len(in) {
+		return opErr{pos: pos, msg: "unexpected EOF"}
+	}
+	return newError(pos, in[pos])
+}
+
+// strictString returns the position of the end of the string whose content begins at pos. Words of printable ASCII
+// without quotes or backslashes, which most strings are made of, are passed over 8 bytes at a time.
+func strictString(in []byte, pos int) (int, error) {
+	for pos < len(in) {
+		if pos+8 <= len(in) {
+			x := word(in, pos)
+			// x&highs marks bytes beyond ASCII; (x - ones*' ') & ^x & highs is non-zero when a byte is a control
+			// character, though it may mark other bytes once one is
+			if x&highs|(x-ones*' ')&^x&highs|equal(x, '"')|equal(x, '\\') == 0 {
+				pos += 8
+				continue
+			}
+		}
+
+		switch c := in[pos]; {
+		case c == '"':
+			return pos + 1, nil
+		case c == '\\':
+			end, err := strictEscape(in, pos)
+			if err != nil {
+				return 0, err
+			}
+			pos = end
+		case c < ' ':
+			return 0, opErr{pos: pos, msg: "control character in string"}
+		case c < utf8.RuneSelf:
+			pos++
+		default:
+			r, size := utf8.DecodeRune(in[pos:])
+			if r == utf8.RuneError && size == 1 {
+				return 0, opErr{pos: pos, msg: "invalid UTF-8 in string"}
+			}
+			pos += size
+		}
+	}
+	return 0, opErr{pos: pos, msg: "unclosed string"}
+}
+
+// strictEscape returns the position following the escape sequence that begins at pos
+func strictEscape(in []byte, pos int) (int, error) {
+	if pos+1 >= len(in) {
+		return 0, opErr{pos: pos, msg: "unclosed string"}
+	}
+
+	switch in[pos+1] {
+	case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+		return pos + 2, nil
+	case 'u':
+		if pos+6 > len(in) {
+			return 0, opErr{pos: pos, msg: "unclosed string"}
+		}
+		for _, c := range in[pos+2 : pos+6] {
+			if !isDigit(c) && (c|0x20 < 'a' || c|0x20 > 'f') {
+				return 0, opErr{pos: pos, msg: "invalid escape", content: string(in[pos : pos+6])}
+			}
+		}
+		return pos + 6, nil
+	default:
+		return 0, opErr{pos: pos, msg: "invalid escape", content: string(in[pos : pos+2])}
+	}
+}
+
+// strictArray returns the position of the end of the array whose content begins at pos
+func strictArray(in []byte, pos int) (int, error) {
+	pos = strictSpace(in, pos)
+	if pos < len(in) && in[pos] == ']' {
+		return pos + 1, nil
+	}
+
+	var err error
+	for {
+		pos, err = StrictAny(in, pos)
+		if err != nil {
+			return 0, err
+		}
+
+		pos = strictSpace(in, pos)
+		if pos >= len(in) {
+			return 0, opErr{pos: pos, msg: "unexpected EOF"}
+		}
+		switch c := in[pos]; c {
+		case ',':
+			pos++
+		case ']':
+			return pos + 1, nil
+		default:
+			return 0, newError(pos, c)
+		}
+	}
+}
+
+// strictObject returns the position of the end of the object whose content begins at pos
+func strictObject(in []byte, pos int) (int, error) {
+	pos = strictSpace(in, pos)
+	if pos < len(in) && in[pos] == '}' {
+		return pos + 1, nil
+	}
+
+	var err error
+	for {
+		pos = strictSpace(in, pos)
+		if pos >= len(in) {
+			return 0, opErr{pos: pos, msg: "unexpected EOF"}
+		}
+		if c := in[pos]; c != '"' {
+			return 0, newError(pos, c)
+		}
+		pos, err = strictString(in, pos+1)
+		if err != nil {
+			return 0, err
+		}
+
+		pos = strictSpace(in, pos)
+		if pos >= len(in) {
+			return 0, opErr{pos: pos, msg: "unexpected EOF"}
+		}
+		if c := in[pos]; c != ':' {
+			return 0, newError(pos, c)
+		}
+
+		pos, err = StrictAny(in, pos+1)
+		if err != nil {
+			return 0, err
+		}
+
+		pos = strictSpace(in, pos)
+		if pos >= len(in) {
+			return 0, opErr{pos: pos, msg: "unexpected EOF"}
+		}
+		switch c := in[pos]; c {
+		case ',':
+			pos++
+		case '}':
+			return pos + 1, nil
+		default:
+			return 0, newError(pos, c)
+		}
+	}
+}
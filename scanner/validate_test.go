@@ -0,0 +1,112 @@
+package scanner_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bubunyo/go-jq/scanner"
+)
+
+func BenchmarkValidate(t *testing.B) {
+	data := []byte(`{"id": 1024, "name": "` + strings.Repeat("lorem ipsum ", 20) + `", "tags": ["a", "b", "生日"], ` +
+		`"price": -12.5e3, "active": true, "owner": null, "nested": {"escaped": "say \"hi\"\né"}}`)
+	t.SetBytes(int64(len(data)))
+	t.ReportAllocs()
+
+	for i := 0; i < t.N; i++ {
+		if err := scanner.Validate(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := map[string]struct {
+		In     string
+		HasErr bool
+	}{
+		"object":             {In: `{"a": [1, 2.5, -0.5e-3, 1E+2], "b": {"c": null}, "d": [true, false]}`},
+		"empty containers":   {In: `[{}, [], {"a": []}]`},
+		"whitespace":         {In: " \t\r\n[ 1 ,\n\t2 ]\r\n "},
+		"scalar":             {In: `"hello"`},
+		"zero":               {In: `0`},
+		"escapes":            {In: `"\" \\ \/ \b \f \n \r \t é 😀"`},
+		"utf8":               {In: `"生日快乐 🎂"`},
+		"long string":        {In: `"` + strings.Repeat("abcdefgh", 10) + `"`},
+		"empty":              {In: ``, HasErr: true},
+		"only whitespace":    {In: "  \n", HasErr: true},
+		"plus":               {In: `+1`, HasErr: true},
+		"leading dot":        {In: `.5`, HasErr: true},
+		"two dots":           {In: `1.2.3`, HasErr: true},
+		"signs":              {In: `--e`, HasErr: true},
+		"leading zero":       {In: `01`, HasErr: true},
+		"trailing dot":       {In: `1.`, HasErr: true},
+		"empty exponent":     {In: `1e+`, HasErr: true},
+		"minus alone":        {In: `-`, HasErr: true},
+		"true suffix":        {In: `trueX`, HasErr: true},
+		"false in array":     {In: `[falsey]`, HasErr: true},
+		"truncated literal":  {In: `nul`, HasErr: true},
+		"nbsp":               {In: "\u00a0[]", HasErr: true},
+		"line separator":     {In: "[1,\u20282]", HasErr: true},
+		"vertical tab":       {In: "[1,\v2]", HasErr: true},
+		"trailing comma":     {In: `[1, 2,]`, HasErr: true},
+		"trailing member":    {In: `{"a": 1,}`, HasErr: true},
+		"missing comma":      {In: `[1 2]`, HasErr: true},
+		"missing member":     {In: `{"a": 1 "b": 2}`, HasErr: true},
+		"leading comma":      {In: `[,1]`, HasErr: true},
+		"unquoted key":       {In: `{a: 1}`, HasErr: true},
+		"missing colon":      {In: `{"a" 1}`, HasErr: true},
+		"missing value":      {In: `{"a":}`, HasErr: true},
+		"unclosed array":     {In: `[1, 2`, HasErr: true},
+		"unclosed object":    {In: `{"a": 1`, HasErr: true},
+		"unclosed string":    {In: `"hello`, HasErr: true},
+		"trailing content":   {In: `{} {}`, HasErr: true},
+		"control character":  {In: "\"a\tb\"", HasErr: true},
+		"control after word": {In: "\"abcdefgh\x01\"", HasErr: true},
+		"unknown escape":     {In: `"\x41"`, HasErr: true},
+		"short unicode":      {In: `"\u12"`, HasErr: true},
+		"bad hex":            {In: `"\u12G4"`, HasErr: true},
+		"invalid utf8":       {In: "\"abc\xff\"", HasErr: true},
+		"overlong utf8":      {In: "\"\xc0\xaf\"", HasErr: true},
+		"encoded surrogate":  {In: "\"\xed\xa0\x80\"", HasErr: true},
+		"truncated utf8":     {In: "\"\xe7\x94\"", HasErr: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			err := scanner.Validate([]byte(tc.In))
+			if tc.HasErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.HasErr, err)
+			}
+		})
+	}
+}
+
+func TestStrictAny(t *testing.T) {
+	testCases := map[string]struct {
+		In     string
+		Out    string
+		HasErr bool
+	}{
+		"number":  {In: `-1.5e3, 2`, Out: `-1.5e3`},
+		"literal": {In: `true]`, Out: `true`},
+		"string":  {In: `"a\"b", "c"`, Out: `"a\"b"`},
+		"array":   {In: ` [1, [2]] , 3`, Out: ` [1, [2]]`},
+		"garbage": {In: `1x`, HasErr: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			end, err := scanner.StrictAny([]byte(tc.In), 0)
+			if tc.HasErr {
+				if err == nil {
+					t.FailNow()
+				}
+				return
+			}
+			if err != nil || tc.In[:end] != tc.Out {
+				t.Fatalf("expected %v, got %v (%v)", tc.Out, tc.In[:end], err)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package scanner
+
+// Token identifies the part of a value reported by Walk
+type Token int
+
+const (
+	// Open is the opening bracket of an array or object
+	Open Token = iota
+	// Key is the key of an object member, including its quotes
+	Key
+	// Scalar is a string, number, boolean or null
+	Scalar
+	// Close is the closing bracket of an array or object
+	Close
+)
+
+// Walk calls fn with each token of the value that begins at pos, in document order and in a single pass, and returns
+// the position of the end of the value; walking stops at the first error returned by fn
+func Walk(in []byte, pos int, fn func(t Token, start, end int) error) (int, error) {
+	pos, err := skipSpace(in, pos)
+	if err != nil {
+		return 0, err
+	}
+
+	switch in[pos] {
+	case '[':
+		return walkArray(in, pos, fn)
+	case '{':
+		return walkObject(in, pos, fn)
+	default:
+		end, err := Any(in, pos)
+		if err != nil {
+			return 0, err
+		}
+		if err := fn(Scalar, pos, end); err != nil {
+			return 0, err
+		}
+		return end, nil
+	}
+}
+
+func walkArray(in []byte, pos int, fn func(t Token, start, end int) error) (int, error) {
+	if err := fn(Open, pos, pos+1); err != nil {
+		return 0, err
+	}
+
+	pos, err := skipSpace(in, pos+1)
+	if err != nil {
+		return 0, err
+	}
+	if in[pos] == ']' {
+		return closing(pos, fn)
+	}
+
+	for {
+		pos, err = Walk(in, pos, fn)
+		if err != nil {
+			return 0, err
+		}
+
+		pos, err = skipSpace(in, pos)
+		if err != nil {
+			return 0, err
+		}
+
+		switch v := in[pos]; v {
+		case ',':
+			pos++
+		case ']':
+			return closing(pos, fn)
+		default:
+			return 0, newError(pos, v)
+		}
+	}
+}
+
+func walkObject(in []byte, pos int, fn func(t Token, start, end int) error) (int, error) {
+	if err := fn(Open, pos, pos+1); err != nil {
+		return 0, err
+	}
+
+	pos, err := skipSpace(in, pos+1)
+	if err != nil {
+		return 0, err
+	}
+	if in[pos] == '}' {
+		return closing(pos, fn)
+	}
+
+	for {
+		pos, err = skipSpace(in, pos)
+		if err != nil {
+			return 0, err
+		}
+
+		keyStart := pos
+		pos, err = String(in, pos)
+		if err != nil {
+			return 0, err
+		}
+		if err := fn(Key, keyStart, pos); err != nil {
+			return 0, err
+		}
+
+		pos, err = skipSpace(in, pos)
+		if err != nil {
+			return 0, err
+		}
+		pos, err = expect(in, pos, ':')
+		if err != nil {
+			return 0, err
+		}
+
+		pos, err = Walk(in, pos, fn)
+		if err != nil {
+			return 0, err
+		}
+
+		pos, err = skipSpace(in, pos)
+		if err != nil {
+			return 0, err
+		}
+
+		switch v := in[pos]; v {
+		case ',':
+			pos++
+		case '}':
+			return closing(pos, fn)
+		default:
+			return 0, newError(pos, v)
+		}
+	}
+}
+
+func closing(pos int, fn func(t Token, start, end int) error) (int, error) {
+	if err := fn(Close, pos, pos+1); err != nil {
+		return 0, err
+	}
+	return pos + 1, nil
+}
@@ -0,0 +1,106 @@
+package scanner_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bubunyo/go-jq/scanner"
+)
+
+func BenchmarkWalk(t *testing.B) {
+	data := []byte(`{"a":[1,{"b":true}],"c":"hello"}`)
+	fn := func(scanner.Token, int, int) error { return nil }
+
+	for i := 0; i < t.N; i++ {
+		end, err := scanner.Walk(data, 0, fn)
+		if err != nil {
+			t.FailNow()
+			return
+		}
+
+		if end != len(data) {
+			t.FailNow()
+			return
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	testCases := map[string]struct {
+		In     string
+		Out    []string
+		End    int
+		HasErr bool
+	}{
+		"scalar": {
+			In:  ` 12 `,
+			Out: []string{`s:12`},
+			End: 3,
+		},
+		"empty": {
+			In:  `[ ]`,
+			Out: []string{`o:[`, `c:]`},
+			End: 3,
+		},
+		"nested": {
+			In:  `{"a": [1, {"b": null}], "c": "x"}`,
+			Out: []string{`o:{`, `k:"a"`, `o:[`, `s:1`, `o:{`, `k:"b"`, `s:null`, `c:}`, `c:]`, `k:"c"`, `s:"x"`, `c:}`},
+			End: 33,
+		},
+		"missing colon": {
+			In:     `{"a" 1}`,
+			HasErr: true,
+		},
+		"missing comma": {
+			In:     `[1 2]`,
+			HasErr: true,
+		},
+		"unclosed": {
+			In:     `{"a":[1}`,
+			HasErr: true,
+		},
+	}
+
+	names := map[scanner.Token]string{scanner.Open: "o", scanner.Key: "k", scanner.Scalar: "s", scanner.Close: "c"}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			var out []string
+			end, err := scanner.Walk([]byte(tc.In), 0, func(tok scanner.Token, start, end int) error {
+				out = append(out, names[tok]+":"+tc.In[start:end])
+				return nil
+			})
+			if tc.HasErr {
+				if err == nil {
+					t.FailNow()
+				}
+				return
+			}
+
+			if err != nil || end != tc.End || len(out) != len(tc.Out) {
+				t.Fatalf("got %v, %v, %v", out, end, err)
+			}
+			for i := range out {
+				if out[i] != tc.Out[i] {
+					t.Fatalf("want %v, got %v", tc.Out[i], out[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWalkStop(t *testing.T) {
+	errStop := errors.New("stop")
+
+	count := 0
+	_, err := scanner.Walk([]byte(`[1,2,3]`), 0, func(tok scanner.Token, start, end int) error {
+		if tok == scanner.Scalar {
+			count++
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop || count != 1 {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,288 @@
+package jq
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+)
+
+func searchBuiltins() {
+	define("contains", 1, function(func(in []byte, args [][]byte) ([]byte, error) {
+		ok, err := containsValue(in, args[0])
+		return boolValue(ok), err
+	}))
+
+	define("has", 1, function(func(in []byte, args [][]byte) ([]byte, error) {
+		ok, err := has(in, args[0])
+		return boolValue(ok), err
+	}))
+
+	define("indices", 1, function(func(in []byte, args [][]byte) ([]byte, error) {
+		return indices(in, args[0])
+	}))
+
+	define("index", 1, function(func(in []byte, args [][]byte) ([]byte, error) {
+		return indexAt(in, args[0], false)
+	}))
+
+	define("rindex", 1, function(func(in []byte, args [][]byte) ([]byte, error) {
+		return indexAt(in, args[0], true)
+	}))
+
+	iterate := &iterateNode{target: identityNode{}}
+
+	define("any", 0, quantifier(true, func(args []node) (node, node) { return iterate, identityNode{} }))
+	define("any", 1, quantifier(true, func(args []node) (node, node) { return iterate, args[0] }))
+	define("any", 2, quantifier(true, func(args []node) (node, node) { return args[0], args[1] }))
+	define("all", 0, quantifier(false, func(args []node) (node, node) { return iterate, identityNode{} }))
+	define("all", 1, quantifier(false, func(args []node) (node, node) { return iterate, args[0] }))
+	define("all", 2, quantifier(false, func(args []node) (node, node) { return args[0], args[1] }))
+}
+
+// quantifier builds any and all, which evaluate cond against each value produced by a generator and stop at the
+// first value for which cond is true, for any, or false, for all; operands selects the generator and condition from
+// the arguments
+func quantifier(want bool, operands func(args []node) (node, node)) *builtin {
+	return &builtin{
+		eval: func(e *env, in []byte, args []node, fn func([]byte) error) error {
+			gen, cond := operands(args)
+
+			found := false
+			done := &stop{}
+			err := gen.eval(e, in, func(v []byte) error {
+				return cond.eval(e, v, func(c []byte) error {
+					if truthy(c) == want {
+						found = true
+						return done
+					}
+					return nil
+				})
+			})
+			if err != nil && err != done {
+				return err
+			}
+			return fn(boolValue(found == want))
+		},
+		aggregate: true,
+	}
+}
+
+// containsValue implements contains: strings contain their substrings, arrays contain the arrays whose elements are
+// each contained by one of theirs, objects contain the objects whose values are contained by their values of the
+// same key, and any other value contains only values equal to it
+func containsValue(a, b []byte) (bool, error) {
+	ka, kb, err := kinds(a, b)
+	if err != nil {
+		return false, err
+	}
+	if ka != kb {
+		return false, fmt.Errorf("%v (%s) and %v (%s) cannot have their containment checked", ka, truncate(a), kb, truncate(b))
+	}
+	return contains(a, b, ka)
+}
+
+func contains(a, b []byte, k kind) (bool, error) {
+	switch k {
+	case kindString:
+		sa, err := unquote(a)
+		if err != nil {
+			return false, err
+		}
+		sb, err := unquote(b)
+		if err != nil {
+			return false, err
+		}
+		return bytes.Contains(sa, sb), nil
+
+	case kindArray:
+		ea, err := elements(a)
+		if err != nil {
+			return false, err
+		}
+		eb, err := elements(b)
+		if err != nil {
+			return false, err
+		}
+		for _, vb := range eb {
+			if ok, err := anyContains(ea, vb); err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+
+	case kindObject:
+		ma, err := members(a)
+		if err != nil {
+			return false, err
+		}
+		builder := objectBuilder{members: ma}
+		mb, err := members(b)
+		if err != nil {
+			return false, err
+		}
+		for _, m := range mb {
+			va, ok := builder.get(m.key)
+			if !ok {
+				return false, nil
+			}
+			if ok, err := nestedContains(va, m.value); err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+
+	default:
+		c, err := compareValues(a, b)
+		return c == 0, err
+	}
+}
+
+// nestedContains is contains for values within arrays and objects, where values of different kinds are simply not
+// contained
+func nestedContains(a, b []byte) (bool, error) {
+	ka, kb, err := kinds(a, b)
+	if err != nil || ka != kb {
+		return false, err
+	}
+	return contains(a, b, ka)
+}
+
+func anyContains(values [][]byte, b []byte) (bool, error) {
+	for _, v := range values {
+		if ok, err := nestedContains(v, b); err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// has reports whether the object in has the string key k, or the array in has an element at the index k
+func has(in, k []byte) (bool, error) {
+	kin, kk, err := kinds(in, k)
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case kin == kindObject && kk == kindString:
+		found := false
+		err := eachMember(in, func(raw, _ []byte) error {
+			found = found || sameKey(raw, k)
+			return nil
+		})
+		return found, err
+	case kin == kindArray && kk == kindNumber:
+		f, err := toFloat(k)
+		if err != nil {
+			return false, err
+		}
+		n, err := count(in)
+		return f >= 0 && f < float64(n), err
+	default:
+		return false, fmt.Errorf("cannot check whether %v has a %v key", kin, kk)
+	}
+}
+
+// indices returns the positions at which i occurs within in: codepoint offsets of a substring within a string, or the
+// indices at which an element, or a run of elements given as an array, occurs within an array; null is returned when
+// there is nothing to search for
+func indices(in, i []byte) ([]byte, error) {
+	kin, ki, err := kinds(in, i)
+	if err != nil {
+		return nil, err
+	}
+
+	var positions []int
+	switch {
+	case kin == kindNull:
+		return nullValue, nil
+	case kin == kindString && ki == kindString:
+		s, err := unquote(in)
+		if err != nil {
+			return nil, err
+		}
+		sub, err := unquote(i)
+		if err != nil {
+			return nil, err
+		}
+		if len(sub) == 0 {
+			return nullValue, nil
+		}
+		for offset := 0; offset+len(sub) <= len(s); offset++ {
+			if bytes.HasPrefix(s[offset:], sub) {
+				positions = append(positions, utf8.RuneCount(s[:offset]))
+			}
+		}
+	case kin == kindArray:
+		values, err := elements(in)
+		if err != nil {
+			return nil, err
+		}
+		run := [][]byte{i}
+		if ki == kindArray {
+			if run, err = elements(i); err != nil {
+				return nil, err
+			}
+			if len(run) == 0 {
+				return nullValue, nil
+			}
+		}
+		if positions, err = subsequences(values, run); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("cannot search %v for %v", kin, ki)
+	}
+
+	dst := make([]byte, 0, len(positions)*4+2)
+	dst = append(dst, '[')
+	for n, p := range positions {
+		if n > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendNumber(dst, float64(p))
+	}
+	return append(dst, ']'), nil
+}
+
+// subsequences returns each index at which run occurs within values
+func subsequences(values, run [][]byte) ([]int, error) {
+	var positions []int
+	for start := 0; start+len(run) <= len(values); start++ {
+		match := true
+		for j, v := range run {
+			c, err := compareValues(values[start+j], v)
+			if err != nil {
+				return nil, err
+			}
+			if c != 0 {
+				match = false
+				break
+			}
+		}
+		if match {
+			positions = append(positions, start)
+		}
+	}
+	return positions, nil
+}
+
+// indexAt returns the first, or last, position at which i occurs within in, or null if it doesn't occur
+func indexAt(in, i []byte, last bool) ([]byte, error) {
+	positions, err := indices(in, i)
+	if err != nil || positions[0] == 'n' {
+		return positions, err
+	}
+
+	values, err := elements(positions)
+	switch {
+	case err != nil:
+		return nil, err
+	case len(values) == 0:
+		return nullValue, nil
+	case last:
+		return values[len(values)-1], nil
+	default:
+		return values[0], nil
+	}
+}
@@ -0,0 +1,81 @@
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkContains(t *testing.B) {
+	op := jq.Must(jq.Parse(`contains({"roles": ["admin"]})`))
+	data := []byte(`{"name": "alice", "roles": ["user", "admin"], "active": true}`)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+		HasError bool
+	}{
+		"contains string":       {In: `"foobar"`, Op: `contains("bar")`, Expected: `true`},
+		"contains array":        {In: `["foobar", "foobaz", "blarp"]`, Op: `contains(["baz", "bar"])`, Expected: `true`},
+		"contains array false":  {In: `["foobar", "foobaz", "blarp"]`, Op: `contains(["bazzzz", "bar"])`, Expected: `false`},
+		"contains object":       {In: `{"foo": 12, "bar": [1, 2, {"barp": 12, "blip": 13}]}`, Op: `contains({foo: 12, bar: [{barp: 12}]})`, Expected: `true`},
+		"contains object false": {In: `{"foo": 12, "bar": [1, 2, {"barp": 12, "blip": 13}]}`, Op: `contains({foo: 12, bar: [{barp: 15}]})`, Expected: `false`},
+		"contains nested kinds": {In: `[1, "a"]`, Op: `contains([[1]])`, Expected: `false`},
+		"contains number":       {In: `1`, Op: `contains(1.0)`, Expected: `true`},
+		"contains mismatch":     {In: `{}`, Op: `contains([])`, HasError: true},
+		"inside":                {In: `["bar"]`, Op: `inside(["foobar", "baz"])`, Expected: `true`},
+		"has key":               {In: `{"a": 1}`, Op: `has("a"), has("b")`, Expected: `[true,false]`},
+		"has escaped key":       {In: `{"\u0061": 1}`, Op: `has("a")`, Expected: `true`},
+		"has index":             {In: `[1, 2]`, Op: `has(1), has(2), has(-1)`, Expected: `[true,false,false]`},
+		"has invalid":           {In: `[1]`, Op: `has("a")`, HasError: true},
+		"in":                    {In: `["a", "c"]`, Op: `map(in({"a": 1, "b": 2}))`, Expected: `[true,false]`},
+		"IN":                    {In: `"admin"`, Op: `IN("user", "admin")`, Expected: `true`},
+		"IN missing":            {In: `"root"`, Op: `IN("user", "admin")`, Expected: `false`},
+		"IN source":             {In: `{"roles": ["a", "b"]}`, Op: `IN(.roles[]; "b", "c")`, Expected: `true`},
+		"indices string":        {In: `"a,b, cd, efg"`, Op: `indices(", ")`, Expected: `[3,7]`},
+		"indices overlap":       {In: `"aaa"`, Op: `indices("aa")`, Expected: `[0,1]`},
+		"indices unicode":       {In: `"héllo, hé"`, Op: `indices("hé")`, Expected: `[0,7]`},
+		"indices element":       {In: `[0, 1, 2, 1, 3, 1, 4]`, Op: `indices(1)`, Expected: `[1,3,5]`},
+		"indices subarray":      {In: `[0, 1, 2, 3, 1, 4, 2, 5, 1, 2, 6, 7]`, Op: `indices([1, 2])`, Expected: `[1,8]`},
+		"indices empty":         {In: `"abc"`, Op: `indices("")`, Expected: `null`},
+		"indices null":          {In: `null`, Op: `indices(1)`, Expected: `null`},
+		"index":                 {In: `"a,b, cd, efg"`, Op: `index(", ")`, Expected: `3`},
+		"rindex":                {In: `"a,b, cd, efg"`, Op: `rindex(", ")`, Expected: `7`},
+		"index missing":         {In: `[1, 2]`, Op: `index(3)`, Expected: `null`},
+		"rindex array":          {In: `[1, 2, 1]`, Op: `rindex(1)`, Expected: `2`},
+		"any":                   {In: `[false, true]`, Op: `any`, Expected: `true`},
+		"any empty":             {In: `[]`, Op: `any`, Expected: `false`},
+		"any condition":         {In: `[1, 5]`, Op: `any(. > 3)`, Expected: `true`},
+		"any generator":         {In: `{"a": [1, 2]}`, Op: `any(.a[]; . == 2)`, Expected: `true`},
+		"any short circuit":     {In: `[1, "a"]`, Op: `any(.[]; . == 1 or .a)`, Expected: `true`},
+		"all":                   {In: `[true, 1]`, Op: `all`, Expected: `true`},
+		"all empty":             {In: `[]`, Op: `all`, Expected: `true`},
+		"all condition":         {In: `[1, 5]`, Op: `all(. > 3)`, Expected: `false`},
+		"all generator":         {In: `{"a": [1, 2]}`, Op: `all(.a[]; . > 0)`, Expected: `true`},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op)
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}
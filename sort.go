@@ -0,0 +1,152 @@
+package jq
+
+import (
+	"fmt"
+	"sort"
+)
+
+func sortBuiltins() {
+	define("sort", 0, function(func(in []byte, _ [][]byte) ([]byte, error) {
+		items, err := keyedElements(in, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := sortKeyed(items); err != nil {
+			return nil, err
+		}
+		return appendArray(nil, itemValues(items)), nil
+	}))
+
+	// the _impl builtins receive the keys of the elements of their input, computed as map([f]), as sort_by and the
+	// other definitions of the prelude call them
+
+	define("_sort_by_impl", 1, function(func(in []byte, args [][]byte) ([]byte, error) {
+		items, err := keyedElements(in, args[0])
+		if err != nil {
+			return nil, err
+		}
+		if err := sortKeyed(items); err != nil {
+			return nil, err
+		}
+		return appendArray(nil, itemValues(items)), nil
+	}))
+
+	define("_group_by_impl", 1, function(func(in []byte, args [][]byte) ([]byte, error) {
+		items, err := keyedElements(in, args[0])
+		if err != nil {
+			return nil, err
+		}
+		if err := sortKeyed(items); err != nil {
+			return nil, err
+		}
+
+		var groups [][]byte
+		for start := 0; start < len(items); {
+			end := start + 1
+			for end < len(items) {
+				c, err := compareValues(items[start].key, items[end].key)
+				if err != nil {
+					return nil, err
+				}
+				if c != 0 {
+					break
+				}
+				end++
+			}
+			groups = append(groups, appendArray(nil, itemValues(items[start:end])))
+			start = end
+		}
+		return appendArray(nil, groups), nil
+	}))
+
+	define("_min_by_impl", 1, function(func(in []byte, args [][]byte) ([]byte, error) {
+		return extreme(in, args[0], func(c int) bool { return c < 0 })
+	}))
+
+	define("_max_by_impl", 1, function(func(in []byte, args [][]byte) ([]byte, error) {
+		return extreme(in, args[0], func(c int) bool { return c >= 0 })
+	}))
+}
+
+// keyed is an element of an array along with the key it is ordered by
+type keyed struct {
+	key   []byte
+	value []byte
+}
+
+// keyedElements pairs the elements of the array in with the elements of the array keys; elements are their own keys
+// when keys is nil
+func keyedElements(in, keys []byte) ([]keyed, error) {
+	k, err := kindOf(in)
+	if err != nil {
+		return nil, err
+	}
+	if k != kindArray {
+		return nil, fmt.Errorf("%v (%s) cannot be sorted, as it is not an array", k, truncate(in))
+	}
+
+	values, err := elements(in)
+	if err != nil {
+		return nil, err
+	}
+	ks := values
+	if keys != nil {
+		if ks, err = elements(keys); err != nil {
+			return nil, err
+		}
+		if len(ks) != len(values) {
+			return nil, fmt.Errorf("the keys of an array must match its length")
+		}
+	}
+
+	items := make([]keyed, len(values))
+	for i, v := range values {
+		items[i] = keyed{key: ks[i], value: v}
+	}
+	return items, nil
+}
+
+// sortKeyed sorts items by key, keeping the order of items with equal keys
+func sortKeyed(items []keyed) error {
+	var err error
+	sort.SliceStable(items, func(i, j int) bool {
+		c, cerr := compareValues(items[i].key, items[j].key)
+		if cerr != nil && err == nil {
+			err = cerr
+		}
+		return c < 0
+	})
+	return err
+}
+
+func itemValues(items []keyed) [][]byte {
+	values := make([][]byte, len(items))
+	for i, item := range items {
+		values[i] = item.value
+	}
+	return values
+}
+
+// extreme returns the element whose key replaces the current candidate whenever better reports true of their
+// comparison, or null for an empty array
+func extreme(in, keys []byte, better func(c int) bool) ([]byte, error) {
+	items, err := keyedElements(in, keys)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nullValue, nil
+	}
+
+	best := items[0]
+	for _, item := range items[1:] {
+		c, err := compareValues(item.key, best.key)
+		if err != nil {
+			return nil, err
+		}
+		if better(c) {
+			best = item
+		}
+	}
+	return best.value, nil
+}
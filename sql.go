@@ -0,0 +1,39 @@
+package jq
+
+func sqlBuiltins() {
+	iterate := &iterateNode{target: identityNode{}}
+
+	define("INDEX", 1, &builtin{
+		eval: func(e *env, in []byte, args []node, fn func([]byte) error) error {
+			return indexRows(e, in, iterate, args[0], fn)
+		},
+		aggregate: true,
+	})
+
+	define("INDEX", 2, &builtin{
+		eval: func(e *env, in []byte, args []node, fn func([]byte) error) error {
+			return indexRows(e, in, args[0], args[1], fn)
+		},
+		aggregate: true,
+	})
+}
+
+// indexRows builds an object of the rows produced by stream keyed by the value of key for each row, converted to a
+// string; later rows replace earlier rows with the same key
+func indexRows(e *env, in []byte, stream, key node, fn func([]byte) error) error {
+	var builder objectBuilder
+	err := stream.eval(e, in, func(row []byte) error {
+		return key.eval(e, row, func(k []byte) error {
+			s, err := stringify(k)
+			if err != nil {
+				return err
+			}
+			builder.set(string(s), nil, row)
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return fn(builder.bytes())
+}
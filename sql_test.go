@@ -0,0 +1,84 @@
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkJoin(t *testing.B) {
+	op := jq.Must(jq.Parse(`INDEX(.customers[]; .id) as $c | [JOIN($c; .orders[]; .customer | tostring; add)]`))
+	data := []byte(`{"customers":[{"id":1,"name":"a"},{"id":2,"name":"b"}],"orders":[{"customer":1,"total":5},{"customer":2,"total":7}]}`)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestSQL(t *testing.T) {
+	data := `{
+		"customers": [{"id": 1, "name": "alice"}, {"id": 2, "name": "bob"}],
+		"orders": [{"id": "o1", "customer": 1}, {"id": "o2", "customer": 3}]
+	}`
+
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+		HasError bool
+	}{
+		"INDEX": {
+			In:       data,
+			Op:       `INDEX(.customers[]; .id)`,
+			Expected: `{"1":{"id": 1, "name": "alice"},"2":{"id": 2, "name": "bob"}}`,
+		},
+		"INDEX input": {
+			In:       `[{"k": "a", "v": 1}, {"k": "a", "v": 2}]`,
+			Op:       `INDEX(.k)`,
+			Expected: `{"a":{"k": "a", "v": 2}}`,
+		},
+		"JOIN": {
+			In:       data,
+			Op:       `INDEX(.customers[]; .id) as $c | .orders | JOIN($c; .customer | tostring)`,
+			Expected: `[[{"id": "o1", "customer": 1},{"id": 1, "name": "alice"}],[{"id": "o2", "customer": 3},null]]`,
+		},
+		"JOIN stream": {
+			In:       data,
+			Op:       `INDEX(.customers[]; .id) as $c | [JOIN($c; .orders[]; .customer | tostring)] | length`,
+			Expected: `2`,
+		},
+		"JOIN expression": {
+			In:       data,
+			Op:       `INDEX(.customers[]; .id) as $c | [JOIN($c; .orders[]; .customer | tostring; {order: .[0].id, name: .[1].name})]`,
+			Expected: `[{"order":"o1","name":"alice"},{"order":"o2","name":null}]`,
+		},
+		"JOIN number key": {
+			In:       data,
+			Op:       `INDEX(.customers[]; .id) as $c | .orders | JOIN($c; .customer)`,
+			HasError: true,
+		},
+		"IN": {
+			In:       data,
+			Op:       `[.orders[] | select(.customer | IN(1, 2)) | .id]`,
+			Expected: `["o1"]`,
+		},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op)
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package jq
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/bubunyo/go-jq/scanner"
+)
+
+func streamBuiltins() {
+	define("tostream", 0, &builtin{
+		eval: func(e *env, in []byte, _ []node, fn func([]byte) error) error {
+			return toStream(in, fn)
+		},
+		stream: true,
+	})
+
+	define("fromstream", 1, &builtin{
+		eval: func(e *env, in []byte, args []node, fn func([]byte) error) error {
+			return fromStream(e, in, args[0], fn)
+		},
+	})
+
+	define("truncate_stream", 1, &builtin{
+		eval: func(e *env, in []byte, args []node, fn func([]byte) error) error {
+			depth, err := numberArg(in)
+			if err != nil {
+				return err
+			}
+			// as in jq the stream is evaluated against null, the input being the depth
+			return args[0].eval(e, nullValue, func(event []byte) error {
+				v, err := truncateEvent(event, clampInt(depth))
+				if err != nil || v == nil {
+					return err
+				}
+				return fn(v)
+			})
+		},
+	})
+}
+
+// toStream calls fn with the streaming form of in: a [path, leaf] event for every scalar and empty array or object,
+// and a [path] event closing each non-empty array or object, whose path is that of its last child. The events are
+// produced from a single pass of the scanner, so no part of in is parsed twice.
+func toStream(in []byte, fn func([]byte) error) error {
+	// p holds, for each open array or object, its child being walked; nil until the first child is reached
+	var p path
+	var opens, indices []int
+
+	event := func(p path, leaf []byte) error {
+		if leaf == nil {
+			return fn(appendArray(nil, [][]byte{p.bytes()}))
+		}
+		return fn(appendArray(nil, [][]byte{p.bytes(), leaf}))
+	}
+
+	// next advances the path to the next element of the array being walked, if any
+	next := func() {
+		n := len(p)
+		if n == 0 || indices[n-1] < 0 {
+			return
+		}
+		p[n-1] = strconv.AppendInt(nil, int64(indices[n-1]), 10)
+		indices[n-1]++
+	}
+
+	_, err := scanner.Walk(in, 0, func(t scanner.Token, start, end int) error {
+		switch t {
+		case scanner.Open:
+			next()
+			p = append(p, nil)
+			opens = append(opens, start)
+			if in[start] == '[' {
+				indices = append(indices, 0)
+			} else {
+				indices = append(indices, -1)
+			}
+			return nil
+		case scanner.Key:
+			p[len(p)-1] = in[start:end]
+			return nil
+		case scanner.Scalar:
+			next()
+			return event(p, in[start:end])
+		default:
+			n := len(p) - 1
+			last, open := p[n], opens[n]
+			p, opens, indices = p[:n], opens[:n], indices[:n]
+			if last == nil {
+				return event(p, in[open:end])
+			}
+			return event(p.append(last), nil)
+		}
+	})
+	return err
+}
+
+// fromStream reassembles the values described by the events f produces, calling fn with each top level value once
+// its closing event is reached
+func fromStream(e *env, in []byte, f node, fn func([]byte) error) error {
+	var result []byte
+	return f.eval(e, in, func(event []byte) error {
+		p, leaf, err := streamEvent(event)
+		if err != nil {
+			return err
+		}
+
+		if leaf == nil {
+			if len(p) != 1 || result == nil {
+				return nil
+			}
+			v := result
+			result = nil
+			return fn(v)
+		}
+
+		if len(p) == 0 {
+			result = nil
+			return fn(leaf)
+		}
+		if result == nil {
+			result = nullValue
+		}
+		result, err = setPath(result, p, leaf)
+		return err
+	})
+}
+
+// truncateEvent removes the first depth elements from the path of event, returning nil for events whose path is
+// no longer than depth
+func truncateEvent(event []byte, depth int) ([]byte, error) {
+	p, leaf, err := streamEvent(event)
+	if err != nil {
+		return nil, err
+	}
+	if depth < 0 || len(p) <= depth {
+		return nil, nil
+	}
+
+	p = p[depth:]
+	if leaf == nil {
+		return appendArray(nil, [][]byte{p.bytes()}), nil
+	}
+	return appendArray(nil, [][]byte{p.bytes(), leaf}), nil
+}
+
+// streamEvent splits a [path, leaf] or [path] event into its parts; leaf is nil for closing events
+func streamEvent(event []byte) (path, []byte, error) {
+	if k, err := kindOf(event); err != nil || k != kindArray {
+		return nil, nil, fmt.Errorf("invalid stream event %s", truncate(event))
+	}
+	parts, err := elements(event)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(parts) != 1 && len(parts) != 2 {
+		return nil, nil, fmt.Errorf("invalid stream event %s", truncate(event))
+	}
+
+	p, err := pathElements(parts[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(parts) == 1 {
+		return p, nil, nil
+	}
+	return p, parts[1], nil
+}
@@ -0,0 +1,94 @@
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkToStream(t *testing.B) {
+	op := jq.Must(jq.Parse(`[tostream]`))
+	data := []byte(`{"a":[1,{"b":true}],"c":"hello","d":{}}`)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestStream(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+		HasError bool
+	}{
+		"tostream": {
+			In:       `{"a": [1, {"b": 2}]}`,
+			Op:       `tostream`,
+			Expected: `[[["a",0],1],[["a",1,"b"],2],[["a",1,"b"]],[["a",1]],[["a"]]]`,
+		},
+		"tostream scalar": {In: `3`, Op: `[tostream]`, Expected: `[[[],3]]`},
+		"tostream empty":  {In: `[ ]`, Op: `[tostream]`, Expected: `[[[],[ ]]]`},
+		"tostream nested empty": {
+			In:       `{"a":[],"b":{}}`,
+			Op:       `[tostream]`,
+			Expected: `[[["a"],[]],[["b"],{}],[["b"]]]`,
+		},
+		"tostream escaped key": {
+			In:       `{"a\"b": 1}`,
+			Op:       `[tostream]`,
+			Expected: `[[["a\"b"],1],[["a\"b"]]]`,
+		},
+		"tostream leaves": {
+			In:       `{"a": {"b": 1, "c": [true]}}`,
+			Op:       `[tostream | select(length == 2) | {path: .[0], value: .[1]}]`,
+			Expected: `[{"path":["a","b"],"value":1},{"path":["a","c",0],"value":true}]`,
+		},
+		"tostream getpath": {
+			In:       `{"a": {"b": 1, "c": [true]}}`,
+			Op:       `. as $d | [tostream | select(length == 2) | .[0] as $p | $d | getpath($p)]`,
+			Expected: `[1,true]`,
+		},
+		"fromstream": {
+			In:       `{"a": [1, {"b": 2}], "c": "x"}`,
+			Op:       `fromstream(tostream)`,
+			Expected: `[{"a":[1,{"b":2}],"c":"x"}]`,
+		},
+		"fromstream scalar": {In: `"x"`, Op: `[fromstream(tostream)]`, Expected: `["x"]`},
+		"fromstream events": {
+			In:       `null`,
+			Op:       `[fromstream([[0],1],[[1],2],[[1]], [[],3])]`,
+			Expected: `[[1,2],3]`,
+		},
+		"fromstream invalid": {In: `null`, Op: `fromstream(1)`, HasError: true},
+		"truncate_stream": {
+			In:       `1`,
+			Op:       `[truncate_stream([[0],1],[[1,0],2],[[1,0]],[[1]])]`,
+			Expected: `[[[0],2],[[0]]]`,
+		},
+		"truncate_stream children": {
+			In:       `{"a": [1, 2], "b": {"c": 3}}`,
+			Op:       `. as $d | [1 | truncate_stream($d | tostream)] | [fromstream(.[])]`,
+			Expected: `[[1,2],{"c":3}]`,
+		},
+		"truncate_stream depth": {In: `"a"`, Op: `truncate_stream([[0],1])`, HasError: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op)
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}
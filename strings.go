@@ -0,0 +1,108 @@
+package jq
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+)
+
+func stringBuiltins() {
+	define("explode", 0, function(func(in []byte, _ [][]byte) ([]byte, error) {
+		return explode(in)
+	}))
+
+	define("implode", 0, function(func(in []byte, _ [][]byte) ([]byte, error) {
+		return implode(in)
+	}))
+
+	define("startswith", 1, function(func(in []byte, args [][]byte) ([]byte, error) {
+		s, prefix, err := stringOperands("startswith", in, args[0])
+		return boolValue(bytes.HasPrefix(s, prefix)), err
+	}))
+
+	define("endswith", 1, function(func(in []byte, args [][]byte) ([]byte, error) {
+		s, suffix, err := stringOperands("endswith", in, args[0])
+		return boolValue(bytes.HasSuffix(s, suffix)), err
+	}))
+}
+
+// stringOperands decodes the input and argument of a builtin that requires both to be strings
+func stringOperands(name string, in, arg []byte) ([]byte, []byte, error) {
+	ki, ka, err := kinds(in, arg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ki != kindString || ka != kindString {
+		return nil, nil, fmt.Errorf("%v() requires string inputs", name)
+	}
+
+	s, err := unquote(in)
+	if err != nil {
+		return nil, nil, err
+	}
+	a, err := unquote(arg)
+	return s, a, err
+}
+
+// explode converts a string into an array of the code points of its characters
+func explode(in []byte) ([]byte, error) {
+	k, err := kindOf(in)
+	if err != nil {
+		return nil, err
+	}
+	if k != kindString {
+		return nil, fmt.Errorf("%v (%s) cannot be exploded, as it is not a string", k, truncate(in))
+	}
+
+	s, err := unquote(in)
+	if err != nil {
+		return nil, err
+	}
+	out := []byte{'['}
+	for len(s) > 0 {
+		r, size := utf8.DecodeRune(s)
+		if len(out) > 1 {
+			out = append(out, ',')
+		}
+		out = strconv.AppendInt(out, int64(r), 10)
+		s = s[size:]
+	}
+	return append(out, ']'), nil
+}
+
+// implode converts an array of code points into a string
+func implode(in []byte) ([]byte, error) {
+	k, err := kindOf(in)
+	if err != nil {
+		return nil, err
+	}
+	if k != kindArray {
+		return nil, fmt.Errorf("%v (%s) cannot be imploded, as it is not an array", k, truncate(in))
+	}
+
+	var s []byte
+	err = eachElement(in, func(_ int, v []byte) error {
+		k, err := kindOf(v)
+		if err != nil {
+			return err
+		}
+		if k != kindNumber {
+			return fmt.Errorf("%v (%s) cannot be imploded, as it is not a code point", k, truncate(v))
+		}
+		f, err := toFloat(v)
+		if err != nil {
+			return err
+		}
+		r := rune(f)
+		if float64(r) != f || !utf8.ValidRune(r) {
+			return fmt.Errorf("invalid code point %s", v)
+		}
+		s = utf8.AppendRune(s, r)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return appendQuoted(nil, s), nil
+}
@@ -0,0 +1,65 @@
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkStrings(t *testing.B) {
+	op := jq.Must(jq.Parse(`explode | implode | startswith("hé")`))
+	data := []byte(`"héllo wörld"`)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestStrings(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+		HasError bool
+	}{
+		"explode":              {In: `"aé𝄞"`, Op: `explode`, Expected: `[97,233,119070]`},
+		"explode escaped":      {In: `"é\n"`, Op: `explode`, Expected: `[233,10]`},
+		"explode empty":        {In: `""`, Op: `explode`, Expected: `[]`},
+		"explode not a string": {In: `1`, Op: `explode`, HasError: true},
+		"implode":              {In: `[97,233,119070]`, Op: `implode`, Expected: `"aé𝄞"`},
+		"implode escapes":      {In: `[34,10]`, Op: `implode`, Expected: `"\"\n"`},
+		"implode round trip":   {In: `"héllo"`, Op: `explode | implode`, Expected: `"héllo"`},
+		"implode not an array": {In: `"a"`, Op: `implode`, HasError: true},
+		"implode not a number": {In: `["a"]`, Op: `implode`, HasError: true},
+		"implode surrogate":    {In: `[55296]`, Op: `implode`, HasError: true},
+		"implode fraction":     {In: `[97.5]`, Op: `implode`, HasError: true},
+		"startswith":           {In: `"héllo"`, Op: `startswith("hé")`, Expected: `true`},
+		"startswith not":       {In: `"héllo"`, Op: `startswith("lo")`, Expected: `false`},
+		"startswith escaped":   {In: `"éa"`, Op: `startswith("é")`, Expected: `true`},
+		"startswith number":    {In: `"1"`, Op: `startswith(1)`, HasError: true},
+		"endswith":             {In: `"héllo"`, Op: `endswith("lo")`, Expected: `true`},
+		"endswith not":         {In: `"héllo"`, Op: `endswith("hé")`, Expected: `false`},
+		"endswith input":       {In: `null`, Op: `endswith("a")`, HasError: true},
+		"reverse":              {In: `[1, [2], 3]`, Op: `reverse`, Expected: `[3,[2],1]`},
+		"reverse string":       {In: `"aé𝄞"`, Op: `reverse`, Expected: `"𝄞éa"`},
+		"reverse null":         {In: `null`, Op: `reverse`, Expected: `[]`},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op)
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package jq
+
+import (
+	"bytes"
+	"fmt"
+)
+
+type kind int
+
+const (
+	kindNull kind = iota
+	kindBoolean
+	kindNumber
+	kindString
+	kindArray
+	kindObject
+)
+
+var typeNames = [...][]byte{
+	kindNull:    []byte(`"null"`),
+	kindBoolean: []byte(`"boolean"`),
+	kindNumber:  []byte(`"number"`),
+	kindString:  []byte(`"string"`),
+	kindArray:   []byte(`"array"`),
+	kindObject:  []byte(`"object"`),
+}
+
+// kindOf determines the type of the JSON value from its first non-space byte
+func kindOf(in []byte) (kind, error) {
+	if sameSlice(in, nanValue) {
+		return kindNumber, nil
+	}
+
+	pos := skipSpace(in, 0)
+	if pos == len(in) {
+		return 0, fmt.Errorf("unexpected EOF")
+	}
+
+	switch in[pos] {
+	case 'n':
+		return kindNull, nil
+	case 't', 'f':
+		return kindBoolean, nil
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return kindNumber, nil
+	case '"':
+		return kindString, nil
+	case '[':
+		return kindArray, nil
+	case '{':
+		return kindObject, nil
+	default:
+		return 0, fmt.Errorf("invalid character at position %v; %v", pos, string(in[pos]))
+	}
+}
+
+func (k kind) String() string {
+	name := typeNames[k]
+	return string(name[1 : len(name)-1])
+}
+
+// Type returns the name of the type of its input as a JSON string: "null", "boolean", "number", "string", "array"
+// or "object". The names are shared by every call so that Type doesn't allocate, and must not be modified; appending
+// to one allocates a copy. Queries using type return a copy of their own.
+func Type() OpFunc {
+	return func(in []byte) ([]byte, error) {
+		k, err := kindOf(in)
+		if err != nil {
+			return nil, err
+		}
+
+		name := typeNames[k]
+		return name[:len(name):len(name)], nil
+	}
+}
+
+// ToString returns strings unchanged and serializes any other value into a JSON string
+func ToString() OpFunc {
+	tojson := ToJSON()
+
+	return func(in []byte) ([]byte, error) {
+		k, err := kindOf(in)
+		if err != nil {
+			return nil, err
+		}
+		if k == kindString {
+			return bytes.TrimSpace(in), nil
+		}
+
+		return tojson(in)
+	}
+}
+
+// ToNumber returns numbers unchanged and parses strings whose content follows the JSON number grammar
+func ToNumber() OpFunc {
+	return func(in []byte) ([]byte, error) {
+		in = bytes.TrimSpace(in)
+
+		k, err := kindOf(in)
+		if err != nil {
+			return nil, err
+		}
+
+		switch k {
+		case kindNumber:
+			if !isNumber(in) {
+				return nil, fmt.Errorf("tonumber: invalid number %s", in)
+			}
+			return in, nil
+		case kindString:
+			content, err := unquote(in)
+			if err != nil {
+				return nil, err
+			}
+			if !isNumber(content) {
+				return nil, fmt.Errorf("tonumber: cannot parse %s as a number", in)
+			}
+			return content, nil
+		default:
+			return nil, fmt.Errorf("tonumber: %v (%s) cannot be parsed as a number", k, in)
+		}
+	}
+}
+
+// selectKinds returns its input when the input's type is one of the kinds provided and produces no value otherwise
+func selectKinds(kinds ...kind) OpFunc {
+	return func(in []byte) ([]byte, error) {
+		k, err := kindOf(in)
+		if err != nil {
+			return nil, err
+		}
+		for _, want := range kinds {
+			if k == want {
+				return in, nil
+			}
+		}
+
+		return nil, nil
+	}
+}
+
+// Arrays returns its input if it is an array and produces no value otherwise
+func Arrays() OpFunc { return selectKinds(kindArray) }
+
+// Objects returns its input if it is an object and produces no value otherwise
+func Objects() OpFunc { return selectKinds(kindObject) }
+
+// Iterables returns its input if it is an array or an object and produces no value otherwise
+func Iterables() OpFunc { return selectKinds(kindArray, kindObject) }
+
+// Scalars returns its input if it is neither an array nor an object and produces no value otherwise
+func Scalars() OpFunc { return selectKinds(kindNull, kindBoolean, kindNumber, kindString) }
+
+// Strings returns its input if it is a string and produces no value otherwise
+func Strings() OpFunc { return selectKinds(kindString) }
+
+// Numbers returns its input if it is a number and produces no value otherwise
+func Numbers() OpFunc { return selectKinds(kindNumber) }
+
+// Booleans returns its input if it is a boolean and produces no value otherwise
+func Booleans() OpFunc { return selectKinds(kindBoolean) }
+
+// Nulls returns its input if it is null and produces no value otherwise
+func Nulls() OpFunc { return selectKinds(kindNull) }
+
+// Values returns its input if it is not null and produces no value otherwise
+func Values() OpFunc {
+	return selectKinds(kindBoolean, kindNumber, kindString, kindArray, kindObject)
+}
+
+// isNumber reports whether in matches the JSON number grammar exactly
+func isNumber(in []byte) bool {
+	pos := 0
+	if pos < len(in) && in[pos] == '-' {
+		pos++
+	}
+
+	switch {
+	case pos < len(in) && in[pos] == '0':
+		pos++
+	case pos < len(in) && in[pos] >= '1' && in[pos] <= '9':
+		pos = skipDigits(in, pos)
+	default:
+		return false
+	}
+
+	if pos < len(in) && in[pos] == '.' {
+		pos++
+		if pos == len(in) || !isDigit(in[pos]) {
+			return false
+		}
+		pos = skipDigits(in, pos)
+	}
+
+	if pos < len(in) && (in[pos] == 'e' || in[pos] == 'E') {
+		pos++
+		if pos < len(in) && (in[pos] == '+' || in[pos] == '-') {
+			pos++
+		}
+		if pos == len(in) || !isDigit(in[pos]) {
+			return false
+		}
+		pos = skipDigits(in, pos)
+	}
+
+	return pos == len(in)
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+func skipDigits(in []byte, pos int) int {
+	for pos < len(in) && isDigit(in[pos]) {
+		pos++
+	}
+	return pos
+}
@@ -0,0 +1,108 @@
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkType(t *testing.B) {
+	op := jq.Type()
+	data := []byte(`{"hello":"world"}`)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestTypes(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+		Empty    bool
+		HasError bool
+	}{
+		"type null":      {In: `null`, Op: "type", Expected: `"null"`},
+		"type boolean":   {In: `false`, Op: "type", Expected: `"boolean"`},
+		"type number":    {In: ` -1.5`, Op: "type", Expected: `"number"`},
+		"type string":    {In: `"a"`, Op: "type", Expected: `"string"`},
+		"type array":     {In: `[1]`, Op: "type", Expected: `"array"`},
+		"type object":    {In: `{"a":{}}`, Op: ".a|type", Expected: `"object"`},
+		"type invalid":   {In: `?`, Op: "type", HasError: true},
+		"tostring":       {In: `{"a": [1, 2]}`, Op: "tostring", Expected: `"{\"a\":[1,2]}"`},
+		"tostring str":   {In: `"abc"`, Op: "tostring", Expected: `"abc"`},
+		"tonumber":       {In: `"12.5e3"`, Op: "tonumber", Expected: `12.5e3`},
+		"tonumber num":   {In: `-0.5`, Op: "tonumber", Expected: `-0.5`},
+		"tonumber plus":  {In: `"+1"`, Op: "tonumber", HasError: true},
+		"tonumber dots":  {In: `"1.2.3"`, Op: "tonumber", HasError: true},
+		"tonumber lead":  {In: `".5"`, Op: "tonumber", HasError: true},
+		"tonumber zeros": {In: `"01"`, Op: "tonumber", HasError: true},
+		"tonumber exp":   {In: `"1e"`, Op: "tonumber", HasError: true},
+		"tonumber bool":  {In: `true`, Op: "tonumber", HasError: true},
+		"arrays":         {In: `[1]`, Op: "arrays", Expected: `[1]`},
+		"arrays empty":   {In: `{}`, Op: "arrays", Empty: true},
+		"objects":        {In: `{}`, Op: "objects", Expected: `{}`},
+		"iterables":      {In: `{}`, Op: "iterables", Expected: `{}`},
+		"iterables none": {In: `1`, Op: "iterables", Empty: true},
+		"scalars":        {In: `1`, Op: "scalars", Expected: `1`},
+		"scalars none":   {In: `[]`, Op: "scalars", Empty: true},
+		"strings":        {In: `"a"`, Op: "strings", Expected: `"a"`},
+		"numbers":        {In: `1`, Op: "numbers", Expected: `1`},
+		"booleans":       {In: `true`, Op: "booleans", Expected: `true`},
+		"nulls":          {In: `null`, Op: "nulls", Expected: `null`},
+		"values none":    {In: `null`, Op: "values", Empty: true},
+		"empty chain":    {In: `{"a":"x"}`, Op: ".a|numbers|.b", Empty: true},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op)
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			switch {
+			case tc.HasError:
+				assert.Error(t, err)
+			case tc.Empty:
+				require.NoError(t, err)
+				assert.Nil(t, data)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, tc.Expected, string(data))
+			}
+		})
+	}
+}
+
+func TestTypeShared(t *testing.T) {
+	op := jq.Type()
+	in := []byte(`{}`)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_, err := op.Apply(in)
+		require.NoError(t, err)
+	})
+	assert.Zero(t, allocs)
+
+	data, err := op.Apply(in)
+	require.NoError(t, err)
+	_ = append(data, "xxxxx"...)
+
+	query := jq.Must(jq.Parse(`type`))
+	result, err := query.Apply(in)
+	require.NoError(t, err)
+	copy(result, `"xxxxx"`)
+
+	data, err = op.Apply(in)
+	require.NoError(t, err)
+	assert.Equal(t, `"object"`, string(data))
+	assert.Equal(t, len(data), cap(data), "the shared name is clipped")
+
+	result, err = query.Apply(in)
+	require.NoError(t, err)
+	assert.Equal(t, `"object"`, string(result))
+}
@@ -0,0 +1,458 @@
+package jq
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/bubunyo/go-jq/scanner"
+)
+
+var (
+	nullValue  = []byte("null")
+	trueValue  = []byte("true")
+	falseValue = []byte("false")
+)
+
+// nanValue, infValue and negInfValue hold the serialized forms of the numbers JSON cannot represent. They are
+// recognized by identity, so a non-finite result keeps its value while it is passed between filters and only becomes
+// null or the largest finite number once it is written into an array, an object or the output.
+var (
+	nanValue    = []byte("null")
+	infValue    = []byte("1.7976931348623157e+308")
+	negInfValue = []byte("-1.7976931348623157e+308")
+)
+
+func boolValue(b bool) []byte {
+	if b {
+		return trueValue
+	}
+	return falseValue
+}
+
+// truthy reports whether the value is anything other than null or false
+func truthy(v []byte) bool {
+	return (v[0] != 'n' || sameSlice(v, nanValue)) && v[0] != 'f'
+}
+
+// eachElement calls fn with the index and value of each element of the array provided
+func eachElement(in []byte, fn func(i int, v []byte) error) error {
+	i := 0
+	_, err := scanner.Elements(in, 0, func(start, end int) error {
+		err := fn(i, in[start:end])
+		i++
+		return err
+	})
+	return err
+}
+
+// eachMember calls fn with the raw key token, including quotes, and value of each member of the object provided
+func eachMember(in []byte, fn func(key, v []byte) error) error {
+	_, err := scanner.Members(in, 0, func(keyStart, keyEnd, valueStart, valueEnd int) error {
+		return fn(in[keyStart:keyEnd], in[valueStart:valueEnd])
+	})
+	return err
+}
+
+// elements returns the elements of the array provided
+func elements(in []byte) ([][]byte, error) {
+	var values [][]byte
+	err := eachElement(in, func(_ int, v []byte) error {
+		values = append(values, v)
+		return nil
+	})
+	return values, err
+}
+
+// appendArray appends the values provided to dst as a JSON array
+func appendArray(dst []byte, values [][]byte) []byte {
+	dst = append(dst, '[')
+	for i, v := range values {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, v...)
+	}
+	return append(dst, ']')
+}
+
+// member is a single key value pair of an object; raw holds the key as a JSON string token
+type member struct {
+	key   string
+	raw   []byte
+	value []byte
+}
+
+// members returns the members of the object provided with their keys decoded
+func members(in []byte) ([]member, error) {
+	var result []member
+	err := eachMember(in, func(raw, v []byte) error {
+		key, err := decodeString(raw)
+		if err != nil {
+			return err
+		}
+		result = append(result, member{key: key, raw: raw, value: v})
+		return nil
+	})
+	return result, err
+}
+
+// objectBuilder assembles an object in insertion order; setting an existing key replaces its value in place
+type objectBuilder struct {
+	members []member
+
+	// positions indexes members by key once the object is large enough that scanning for keys becomes slow
+	positions map[string]int
+}
+
+const objectBuilderIndexSize = 16
+
+func (b *objectBuilder) find(key string) int {
+	if b.positions == nil && len(b.members) >= objectBuilderIndexSize {
+		b.positions = make(map[string]int, len(b.members))
+		for i := len(b.members) - 1; i >= 0; i-- {
+			b.positions[b.members[i].key] = i
+		}
+	}
+	if b.positions != nil {
+		if i, ok := b.positions[key]; ok {
+			return i
+		}
+		return -1
+	}
+
+	for i := range b.members {
+		if b.members[i].key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func (b *objectBuilder) get(key string) ([]byte, bool) {
+	if i := b.find(key); i >= 0 {
+		return b.members[i].value, true
+	}
+	return nil, false
+}
+
+func (b *objectBuilder) set(key string, raw, value []byte) {
+	if i := b.find(key); i >= 0 {
+		b.members[i].value = value
+		return
+	}
+	if raw == nil {
+		raw = quote(key)
+	}
+	b.members = append(b.members, member{key: key, raw: raw, value: value})
+	if b.positions != nil {
+		b.positions[key] = len(b.members) - 1
+	}
+}
+
+func (b *objectBuilder) bytes() []byte {
+	size := 2
+	for _, m := range b.members {
+		size += len(m.raw) + len(m.value) + 2
+	}
+
+	dst := make([]byte, 0, size)
+	dst = append(dst, '{')
+	for i, m := range b.members {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, m.raw...)
+		dst = append(dst, ':')
+		dst = append(dst, m.value...)
+	}
+	return append(dst, '}')
+}
+
+// sortedMembers returns the members of the object sorted by key
+func sortedMembers(in []byte) ([]member, error) {
+	ms, err := members(in)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(ms, func(i, j int) bool { return ms[i].key < ms[j].key })
+	return ms, nil
+}
+
+// decodeString returns the content of the JSON string token provided
+func decodeString(v []byte) (string, error) {
+	content, err := unquote(v)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// quote encodes s as a JSON string token
+func quote(s string) []byte {
+	return appendQuoted(make([]byte, 0, len(s)+2), []byte(s))
+}
+
+// toFloat parses the JSON number provided
+func toFloat(v []byte) (float64, error) {
+	switch {
+	case sameSlice(v, nanValue):
+		return math.NaN(), nil
+	case sameSlice(v, infValue):
+		return math.Inf(1), nil
+	case sameSlice(v, negInfValue):
+		return math.Inf(-1), nil
+	}
+
+	f, err := strconv.ParseFloat(string(v), 64)
+	if err != nil {
+		if ne, ok := err.(*strconv.NumError); ok && ne.Err == strconv.ErrRange {
+			return f, nil
+		}
+		return 0, fmt.Errorf("invalid number %s", v)
+	}
+	return f, nil
+}
+
+// toInt parses the JSON number provided, truncating any fraction
+func toInt(v []byte) (int, error) {
+	f, err := toFloat(v)
+	if err != nil {
+		return 0, err
+	}
+	return clampInt(f), nil
+}
+
+func clampInt(f float64) int {
+	switch {
+	case math.IsNaN(f):
+		return 0
+	case f >= math.MaxInt32:
+		return math.MaxInt32
+	case f <= math.MinInt32:
+		return math.MinInt32
+	default:
+		return int(f)
+	}
+}
+
+// number formats f as a JSON number, printing it without an exponent where possible
+func number(f float64) []byte {
+	switch {
+	case math.IsNaN(f):
+		return nanValue
+	case math.IsInf(f, 1):
+		return infValue
+	case math.IsInf(f, -1):
+		return negInfValue
+	default:
+		return appendNumber(nil, f)
+	}
+}
+
+func appendNumber(dst []byte, f float64) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(dst, "null"...)
+	case math.IsInf(f, 1):
+		return append(dst, "1.7976931348623157e+308"...)
+	case math.IsInf(f, -1):
+		return append(dst, "-1.7976931348623157e+308"...)
+	case math.Abs(f) < 1e17 && (f == math.Trunc(f) || math.Abs(f) >= 1e-4):
+		// as in jq, exponents are only used for very large or very small numbers
+		return strconv.AppendFloat(dst, f, 'f', -1, 64)
+	default:
+		return strconv.AppendFloat(dst, f, 'g', -1, 64)
+	}
+}
+
+// length returns the jq length of a value: the absolute value of numbers, the number of codepoints in strings and
+// the number of entries in arrays and objects
+func length(in []byte) ([]byte, error) {
+	k, err := kindOf(in)
+	if err != nil {
+		return nil, err
+	}
+
+	switch k {
+	case kindNull:
+		return number(0), nil
+	case kindNumber:
+		f, err := toFloat(in)
+		if err != nil {
+			return nil, err
+		}
+		return number(math.Abs(f)), nil
+	case kindString:
+		s, err := unquote(in)
+		if err != nil {
+			return nil, err
+		}
+		return number(float64(utf8.RuneCount(s))), nil
+	case kindArray:
+		n := 0
+		err := eachElement(in, func(int, []byte) error { n++; return nil })
+		return number(float64(n)), err
+	case kindObject:
+		n := 0
+		err := eachMember(in, func(_, _ []byte) error { n++; return nil })
+		return number(float64(n)), err
+	default:
+		return nil, fmt.Errorf("%v (%s) has no length", k, in)
+	}
+}
+
+// compareValues orders values as jq does: null < false < true < numbers < strings < arrays < objects
+func compareValues(a, b []byte) (int, error) {
+	ka, err := kindOf(a)
+	if err != nil {
+		return 0, err
+	}
+	kb, err := kindOf(b)
+	if err != nil {
+		return 0, err
+	}
+
+	if ka != kb {
+		return compareInts(int(ka), int(kb)), nil
+	}
+
+	switch ka {
+	case kindBoolean:
+		return compareInts(boolRank(a), boolRank(b)), nil
+	case kindNumber:
+		fa, err := toFloat(a)
+		if err != nil {
+			return 0, err
+		}
+		fb, err := toFloat(b)
+		if err != nil {
+			return 0, err
+		}
+		// nan sorts below every number, as in jq
+		switch {
+		case math.IsNaN(fa):
+			return -1, nil
+		case math.IsNaN(fb):
+			return 1, nil
+		case fa < fb:
+			return -1, nil
+		case fa > fb:
+			return 1, nil
+		default:
+			return 0, nil
+		}
+	case kindString:
+		sa, err := unquote(a)
+		if err != nil {
+			return 0, err
+		}
+		sb, err := unquote(b)
+		if err != nil {
+			return 0, err
+		}
+		return bytes.Compare(sa, sb), nil
+	case kindArray:
+		return compareArrays(a, b)
+	case kindObject:
+		return compareObjects(a, b)
+	default:
+		return 0, nil
+	}
+}
+
+func compareArrays(a, b []byte) (int, error) {
+	ea, err := elements(a)
+	if err != nil {
+		return 0, err
+	}
+	eb, err := elements(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < len(ea) && i < len(eb); i++ {
+		if c, err := compareValues(ea[i], eb[i]); err != nil || c != 0 {
+			return c, err
+		}
+	}
+	return compareInts(len(ea), len(eb)), nil
+}
+
+func compareObjects(a, b []byte) (int, error) {
+	ma, err := sortedMembers(a)
+	if err != nil {
+		return 0, err
+	}
+	mb, err := sortedMembers(b)
+	if err != nil {
+		return 0, err
+	}
+
+	// objects are ordered by their sorted key sets first, then by their values in key order
+	for i := 0; i < len(ma) && i < len(mb); i++ {
+		if ma[i].key != mb[i].key {
+			if ma[i].key < mb[i].key {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+	if len(ma) != len(mb) {
+		return compareInts(len(ma), len(mb)), nil
+	}
+
+	for i := range ma {
+		if c, err := compareValues(ma[i].value, mb[i].value); err != nil || c != 0 {
+			return c, err
+		}
+	}
+	return 0, nil
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func boolRank(v []byte) int {
+	if v[0] == 't' {
+		return 1
+	}
+	return 0
+}
+
+// offsetOf returns the position of v within in when v is a subslice of in, or -1 otherwise
+func offsetOf(in, v []byte) int {
+	if len(in) == 0 || len(v) == 0 {
+		return -1
+	}
+	o := cap(in) - cap(v)
+	if o < 0 || o+len(v) > len(in) || &in[o] != &v[0] {
+		return -1
+	}
+	return o
+}
+
+// owned returns v when it is part of in, which belongs to the caller, and a copy of v otherwise: a computed value may
+// share the constants of a query or of the package, which a caller modifying its result mustn't change
+func owned(v, in []byte) []byte {
+	if v == nil || offsetOf(in, v) >= 0 {
+		return v
+	}
+	return bytes.Clone(v)
+}
+
+// sameSlice reports whether a and b are the same bytes in memory, as opposed to equal bytes
+func sameSlice(a, b []byte) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
@@ -0,0 +1,251 @@
+package jq
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/bubunyo/go-jq/scanner"
+)
+
+func walkBuiltins() {
+	define("walk", 1, &builtin{
+		eval: func(e *env, in []byte, args []node, fn func([]byte) error) error {
+			return (&walkNode{f: args[0]}).eval(e, in, fn)
+		},
+	})
+
+	define("to_entries", 0, function(func(in []byte, _ [][]byte) ([]byte, error) {
+		return toEntries(in)
+	}))
+
+	define("from_entries", 0, function(func(in []byte, _ [][]byte) ([]byte, error) {
+		return fromEntries(in)
+	}))
+}
+
+// walkNode applies f to every value within its input, children before their parents. An array element is replaced
+// by every value f produces for it and an object value by the first, with members for which f produces no value
+// removed. Containers whose children all come back unchanged are passed to f as they are, and changed children are
+// spliced into the original bytes of their container, so only the subtrees that change are serialized again.
+type walkNode struct {
+	f node
+}
+
+func (n *walkNode) eval(e *env, in []byte, fn func([]byte) error) error {
+	v, err := n.children(e, in)
+	if err != nil {
+		return err
+	}
+	return n.f.eval(e, v, fn)
+}
+
+// replacement holds the values replacing the bytes between start and end of a container
+type replacement struct {
+	start, end int
+	values     [][]byte
+}
+
+func (n *walkNode) children(e *env, in []byte) ([]byte, error) {
+	var edits []replacement
+	removed := false
+
+	switch in[0] {
+	case '[':
+		_, err := scanner.Elements(in, 0, func(start, end int) error {
+			child := in[start:end]
+			var values [][]byte
+			err := n.eval(e, child, func(v []byte) error {
+				values = append(values, v)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if len(values) == 1 && sameSlice(values[0], child) {
+				return nil
+			}
+			removed = removed || len(values) == 0
+			edits = append(edits, replacement{start: start, end: end, values: values})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+	case '{':
+		_, err := scanner.Members(in, 0, func(keyStart, _, valueStart, valueEnd int) error {
+			child := in[valueStart:valueEnd]
+			v, ok, err := first(e, n, child)
+			switch {
+			case err != nil:
+				return err
+			case !ok:
+				removed = true
+				edits = append(edits, replacement{start: keyStart, end: valueEnd})
+			case !sameSlice(v, child):
+				edits = append(edits, replacement{start: valueStart, end: valueEnd, values: [][]byte{v}})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		return in, nil
+	}
+
+	if len(edits) == 0 {
+		return in, nil
+	}
+	if removed {
+		return rebuild(in, edits)
+	}
+
+	out := make([]byte, 0, len(in))
+	pos := 0
+	for _, r := range edits {
+		out = append(out, in[pos:r.start]...)
+		for i, v := range r.values {
+			if i > 0 {
+				out = append(out, ',')
+			}
+			out = append(out, v...)
+		}
+		pos = r.end
+	}
+	return append(out, in[pos:]...), nil
+}
+
+// rebuild serializes the container in with its edits applied; it is used when entries are removed, which leaves no
+// separators to splice around
+func rebuild(in []byte, edits []replacement) ([]byte, error) {
+	next := 0
+	edited := func(start int) (replacement, bool) {
+		if next < len(edits) && edits[next].start == start {
+			next++
+			return edits[next-1], true
+		}
+		return replacement{}, false
+	}
+
+	if in[0] == '[' {
+		var values [][]byte
+		_, err := scanner.Elements(in, 0, func(start, end int) error {
+			if r, ok := edited(start); ok {
+				values = append(values, r.values...)
+			} else {
+				values = append(values, in[start:end])
+			}
+			return nil
+		})
+		return appendArray(nil, values), err
+	}
+
+	var builder objectBuilder
+	_, err := scanner.Members(in, 0, func(keyStart, keyEnd, valueStart, valueEnd int) error {
+		value := in[valueStart:valueEnd]
+		r, ok := edited(keyStart)
+		if !ok {
+			r, ok = edited(valueStart)
+		}
+		if ok {
+			if len(r.values) == 0 {
+				return nil
+			}
+			value = r.values[0]
+		}
+		builder.members = append(builder.members, member{raw: in[keyStart:keyEnd], value: value})
+		return nil
+	})
+	return builder.bytes(), err
+}
+
+// toEntries converts an object into an array of {"key": k, "value": v} objects; arrays use their indices as keys
+func toEntries(in []byte) ([]byte, error) {
+	k, err := kindOf(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries [][]byte
+	entry := func(key, value []byte) {
+		dst := make([]byte, 0, len(key)+len(value)+19)
+		dst = append(dst, `{"key":`...)
+		dst = append(dst, key...)
+		dst = append(dst, `,"value":`...)
+		dst = append(dst, value...)
+		entries = append(entries, append(dst, '}'))
+	}
+
+	switch k {
+	case kindObject:
+		err = eachMember(in, func(key, v []byte) error {
+			entry(key, v)
+			return nil
+		})
+	case kindArray:
+		err = eachElement(in, func(i int, v []byte) error {
+			entry(strconv.AppendInt(nil, int64(i), 10), v)
+			return nil
+		})
+	default:
+		return nil, fmt.Errorf("%v (%s) has no keys", k, truncate(in))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return appendArray(nil, entries), nil
+}
+
+// entryKeys are the names accepted for the key of an entry after key itself, as in jq
+var entryKeys = [][]byte{[]byte(`"k"`), []byte(`"name"`), []byte(`"Name"`), []byte(`"K"`), []byte(`"Key"`)}
+
+// fromEntries converts an array of {"key": k, "value": v} objects into an object; keys that aren't strings are
+// converted with tojson
+func fromEntries(in []byte) ([]byte, error) {
+	var builder objectBuilder
+	err := eachChild(in, nil, func(x []byte, _ path) error {
+		key, err := index(x, []byte(`"key"`))
+		if err != nil {
+			return err
+		}
+		if key[0] == 'n' {
+			for _, name := range entryKeys {
+				if key, err = index(x, name); err != nil || truthy(key) {
+					break
+				}
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if key[0] != '"' {
+			s, err := stringify(key)
+			if err != nil {
+				return err
+			}
+			key = appendQuoted(nil, s)
+		}
+
+		value, err := scanner.FindKey(x, 0, []byte("value"))
+		if errors.Is(err, scanner.ErrKeyNotFound) {
+			value, err = index(x, []byte(`"v"`))
+		}
+		if err != nil {
+			return err
+		}
+
+		name, err := decodeString(key)
+		if err != nil {
+			return err
+		}
+		builder.set(name, key, value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return builder.bytes(), nil
+}
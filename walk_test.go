@@ -0,0 +1,64 @@
+package jq_test
+
+import (
+	"testing"
+
+	"github.com/bubunyo/go-jq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkWalk(t *testing.B) {
+	op := jq.Must(jq.Parse(`walk(if type == "string" and . == "b" then "x" else . end)`))
+	data := []byte(`{"a": ["a", "b", {"c": "d"}], "e": {"f": [1, 2, 3]}}`)
+
+	for i := 0; i < t.N; i++ {
+		_, err := op.Apply(data)
+		require.NoError(t, err)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	testCases := map[string]struct {
+		In       string
+		Op       string
+		Expected string
+		HasError bool
+	}{
+		"walk identity":      {In: `{ "a" : [1, 2] }`, Op: `walk(.)`, Expected: `{ "a" : [1, 2] }`},
+		"walk numbers":       {In: `{"a": [1, {"b": 2}], "c": "x"}`, Op: `walk(if type == "number" then . + 1 else . end)`, Expected: `{"a": [2, {"b": 3}], "c": "x"}`},
+		"walk delete":        {In: `{"a": null, "b": {"c": null, "d": 1}}`, Op: `walk(if type == "object" then del(.[] | nulls) else . end)`, Expected: `{"b": {"d": 1}}`},
+		"walk empty":         {In: `[1, "a", 2]`, Op: `walk(if type == "string" then empty else . end)`, Expected: `[1,2]`},
+		"walk member empty":  {In: `{"a": 1, "b": "x"}`, Op: `walk(if type == "string" then empty else . end)`, Expected: `{"a":1}`},
+		"walk several":       {In: `[1]`, Op: `[walk(if type == "number" then (., .) else . end)]`, Expected: `[[1,1]]`},
+		"walk root":          {In: `1`, Op: `[walk(., .)]`, Expected: `[1,1]`},
+		"map_values":         {In: `{"a": 1, "b": 2}`, Op: `map_values(. * 2)`, Expected: `{"a": 2, "b": 4}`},
+		"map_values array":   {In: `[1, 2]`, Op: `map_values(. + 1)`, Expected: `[2, 3]`},
+		"map_values empty":   {In: `{"a": 1, "b": 2}`, Op: `map_values(select(. > 1))`, Expected: `{"b": 2}`},
+		"to_entries":         {In: `{"a": 1, "b": [2]}`, Op: `to_entries`, Expected: `[{"key":"a","value":1},{"key":"b","value":[2]}]`},
+		"to_entries array":   {In: `["x"]`, Op: `to_entries`, Expected: `[{"key":0,"value":"x"}]`},
+		"to_entries number":  {In: `1`, Op: `to_entries`, HasError: true},
+		"from_entries":       {In: `[{"key":"a","value":1},{"k":"b","v":2},{"name":"c"},{"key":1,"value":3},{"key":null,"value":4}]`, Op: `from_entries`, Expected: `{"a":1,"b":2,"c":null,"1":3,"null":4}`},
+		"from_entries false": {In: `[{"key":false,"value":1}]`, Op: `from_entries`, Expected: `{"false":1}`},
+		"from_entries value": {In: `[{"key":"a","value":null,"v":1}]`, Op: `from_entries`, Expected: `{"a":null}`},
+		"with_entries":       {In: `{"a": 1, "b": 2}`, Op: `with_entries(select(.value > 1) | .key += "x")`, Expected: `{"bx":2}`},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			op, err := jq.Parse(tc.Op)
+			if tc.HasError && err != nil {
+				return
+			}
+			require.NoError(t, err)
+
+			data, err := op.Apply([]byte(tc.In))
+			if tc.HasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(data))
+		})
+	}
+}